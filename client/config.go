@@ -3,6 +3,8 @@ package client
 import (
 	"fmt"
 
+	"github.com/pkg/errors"
+
 	"github.com/memprofiler/memprofiler/schema"
 	"github.com/memprofiler/memprofiler/utils"
 )
@@ -15,6 +17,8 @@ type Config struct {
 	InstanceDescription *schema.InstanceDescription `json:"instance_description" yaml:"instance_description"`
 	// Periodicity sets time interval between measurements
 	Periodicity *utils.Duration `json:"periodicity" yaml:"periodicity"`
+	// Callstack contains optional rules for callstack filtering and trimming
+	Callstack *CallstackConfig `json:"callstack" yaml:"callstack"`
 	// Verbose enables measurement printing into logger
 	Verbose bool `json:"verbose" yaml:"verbose"`
 }
@@ -30,5 +34,39 @@ func (c *Config) Verify() error {
 	if c.InstanceDescription.InstanceName == "" {
 		return fmt.Errorf("empty instance_description.instance_name")
 	}
+	if c.Callstack != nil {
+		if err := c.Callstack.Verify(); err != nil {
+			return errors.Wrap(err, "callstack")
+		}
+	}
 	return nil
 }
+
+// CallstackConfig defines which stack frames are taken into account when
+// the allocation callstack is built; the rules are applied before hashing,
+// so the stacks that differ only in filtered frames share the same ID
+type CallstackConfig struct {
+	// Include is a list of regular expressions; if not empty,
+	// only frames with matching function names are kept
+	Include []string `json:"include" yaml:"include"`
+	// Exclude is a list of regular expressions; frames
+	// with matching function names are dropped
+	Exclude []string `json:"exclude" yaml:"exclude"`
+	// MaxDepth limits the number of frames kept starting from the allocation site;
+	// zero value means no limit
+	MaxDepth int `json:"max_depth" yaml:"max_depth"`
+}
+
+// Verify checks the config
+func (c *CallstackConfig) Verify() error {
+	_, err := c.newFilter()
+	return err
+}
+
+// newFilter builds callstack filter; nil config corresponds to nil filter
+func (c *CallstackConfig) newFilter() (*utils.CallstackFilter, error) {
+	if c == nil {
+		return nil, nil
+	}
+	return utils.NewCallstackFilter(c.Include, c.Exclude, c.MaxDepth)
+}
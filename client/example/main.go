@@ -24,6 +24,11 @@ func Example() {
 		},
 		// granularity
 		Periodicity: &utils.Duration{Duration: time.Second},
+		// drop framework frames from allocation callstacks
+		Callstack: &client.CallstackConfig{
+			Exclude:  []string{`^net/http\.`, `^google\.golang\.org/grpc\.`},
+			MaxDepth: 16,
+		},
 		// logging setting
 		Verbose: false,
 	}
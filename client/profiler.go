@@ -30,6 +30,7 @@ type Profiler interface {
 type defaultProfiler struct {
 	stream     schema.MemprofilerBackend_SaveReportClient
 	limiter    *rate.Limiter
+	filter     *utils.CallstackFilter
 	clientConn *grpc.ClientConn
 	cfg        *Config
 	logger     Logger
@@ -98,7 +99,7 @@ func (p *defaultProfiler) measure() (*schema.Measurement, error) {
 	// iterate over profiler records, prepare structures to be sent to the server
 	for i := range records {
 		cs := &schema.Callstack{}
-		utils.FillCallstack(cs, records[i].Stack(), false)
+		utils.FillCallstack(cs, records[i].Stack(), false, p.filter)
 		cs.Id, err = utils.HashCallstack(cs)
 		if err != nil {
 			return nil, err
@@ -156,6 +157,11 @@ func NewProfiler(logger Logger, cfg *Config) (Profiler, error) {
 		return nil, errors.Wrap(err, "validate config")
 	}
 
+	filter, err := cfg.Callstack.newFilter()
+	if err != nil {
+		return nil, errors.Wrap(err, "build callstack filter")
+	}
+
 	// prepare GRPC client
 	clientConn, err := grpc.Dial(cfg.ServerEndpoint, grpc.WithInsecure(), grpc.WithBlock())
 	if err != nil {
@@ -172,6 +178,7 @@ func NewProfiler(logger Logger, cfg *Config) (Profiler, error) {
 	p := &defaultProfiler{
 		stream:     stream,
 		limiter:    rate.NewLimiter(rate.Every(cfg.Periodicity.Duration), 1),
+		filter:     filter,
 		logger:     logger,
 		clientConn: clientConn,
 		cfg:        cfg,
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/memprofiler/memprofiler/server/config"
+	"github.com/memprofiler/memprofiler/server/storage/data/filesystem"
+)
+
+// fsck checks data files of the filesystem storage; server must be stopped while it's running.
+// Exit code is 1 if damaged files were found and not repaired.
+func fsck(args []string) int {
+	flags := flag.NewFlagSet("fsck", flag.ExitOnError)
+	cfgPath := flags.String("c", "", "path to config file")
+	repair := flags.Bool("repair", false, "truncate torn tails of data files, rebuild indexes and remove temporary files")
+	verbose := flags.Bool("v", false, "report healthy files too")
+	_ = flags.Parse(args)
+
+	cfg, err := config.FromYAMLFile(*cfgPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cfg.DataStorage.Filesystem == nil {
+		log.Fatal("fsck is supported only for filesystem data storage")
+	}
+
+	reports, err := filesystem.Check(cfg.DataStorage.Filesystem, *repair)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var damaged, repaired, indexed int
+	for _, report := range reports {
+		if report.IndexRebuilt {
+			indexed++
+		}
+		switch {
+		case report.Repaired:
+			repaired++
+			fmt.Printf("%s: %v: truncated %d bytes, %d records left\n",
+				report.Path, report.Err, report.Size-report.ValidSize, report.Records)
+		case report.Damaged():
+			damaged++
+			fmt.Printf("%s: %v: %d bytes after %d valid records are damaged\n",
+				report.Path, report.Err, report.Size-report.ValidSize, report.Records)
+		case *verbose:
+			fmt.Printf("%s: ok, %d records\n", report.Path, report.Records)
+		}
+	}
+	fmt.Printf("%d files checked, %d damaged, %d repaired, %d indexes rebuilt\n",
+		len(reports), damaged+repaired, repaired, indexed)
+
+	if damaged > 0 {
+		return 1
+	}
+	return 0
+}
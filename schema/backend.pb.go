@@ -7,6 +7,7 @@ import (
 	context "context"
 	fmt "fmt"
 	proto "github.com/golang/protobuf/proto"
+	duration "github.com/golang/protobuf/ptypes/duration"
 	timestamp "github.com/golang/protobuf/ptypes/timestamp"
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
@@ -143,10 +144,12 @@ type Measurement struct {
 	// observed_at - measurement timestamp
 	ObservedAt *timestamp.Timestamp `protobuf:"bytes,1,opt,name=observed_at,json=observedAt,proto3" json:"observed_at,omitempty"`
 	// locations - list of known memory allocations occured in a process
-	Locations            []*Location `protobuf:"bytes,2,rep,name=locations,proto3" json:"locations,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
-	XXX_unrecognized     []byte      `json:"-"`
-	XXX_sizecache        int32       `json:"-"`
+	Locations []*Location `protobuf:"bytes,2,rep,name=locations,proto3" json:"locations,omitempty"`
+	// resolution - time span covered by the downsampled measurement; empty for raw measurements
+	Resolution           *duration.Duration `protobuf:"bytes,3,opt,name=resolution,proto3" json:"resolution,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
 }
 
 func (m *Measurement) Reset()         { *m = Measurement{} }
@@ -188,11 +191,23 @@ func (m *Measurement) GetLocations() []*Location {
 	return nil
 }
 
+func (m *Measurement) GetResolution() *duration.Duration {
+	if m != nil {
+		return m.Resolution
+	}
+	return nil
+}
+
 // Location contains memory allocation stats with
 // information about where memory was actually allocated
 type Location struct {
-	MemoryUsage          *MemoryUsage `protobuf:"bytes,1,opt,name=memory_usage,json=memoryUsage,proto3" json:"memory_usage,omitempty"`
-	Callstack            *Callstack   `protobuf:"bytes,2,opt,name=callstack,proto3" json:"callstack,omitempty"`
+	// memory_usage - for downsampled measurements contains the last values within the time span
+	MemoryUsage *MemoryUsage `protobuf:"bytes,1,opt,name=memory_usage,json=memoryUsage,proto3" json:"memory_usage,omitempty"`
+	Callstack   *Callstack   `protobuf:"bytes,2,opt,name=callstack,proto3" json:"callstack,omitempty"`
+	// min_memory_usage, max_memory_usage - the minimal and the maximal values within the time span,
+	// set for downsampled measurements only
+	MinMemoryUsage       *MemoryUsage `protobuf:"bytes,3,opt,name=min_memory_usage,json=minMemoryUsage,proto3" json:"min_memory_usage,omitempty"`
+	MaxMemoryUsage       *MemoryUsage `protobuf:"bytes,4,opt,name=max_memory_usage,json=maxMemoryUsage,proto3" json:"max_memory_usage,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
 	XXX_unrecognized     []byte       `json:"-"`
 	XXX_sizecache        int32        `json:"-"`
@@ -237,6 +252,20 @@ func (m *Location) GetCallstack() *Callstack {
 	return nil
 }
 
+func (m *Location) GetMinMemoryUsage() *MemoryUsage {
+	if m != nil {
+		return m.MinMemoryUsage
+	}
+	return nil
+}
+
+func (m *Location) GetMaxMemoryUsage() *MemoryUsage {
+	if m != nil {
+		return m.MaxMemoryUsage
+	}
+	return nil
+}
+
 // MemoryUsage contains memory usage stats for a particular call stack;
 // this stats comes directly from Go runtime
 type MemoryUsage struct {
@@ -421,38 +450,42 @@ func init() {
 func init() { proto.RegisterFile("backend.proto", fileDescriptor_5ab9ba5b8d8b2ba5) }
 
 var fileDescriptor_5ab9ba5b8d8b2ba5 = []byte{
-	// 494 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x93, 0xcf, 0x8e, 0xd3, 0x30,
-	0x10, 0xc6, 0xfb, 0x67, 0x29, 0x64, 0xd2, 0x45, 0xac, 0x77, 0x0f, 0x25, 0x08, 0xed, 0x12, 0x2e,
-	0x15, 0x87, 0x54, 0x2a, 0x12, 0x1c, 0x38, 0x51, 0x10, 0x5b, 0x24, 0x56, 0x20, 0x2f, 0x1c, 0x51,
-	0xe5, 0x38, 0xd3, 0x12, 0x36, 0xb6, 0x83, 0xed, 0xae, 0x54, 0xde, 0x83, 0x87, 0xe0, 0x2d, 0x91,
-	0x9d, 0xb8, 0xa9, 0x80, 0x9b, 0xf3, 0xcd, 0x2f, 0xdf, 0x7c, 0x33, 0xb2, 0xe1, 0x38, 0x67, 0xfc,
-	0x06, 0x65, 0x91, 0xd5, 0x5a, 0x59, 0x45, 0x46, 0x86, 0x7f, 0x43, 0xc1, 0x92, 0x31, 0x57, 0x42,
-	0x28, 0xd9, 0xa8, 0xc9, 0xf9, 0x46, 0xa9, 0x4d, 0x85, 0x33, 0xff, 0x95, 0x6f, 0xd7, 0x33, 0x5b,
-	0x0a, 0x34, 0x96, 0x89, 0xba, 0x01, 0xd2, 0xdf, 0x7d, 0x38, 0xb9, 0x66, 0xb7, 0x48, 0xb1, 0x56,
-	0xda, 0x52, 0xfc, 0xb1, 0x45, 0x63, 0xc9, 0x27, 0x38, 0x2b, 0xa5, 0xb1, 0x4c, 0x72, 0x5c, 0x15,
-	0x68, 0xb8, 0x2e, 0x6b, 0x5b, 0x2a, 0x39, 0xe9, 0x5f, 0xf4, 0xa7, 0xf1, 0xfc, 0x51, 0xd6, 0xf4,
-	0xca, 0xde, 0xb7, 0xcc, 0xdb, 0x0e, 0x59, 0xf6, 0xe8, 0x69, 0xf9, 0xaf, 0x4c, 0x5e, 0x42, 0x2c,
-	0x90, 0x99, 0xad, 0x46, 0x81, 0xd2, 0x4e, 0x06, 0xde, 0xe8, 0x34, 0x18, 0x5d, 0x75, 0xa5, 0x65,
-	0x8f, 0x1e, 0x92, 0x8b, 0x08, 0xee, 0xd6, 0x6c, 0x57, 0x29, 0x56, 0xa4, 0x67, 0x40, 0x0e, 0xa3,
-	0x9a, 0x5a, 0x49, 0x83, 0xe9, 0x4f, 0x88, 0x0f, 0x7e, 0x27, 0xaf, 0x20, 0x56, 0xb9, 0x41, 0x7d,
-	0x8b, 0xc5, 0x8a, 0xd9, 0x36, 0x71, 0x92, 0x35, 0x7b, 0xc8, 0xc2, 0x1e, 0xb2, 0xcf, 0x61, 0x0f,
-	0x14, 0x02, 0xfe, 0xda, 0x92, 0x0c, 0xa2, 0x4a, 0x71, 0xe6, 0x12, 0x9b, 0xc9, 0xe0, 0x62, 0x38,
-	0x8d, 0xe7, 0x0f, 0x42, 0xc6, 0x0f, 0x6d, 0x81, 0x76, 0x48, 0x6a, 0xe0, 0x5e, 0x90, 0xc9, 0x0b,
-	0x18, 0x0b, 0x14, 0x4a, 0xef, 0x56, 0x5b, 0xc3, 0x36, 0xd8, 0x76, 0x3e, 0x18, 0xd1, 0xd5, 0xbe,
-	0xb8, 0x92, 0x1b, 0x70, 0xff, 0x41, 0x66, 0x10, 0x71, 0x56, 0x55, 0xc6, 0x32, 0x7e, 0xd3, 0xee,
-	0xe5, 0x24, 0xfc, 0xf4, 0x26, 0x14, 0x68, 0xc7, 0xa4, 0xbf, 0xfa, 0x6e, 0xe2, 0xce, 0xe0, 0x29,
-	0x1c, 0xb3, 0xaa, 0x52, 0x7c, 0xa5, 0xf2, 0xef, 0xc8, 0xad, 0xf1, 0x9d, 0x87, 0x74, 0xec, 0xc5,
-	0x8f, 0x8d, 0x46, 0xce, 0x21, 0x6e, 0xa0, 0x7c, 0x67, 0xd1, 0xf8, 0x3e, 0x43, 0x0a, 0x5e, 0x5a,
-	0x38, 0x85, 0x3c, 0x81, 0xf1, 0x5a, 0x23, 0xee, 0x4d, 0x86, 0x9e, 0x88, 0x9d, 0x16, 0x3c, 0x1e,
-	0x03, 0x78, 0xa4, 0xb1, 0x38, 0xf2, 0x40, 0xe4, 0x14, 0xef, 0x90, 0x5e, 0x42, 0xb4, 0xcf, 0x4b,
-	0xee, 0xc3, 0xa0, 0x2c, 0x7c, 0x92, 0x88, 0x0e, 0xca, 0x82, 0x3c, 0x83, 0xd1, 0x5a, 0x33, 0x81,
-	0x61, 0xad, 0x24, 0x8c, 0x78, 0xed, 0xf0, 0x77, 0xae, 0x44, 0x5b, 0x22, 0x5d, 0x02, 0x74, 0x2a,
-	0x21, 0x70, 0x24, 0x99, 0xc0, 0xd6, 0xcb, 0x9f, 0x9d, 0xb6, 0x2e, 0x2b, 0xf4, 0x63, 0x44, 0xd4,
-	0x9f, 0x9d, 0x56, 0x95, 0x12, 0x7d, 0xf0, 0x3b, 0xd4, 0x9f, 0xe7, 0x5f, 0x81, 0x5c, 0xa1, 0xa8,
-	0xb5, 0x72, 0x84, 0x5e, 0x34, 0x0f, 0x86, 0x5c, 0x02, 0x74, 0xf7, 0x88, 0x3c, 0xdc, 0x27, 0xf9,
-	0xfb, 0x19, 0x24, 0xc9, 0xff, 0x4a, 0xed, 0xb5, 0xeb, 0x4d, 0xfb, 0xf9, 0xc8, 0x5f, 0xa7, 0xe7,
-	0x7f, 0x02, 0x00, 0x00, 0xff, 0xff, 0x54, 0xe7, 0xa8, 0x11, 0x8b, 0x03, 0x00, 0x00,
+	// 557 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x54, 0xd1, 0x6e, 0xd3, 0x30,
+	0x14, 0x6d, 0x9a, 0x52, 0xc8, 0x4d, 0x37, 0x6d, 0xde, 0x1e, 0xba, 0x22, 0xd8, 0x08, 0x2f, 0x15,
+	0x0f, 0xa9, 0x54, 0x24, 0x10, 0x42, 0x3c, 0x50, 0x26, 0x56, 0x24, 0x26, 0x90, 0x07, 0x8f, 0xa8,
+	0x72, 0x92, 0xdb, 0x12, 0x16, 0xc7, 0xc1, 0x76, 0xa6, 0xf5, 0x43, 0xf8, 0x08, 0x3e, 0x80, 0x0f,
+	0xe3, 0x0f, 0x50, 0x9c, 0xa4, 0xc9, 0x3a, 0xe0, 0x2d, 0x3e, 0xe7, 0xdc, 0xe3, 0x7b, 0x8f, 0xed,
+	0xc0, 0x4e, 0xc0, 0xc2, 0x4b, 0x4c, 0x23, 0x3f, 0x93, 0x42, 0x0b, 0xd2, 0x57, 0xe1, 0x57, 0xe4,
+	0x6c, 0x34, 0x08, 0x05, 0xe7, 0x22, 0x2d, 0xd1, 0xd1, 0xc3, 0x95, 0x10, 0xab, 0x04, 0x27, 0x66,
+	0x15, 0xe4, 0xcb, 0x49, 0x94, 0x4b, 0xa6, 0xe3, 0x0d, 0x7f, 0xbc, 0xcd, 0xeb, 0x98, 0xa3, 0xd2,
+	0x8c, 0x67, 0xa5, 0xc0, 0xfb, 0x69, 0xc1, 0xfe, 0x05, 0xbb, 0x42, 0x8a, 0x99, 0x90, 0x9a, 0xe2,
+	0xf7, 0x1c, 0x95, 0x26, 0x1f, 0xe1, 0x30, 0x4e, 0x95, 0x66, 0x69, 0x88, 0x8b, 0x08, 0x55, 0x28,
+	0xe3, 0xac, 0x30, 0x1d, 0x5a, 0x27, 0xd6, 0xd8, 0x9d, 0xde, 0xf7, 0xcb, 0x5e, 0xfc, 0x77, 0x95,
+	0xe6, 0xb4, 0x91, 0xcc, 0x3b, 0xf4, 0x20, 0xbe, 0x0d, 0x93, 0xe7, 0xe0, 0x72, 0x64, 0x2a, 0x97,
+	0xc8, 0x31, 0xd5, 0xc3, 0xae, 0x31, 0x3a, 0xa8, 0x8d, 0xce, 0x1b, 0x6a, 0xde, 0xa1, 0x6d, 0xe5,
+	0xcc, 0x81, 0xbb, 0x19, 0x5b, 0x27, 0x82, 0x45, 0xde, 0x21, 0x90, 0x76, 0xab, 0x2a, 0x13, 0xa9,
+	0x42, 0xef, 0x97, 0x05, 0x6e, 0xab, 0x9e, 0xbc, 0x04, 0x57, 0x04, 0x0a, 0xe5, 0x15, 0x46, 0x0b,
+	0xa6, 0xab, 0x96, 0x47, 0x7e, 0x19, 0x84, 0x5f, 0x07, 0xe1, 0x7f, 0xaa, 0x83, 0xa0, 0x50, 0xcb,
+	0x5f, 0x6b, 0xe2, 0x83, 0x93, 0x88, 0xd0, 0x24, 0xa8, 0x86, 0xdd, 0x13, 0x7b, 0xec, 0x4e, 0xf7,
+	0xea, 0x26, 0xdf, 0x57, 0x04, 0x6d, 0x24, 0xe4, 0x05, 0x80, 0x44, 0x25, 0x92, 0xdc, 0xc4, 0x63,
+	0x9b, 0xbd, 0x8e, 0x6e, 0xed, 0x75, 0x5a, 0x1d, 0x0a, 0x6d, 0x89, 0xbd, 0xdf, 0x16, 0xdc, 0xab,
+	0x2d, 0xc9, 0x33, 0x18, 0x70, 0xe4, 0x42, 0xae, 0x17, 0xb9, 0x62, 0x2b, 0x1c, 0x5a, 0xdb, 0xf9,
+	0x14, 0xdc, 0xe7, 0x82, 0x2a, 0xd2, 0xd9, 0x2c, 0xc8, 0x04, 0x9c, 0x90, 0x25, 0x89, 0xd2, 0x2c,
+	0xbc, 0xac, 0x42, 0xdd, 0xaf, 0x8b, 0xde, 0xd4, 0x04, 0x6d, 0x34, 0xe4, 0x15, 0xec, 0xf1, 0x38,
+	0x5d, 0xdc, 0xd8, 0xcc, 0xfe, 0xf7, 0x66, 0xbb, 0x3c, 0x4e, 0x5b, 0x6b, 0x53, 0xce, 0xae, 0x6f,
+	0x96, 0xf7, 0xfe, 0x57, 0xce, 0xae, 0x5b, 0x6b, 0xef, 0x87, 0x39, 0xab, 0xc6, 0xee, 0x31, 0xec,
+	0xb0, 0x24, 0x11, 0xe1, 0x42, 0x04, 0xdf, 0x30, 0xd4, 0xca, 0xcc, 0x6d, 0xd3, 0x81, 0x01, 0x3f,
+	0x94, 0x18, 0x39, 0x06, 0xb7, 0x14, 0x05, 0x6b, 0x8d, 0xca, 0x4c, 0x69, 0x53, 0x30, 0xd0, 0xac,
+	0x40, 0xc8, 0x23, 0x18, 0x2c, 0x25, 0xe2, 0xc6, 0xc4, 0x36, 0x0a, 0xb7, 0xc0, 0x6a, 0x8f, 0x07,
+	0x00, 0x46, 0x52, 0x5a, 0xf4, 0x8c, 0xc0, 0x29, 0x10, 0xe3, 0xe0, 0x9d, 0x81, 0xb3, 0x49, 0x8b,
+	0xec, 0x42, 0x37, 0x8e, 0x4c, 0x27, 0x0e, 0xed, 0xc6, 0x11, 0x79, 0x02, 0xfd, 0xa5, 0x64, 0x1c,
+	0xeb, 0x0b, 0x41, 0xea, 0x49, 0x2f, 0x0a, 0xf9, 0xdb, 0x82, 0xa2, 0x95, 0xc2, 0x9b, 0x03, 0x34,
+	0x28, 0x21, 0xd0, 0x4b, 0x19, 0xc7, 0xca, 0xab, 0x97, 0x56, 0xd8, 0x32, 0x4e, 0xd0, 0x8c, 0xe1,
+	0x50, 0xf3, 0x5d, 0x60, 0x49, 0x9c, 0x96, 0x07, 0x71, 0x87, 0x9a, 0xef, 0xe9, 0x17, 0x20, 0xe7,
+	0xc8, 0x33, 0x29, 0x0a, 0x85, 0x9c, 0x95, 0xff, 0x02, 0x72, 0x06, 0xd0, 0x3c, 0x01, 0x72, 0xb4,
+	0xe9, 0x64, 0xfb, 0x05, 0x8f, 0x46, 0x7f, 0xa3, 0xaa, 0x17, 0xd3, 0x19, 0x5b, 0x41, 0xdf, 0x5c,
+	0xce, 0xa7, 0x7f, 0x06, 0x00, 0x9a, 0x80, 0x88, 0xa0, 0x66, 0x04, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
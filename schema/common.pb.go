@@ -21,6 +21,30 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
+// AnnotationKind enumerates the types of session annotations
+type AnnotationKind int32
+
+const (
+	// CHANGE_POINT - the moment when the growth regime of in-use memory has changed
+	AnnotationKind_CHANGE_POINT AnnotationKind = 0
+)
+
+var AnnotationKind_name = map[int32]string{
+	0: "CHANGE_POINT",
+}
+
+var AnnotationKind_value = map[string]int32{
+	"CHANGE_POINT": 0,
+}
+
+func (x AnnotationKind) String() string {
+	return proto.EnumName(AnnotationKind_name, int32(x))
+}
+
+func (AnnotationKind) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_555bd8c177793206, []int{0}
+}
+
 // InstanceDescription describes a service instance whose memory stats is being tracked
 type InstanceDescription struct {
 	// type - general service description (kind, role and so on)
@@ -175,20 +199,81 @@ func (m *SessionMetadata) GetFinishedAt() *timestamp.Timestamp {
 	return nil
 }
 
+// SessionBounds summarizes session data stored on the server
+type SessionBounds struct {
+	// first_observed_at - time of the first measurement
+	FirstObservedAt *timestamp.Timestamp `protobuf:"bytes,1,opt,name=first_observed_at,json=firstObservedAt,proto3" json:"first_observed_at,omitempty"`
+	// last_observed_at - time of the last measurement
+	LastObservedAt *timestamp.Timestamp `protobuf:"bytes,2,opt,name=last_observed_at,json=lastObservedAt,proto3" json:"last_observed_at,omitempty"`
+	// callstacks - number of distinct callstacks observed during the session
+	Callstacks           uint32   `protobuf:"varint,3,opt,name=callstacks,proto3" json:"callstacks,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SessionBounds) Reset()         { *m = SessionBounds{} }
+func (m *SessionBounds) String() string { return proto.CompactTextString(m) }
+func (*SessionBounds) ProtoMessage()    {}
+func (*SessionBounds) Descriptor() ([]byte, []int) {
+	return fileDescriptor_555bd8c177793206, []int{3}
+}
+
+func (m *SessionBounds) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SessionBounds.Unmarshal(m, b)
+}
+func (m *SessionBounds) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SessionBounds.Marshal(b, m, deterministic)
+}
+func (m *SessionBounds) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SessionBounds.Merge(m, src)
+}
+func (m *SessionBounds) XXX_Size() int {
+	return xxx_messageInfo_SessionBounds.Size(m)
+}
+func (m *SessionBounds) XXX_DiscardUnknown() {
+	xxx_messageInfo_SessionBounds.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SessionBounds proto.InternalMessageInfo
+
+func (m *SessionBounds) GetFirstObservedAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.FirstObservedAt
+	}
+	return nil
+}
+
+func (m *SessionBounds) GetLastObservedAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.LastObservedAt
+	}
+	return nil
+}
+
+func (m *SessionBounds) GetCallstacks() uint32 {
+	if m != nil {
+		return m.Callstacks
+	}
+	return 0
+}
+
 // Session combines all available information about a memory tracking session
 type Session struct {
-	Description          *SessionDescription `protobuf:"bytes,1,opt,name=description,proto3" json:"description,omitempty"`
-	Metadata             *SessionMetadata    `protobuf:"bytes,2,opt,name=metadata,proto3" json:"metadata,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
-	XXX_unrecognized     []byte              `json:"-"`
-	XXX_sizecache        int32               `json:"-"`
+	Description *SessionDescription `protobuf:"bytes,1,opt,name=description,proto3" json:"description,omitempty"`
+	Metadata    *SessionMetadata    `protobuf:"bytes,2,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	// bounds - may be empty if session has no data yet
+	Bounds               *SessionBounds `protobuf:"bytes,3,opt,name=bounds,proto3" json:"bounds,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
 }
 
 func (m *Session) Reset()         { *m = Session{} }
 func (m *Session) String() string { return proto.CompactTextString(m) }
 func (*Session) ProtoMessage()    {}
 func (*Session) Descriptor() ([]byte, []int) {
-	return fileDescriptor_555bd8c177793206, []int{3}
+	return fileDescriptor_555bd8c177793206, []int{4}
 }
 
 func (m *Session) XXX_Unmarshal(b []byte) error {
@@ -223,34 +308,122 @@ func (m *Session) GetMetadata() *SessionMetadata {
 	return nil
 }
 
+func (m *Session) GetBounds() *SessionBounds {
+	if m != nil {
+		return m.Bounds
+	}
+	return nil
+}
+
+// Annotation marks a notable moment of a session
+type Annotation struct {
+	// observed_at - the moment of time annotation refers to
+	ObservedAt *timestamp.Timestamp `protobuf:"bytes,1,opt,name=observed_at,json=observedAt,proto3" json:"observed_at,omitempty"`
+	// kind - annotation type
+	Kind AnnotationKind `protobuf:"varint,2,opt,name=kind,proto3,enum=schema.AnnotationKind" json:"kind,omitempty"`
+	// callstack_id - the location annotation refers to; empty value means the whole session
+	CallstackId string `protobuf:"bytes,3,opt,name=callstack_id,json=callstackId,proto3" json:"callstack_id,omitempty"`
+	// description - human readable annotation text
+	Description          string   `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Annotation) Reset()         { *m = Annotation{} }
+func (m *Annotation) String() string { return proto.CompactTextString(m) }
+func (*Annotation) ProtoMessage()    {}
+func (*Annotation) Descriptor() ([]byte, []int) {
+	return fileDescriptor_555bd8c177793206, []int{5}
+}
+
+func (m *Annotation) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Annotation.Unmarshal(m, b)
+}
+func (m *Annotation) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Annotation.Marshal(b, m, deterministic)
+}
+func (m *Annotation) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Annotation.Merge(m, src)
+}
+func (m *Annotation) XXX_Size() int {
+	return xxx_messageInfo_Annotation.Size(m)
+}
+func (m *Annotation) XXX_DiscardUnknown() {
+	xxx_messageInfo_Annotation.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Annotation proto.InternalMessageInfo
+
+func (m *Annotation) GetObservedAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.ObservedAt
+	}
+	return nil
+}
+
+func (m *Annotation) GetKind() AnnotationKind {
+	if m != nil {
+		return m.Kind
+	}
+	return AnnotationKind_CHANGE_POINT
+}
+
+func (m *Annotation) GetCallstackId() string {
+	if m != nil {
+		return m.CallstackId
+	}
+	return ""
+}
+
+func (m *Annotation) GetDescription() string {
+	if m != nil {
+		return m.Description
+	}
+	return ""
+}
+
 func init() {
+	proto.RegisterEnum("schema.AnnotationKind", AnnotationKind_name, AnnotationKind_value)
 	proto.RegisterType((*InstanceDescription)(nil), "schema.InstanceDescription")
 	proto.RegisterType((*SessionDescription)(nil), "schema.SessionDescription")
 	proto.RegisterType((*SessionMetadata)(nil), "schema.SessionMetadata")
+	proto.RegisterType((*SessionBounds)(nil), "schema.SessionBounds")
 	proto.RegisterType((*Session)(nil), "schema.Session")
+	proto.RegisterType((*Annotation)(nil), "schema.Annotation")
 }
 
 func init() { proto.RegisterFile("common.proto", fileDescriptor_555bd8c177793206) }
 
 var fileDescriptor_555bd8c177793206 = []byte{
-	// 291 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x90, 0x3f, 0x4b, 0x33, 0x41,
-	0x10, 0xc6, 0xc9, 0xbd, 0x90, 0xd7, 0xcc, 0x9d, 0x0a, 0x1b, 0x41, 0x39, 0x0b, 0xf5, 0x6c, 0xac,
-	0x36, 0x60, 0x2a, 0xd1, 0x26, 0x60, 0x63, 0x61, 0x8a, 0xd5, 0x56, 0xc2, 0xe6, 0x6e, 0x92, 0x0c,
-	0xb8, 0xbb, 0xc7, 0xed, 0x68, 0xe5, 0x17, 0xf0, 0x5b, 0x8b, 0x77, 0xbb, 0x31, 0x98, 0x80, 0xed,
-	0xf0, 0x7b, 0xe6, 0xf9, 0x03, 0x59, 0xe9, 0x8c, 0x71, 0x56, 0xd6, 0x8d, 0x63, 0x27, 0xfa, 0xbe,
-	0x5c, 0xa1, 0xd1, 0xf9, 0xd9, 0xd2, 0xb9, 0xe5, 0x2b, 0x8e, 0xda, 0xeb, 0xfc, 0x6d, 0x31, 0x62,
-	0x32, 0xe8, 0x59, 0x9b, 0xba, 0x03, 0x8b, 0x17, 0x18, 0x3e, 0x58, 0xcf, 0xda, 0x96, 0x78, 0x8f,
-	0xbe, 0x6c, 0xa8, 0x66, 0x72, 0x56, 0x5c, 0x40, 0xe6, 0xb1, 0x79, 0xa7, 0x12, 0x67, 0x56, 0x1b,
-	0x3c, 0xe9, 0x9d, 0xf7, 0xae, 0x06, 0x2a, 0x0d, 0xb7, 0xa9, 0x36, 0x28, 0x2e, 0x61, 0x9f, 0x82,
-	0xb2, 0x63, 0x92, 0x96, 0xc9, 0xe2, 0xf1, 0x1b, 0x2a, 0x18, 0xc4, 0x13, 0x7a, 0x4f, 0xce, 0x6e,
-	0x7e, 0x9f, 0xc2, 0xd1, 0x5a, 0x5a, 0xfd, 0xdc, 0x5b, 0x97, 0xf4, 0xfa, 0x54, 0x76, 0xe1, 0xe5,
-	0x8e, 0x60, 0x6a, 0x48, 0x3b, 0xd2, 0x1e, 0x40, 0x42, 0x55, 0xeb, 0xff, 0x4f, 0x25, 0x54, 0x15,
-	0x9f, 0x3d, 0x38, 0x0c, 0xb6, 0x8f, 0xc8, 0xba, 0xd2, 0xac, 0xc5, 0x0d, 0x80, 0x67, 0xdd, 0x30,
-	0x56, 0x33, 0xcd, 0xc1, 0x29, 0x97, 0xdd, 0x3c, 0x32, 0xce, 0x23, 0x9f, 0xe3, 0x3c, 0x6a, 0x10,
-	0xe8, 0x09, 0x8b, 0x5b, 0x48, 0x17, 0x64, 0xc9, 0xaf, 0x3a, 0x6d, 0xf2, 0xa7, 0x16, 0x22, 0x3e,
-	0xe1, 0xe2, 0x03, 0xfe, 0x87, 0x28, 0xe2, 0x0e, 0xd2, 0xed, 0xb6, 0x79, 0x6c, 0xbb, 0xbd, 0x93,
-	0xda, 0xc4, 0xc5, 0x18, 0xf6, 0x4c, 0x28, 0x13, 0x22, 0x1c, 0xff, 0x92, 0xc6, 0xae, 0x6a, 0x0d,
-	0xce, 0xfb, 0x6d, 0xba, 0xf1, 0x57, 0x00, 0x00, 0x00, 0xff, 0xff, 0x7f, 0x2d, 0xb8, 0xd0, 0x1e,
-	0x02, 0x00, 0x00,
+	// 461 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x93, 0xcd, 0x6e, 0x13, 0x31,
+	0x14, 0x85, 0x99, 0xb4, 0x0a, 0xe4, 0x4e, 0x92, 0x06, 0x97, 0x9f, 0x2a, 0x48, 0x10, 0x86, 0x4d,
+	0x55, 0x89, 0xa9, 0x94, 0xae, 0x50, 0xd9, 0x0c, 0x94, 0x9f, 0x08, 0x91, 0x22, 0xd3, 0x2d, 0x1a,
+	0x39, 0x63, 0xa7, 0xb5, 0x3a, 0xb6, 0xa3, 0xf1, 0x2d, 0xef, 0xc0, 0xb3, 0xf0, 0x06, 0x48, 0xbc,
+	0x1b, 0x8a, 0xed, 0xc9, 0x5f, 0x2b, 0x95, 0x6e, 0x8f, 0xce, 0xb9, 0xf7, 0xd3, 0xb9, 0x36, 0xb4,
+	0x0b, 0xa3, 0x94, 0xd1, 0xe9, 0xac, 0x32, 0x68, 0x48, 0xd3, 0x16, 0x17, 0x42, 0xb1, 0xfe, 0x8b,
+	0x73, 0x63, 0xce, 0x4b, 0x71, 0xe8, 0xd4, 0xc9, 0xd5, 0xf4, 0x10, 0xa5, 0x12, 0x16, 0x99, 0x9a,
+	0x79, 0x63, 0xf2, 0x03, 0x76, 0x47, 0xda, 0x22, 0xd3, 0x85, 0x38, 0x11, 0xb6, 0xa8, 0xe4, 0x0c,
+	0xa5, 0xd1, 0xe4, 0x25, 0xb4, 0xad, 0xa8, 0x7e, 0xca, 0x42, 0xe4, 0x9a, 0x29, 0xb1, 0x17, 0x0d,
+	0xa2, 0xfd, 0x16, 0x8d, 0x83, 0x36, 0x66, 0x4a, 0x90, 0x57, 0xd0, 0x91, 0x21, 0xe9, 0x3d, 0x0d,
+	0xe7, 0x69, 0xd7, 0xe2, 0xdc, 0x94, 0x20, 0x90, 0xef, 0xc2, 0x5a, 0x69, 0xf4, 0xea, 0xf4, 0x31,
+	0x3c, 0x5a, 0x44, 0xf9, 0x52, 0x77, 0x5b, 0xe2, 0xe1, 0xb3, 0xd4, 0xc3, 0xa7, 0x37, 0x80, 0xd1,
+	0x5d, 0x79, 0x03, 0x6d, 0x17, 0x1a, 0x92, 0xbb, 0xfd, 0x5b, 0xb4, 0x21, 0x79, 0xf2, 0x2b, 0x82,
+	0x9d, 0xb0, 0xf6, 0xab, 0x40, 0xc6, 0x19, 0x32, 0xf2, 0x06, 0xc0, 0x22, 0xab, 0x50, 0xf0, 0x9c,
+	0x61, 0xd8, 0xd4, 0x4f, 0x7d, 0x3d, 0x69, 0x5d, 0x4f, 0x7a, 0x56, 0xd7, 0x43, 0x5b, 0xc1, 0x9d,
+	0x21, 0x39, 0x86, 0x78, 0x2a, 0xb5, 0xb4, 0x17, 0x3e, 0xdb, 0xb8, 0x35, 0x0b, 0xb5, 0x3d, 0xc3,
+	0xe4, 0x6f, 0x04, 0x9d, 0xc0, 0xf2, 0xce, 0x5c, 0x69, 0x6e, 0xc9, 0x47, 0x78, 0x38, 0x95, 0x95,
+	0xc5, 0xdc, 0x4c, 0xe6, 0x7d, 0xfe, 0x2f, 0xd0, 0x8e, 0x0b, 0x9d, 0x86, 0x4c, 0x86, 0xe4, 0x04,
+	0x7a, 0x25, 0xdb, 0x18, 0x73, 0x3b, 0x5b, 0xb7, 0x64, 0x6b, 0x53, 0x9e, 0x03, 0x14, 0xac, 0x2c,
+	0x2d, 0xb2, 0xe2, 0xd2, 0xee, 0x6d, 0x0d, 0xa2, 0xfd, 0x0e, 0x5d, 0x51, 0x92, 0xdf, 0x11, 0xdc,
+	0x0f, 0xfc, 0xe4, 0x2d, 0xc4, 0xd7, 0xcf, 0xd5, 0xaf, 0xcf, 0x75, 0xfd, 0xd0, 0x74, 0xd5, 0x4e,
+	0x8e, 0xe0, 0x81, 0x0a, 0xd7, 0x08, 0x9c, 0x4f, 0x37, 0xa2, 0xf5, 0xb1, 0xe8, 0xc2, 0x48, 0x5e,
+	0x43, 0x73, 0xe2, 0x6a, 0x73, 0x68, 0xf1, 0xf0, 0xf1, 0x46, 0xc4, 0x77, 0x4a, 0x83, 0x29, 0xf9,
+	0x13, 0x01, 0x64, 0x5a, 0x1b, 0x64, 0x6e, 0xe5, 0x31, 0xc4, 0x77, 0x2b, 0x19, 0xcc, 0xb2, 0x99,
+	0x03, 0xd8, 0xbe, 0x94, 0xda, 0xbf, 0xab, 0xee, 0xf0, 0x49, 0xbd, 0x78, 0x39, 0xfe, 0x8b, 0xd4,
+	0x9c, 0x3a, 0xcf, 0xfc, 0xbf, 0x2c, 0x3a, 0xcb, 0x25, 0x77, 0xb0, 0x2d, 0x1a, 0x2f, 0xb4, 0x11,
+	0x27, 0x83, 0xf5, 0xf2, 0xb6, 0xbd, 0x63, 0x45, 0x3a, 0x48, 0xa0, 0xbb, 0x3e, 0x9c, 0xf4, 0xa0,
+	0xfd, 0xfe, 0x73, 0x36, 0xfe, 0xf4, 0x21, 0xff, 0x76, 0x3a, 0x1a, 0x9f, 0xf5, 0xee, 0x4d, 0x9a,
+	0x0e, 0xfa, 0xe8, 0xdf, 0x00, 0x30, 0x70, 0xa3, 0xff, 0xef, 0x03, 0x00, 0x00,
 }
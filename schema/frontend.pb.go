@@ -8,6 +8,8 @@ import (
 	fmt "fmt"
 	proto "github.com/golang/protobuf/proto"
 	duration "github.com/golang/protobuf/ptypes/duration"
+	timestamp "github.com/golang/protobuf/ptypes/timestamp"
+	wrappers "github.com/golang/protobuf/ptypes/wrappers"
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
 	status "google.golang.org/grpc/status"
@@ -25,6 +27,127 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
+// MemoryIndicator enumerates memory consumption indicators
+type MemoryIndicator int32
+
+const (
+	MemoryIndicator_IN_USE_BYTES   MemoryIndicator = 0
+	MemoryIndicator_IN_USE_OBJECTS MemoryIndicator = 1
+	MemoryIndicator_ALLOC_BYTES    MemoryIndicator = 2
+	MemoryIndicator_ALLOC_OBJECTS  MemoryIndicator = 3
+	MemoryIndicator_FREE_BYTES     MemoryIndicator = 4
+	MemoryIndicator_FREE_OBJECTS   MemoryIndicator = 5
+	// IN_USE_BYTES_BASELINE - lower envelope (rolling minimum) of in-use bytes;
+	// it filters out oscillations caused by garbage collection, so its growth reflects leaks better
+	MemoryIndicator_IN_USE_BYTES_BASELINE MemoryIndicator = 6
+	// IN_USE_OBJECTS_BASELINE - lower envelope (rolling minimum) of in-use objects
+	MemoryIndicator_IN_USE_OBJECTS_BASELINE MemoryIndicator = 7
+)
+
+var MemoryIndicator_name = map[int32]string{
+	0: "IN_USE_BYTES",
+	1: "IN_USE_OBJECTS",
+	2: "ALLOC_BYTES",
+	3: "ALLOC_OBJECTS",
+	4: "FREE_BYTES",
+	5: "FREE_OBJECTS",
+	6: "IN_USE_BYTES_BASELINE",
+	7: "IN_USE_OBJECTS_BASELINE",
+}
+
+var MemoryIndicator_value = map[string]int32{
+	"IN_USE_BYTES":            0,
+	"IN_USE_OBJECTS":          1,
+	"ALLOC_BYTES":             2,
+	"ALLOC_OBJECTS":           3,
+	"FREE_BYTES":              4,
+	"FREE_OBJECTS":            5,
+	"IN_USE_BYTES_BASELINE":   6,
+	"IN_USE_OBJECTS_BASELINE": 7,
+}
+
+func (x MemoryIndicator) String() string {
+	return proto.EnumName(MemoryIndicator_name, int32(x))
+}
+
+func (MemoryIndicator) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{0}
+}
+
+// AggregationMode defines how locations are grouped: series of all locations
+// sharing the same key are summed up, and rates are computed over the summed series
+type AggregationMode int32
+
+const (
+	// CALLSTACK - no aggregation, every unique callstack is a separate location
+	AggregationMode_CALLSTACK AggregationMode = 0
+	// FUNCTION - locations are grouped by leaf function (where the allocation happened)
+	AggregationMode_FUNCTION AggregationMode = 1
+	// FILE - locations are grouped by the file of the leaf function
+	AggregationMode_FILE AggregationMode = 2
+	// PACKAGE - locations are grouped by the package of the leaf function
+	AggregationMode_PACKAGE AggregationMode = 3
+	// MODULE - locations are grouped by the first frame (starting from the allocation site)
+	// that belongs to the user's own code
+	AggregationMode_MODULE AggregationMode = 4
+)
+
+var AggregationMode_name = map[int32]string{
+	0: "CALLSTACK",
+	1: "FUNCTION",
+	2: "FILE",
+	3: "PACKAGE",
+	4: "MODULE",
+}
+
+var AggregationMode_value = map[string]int32{
+	"CALLSTACK": 0,
+	"FUNCTION":  1,
+	"FILE":      2,
+	"PACKAGE":   3,
+	"MODULE":    4,
+}
+
+func (x AggregationMode) String() string {
+	return proto.EnumName(AggregationMode_name, int32(x))
+}
+
+func (AggregationMode) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{1}
+}
+
+// TrendEstimator enumerates methods used to estimate rates
+type TrendEstimator int32
+
+const (
+	// OLS - slope of ordinary least squares linear regression
+	TrendEstimator_OLS TrendEstimator = 0
+	// THEIL_SEN - median of slopes between all pairs of points, robust to outliers
+	TrendEstimator_THEIL_SEN TrendEstimator = 1
+	// EWMA - exponentially weighted moving average of the first differences
+	TrendEstimator_EWMA TrendEstimator = 2
+)
+
+var TrendEstimator_name = map[int32]string{
+	0: "OLS",
+	1: "THEIL_SEN",
+	2: "EWMA",
+}
+
+var TrendEstimator_value = map[string]int32{
+	"OLS":       0,
+	"THEIL_SEN": 1,
+	"EWMA":      2,
+}
+
+func (x TrendEstimator) String() string {
+	return proto.EnumName(TrendEstimator_name, int32(x))
+}
+
+func (TrendEstimator) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{2}
+}
+
 // GetServicesRequest is a request body for GetServices method
 type GetServicesRequest struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
@@ -264,10 +387,33 @@ func (m *GetSessionsResponse) GetSessions() []*Session {
 
 // SubscribeForSessionRequest is a request body for SubscribeForSession request
 type SubscribeForSessionRequest struct {
-	Session              *SessionDescription `protobuf:"bytes,1,opt,name=session,proto3" json:"session,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
-	XXX_unrecognized     []byte              `json:"-"`
-	XXX_sizecache        int32               `json:"-"`
+	Session *SessionDescription `protobuf:"bytes,1,opt,name=session,proto3" json:"session,omitempty"`
+	// aggregation - defines how callstacks are grouped before rate computation
+	Aggregation AggregationMode `protobuf:"varint,2,opt,name=aggregation,proto3,enum=schema.AggregationMode" json:"aggregation,omitempty"`
+	// module_prefix - function name prefix of the user's own code (like "github.com/org/project"),
+	// required for MODULE aggregation mode
+	ModulePrefix string `protobuf:"bytes,3,opt,name=module_prefix,json=modulePrefix,proto3" json:"module_prefix,omitempty"`
+	// sort_by - memory indicator whose rate is used to sort locations (in descending order)
+	// and to compare with min_rate threshold
+	SortBy MemoryIndicator `protobuf:"varint,4,opt,name=sort_by,json=sortBy,proto3,enum=schema.MemoryIndicator" json:"sort_by,omitempty"`
+	// averaging_window - span of the rates used for sorting and thresholding;
+	// must be one of the server averaging windows, the shortest one is used by default
+	AveragingWindow *duration.Duration `protobuf:"bytes,5,opt,name=averaging_window,json=averagingWindow,proto3" json:"averaging_window,omitempty"`
+	// offset - number of top locations to skip
+	Offset uint32 `protobuf:"varint,6,opt,name=offset,proto3" json:"offset,omitempty"`
+	// limit - maximum number of locations sent within a single update, 0 means no limit
+	Limit uint32 `protobuf:"varint,7,opt,name=limit,proto3" json:"limit,omitempty"`
+	// min_rate - if set, locations with lower rate values are skipped
+	MinRate *wrappers.DoubleValue `protobuf:"bytes,8,opt,name=min_rate,json=minRate,proto3" json:"min_rate,omitempty"`
+	// filter - if set, only locations with at least one stack frame
+	// containing this substring in function name or file name are sent
+	Filter string `protobuf:"bytes,9,opt,name=filter,proto3" json:"filter,omitempty"`
+	// recompute_interval - minimal time between two consecutive updates;
+	// it can't be shorter than the server recompute interval, which is used by default
+	RecomputeInterval    *duration.Duration `protobuf:"bytes,10,opt,name=recompute_interval,json=recomputeInterval,proto3" json:"recompute_interval,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
 }
 
 func (m *SubscribeForSessionRequest) Reset()         { *m = SubscribeForSessionRequest{} }
@@ -302,6 +448,291 @@ func (m *SubscribeForSessionRequest) GetSession() *SessionDescription {
 	return nil
 }
 
+func (m *SubscribeForSessionRequest) GetAggregation() AggregationMode {
+	if m != nil {
+		return m.Aggregation
+	}
+	return AggregationMode_CALLSTACK
+}
+
+func (m *SubscribeForSessionRequest) GetModulePrefix() string {
+	if m != nil {
+		return m.ModulePrefix
+	}
+	return ""
+}
+
+func (m *SubscribeForSessionRequest) GetSortBy() MemoryIndicator {
+	if m != nil {
+		return m.SortBy
+	}
+	return MemoryIndicator_IN_USE_BYTES
+}
+
+func (m *SubscribeForSessionRequest) GetAveragingWindow() *duration.Duration {
+	if m != nil {
+		return m.AveragingWindow
+	}
+	return nil
+}
+
+func (m *SubscribeForSessionRequest) GetOffset() uint32 {
+	if m != nil {
+		return m.Offset
+	}
+	return 0
+}
+
+func (m *SubscribeForSessionRequest) GetLimit() uint32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+func (m *SubscribeForSessionRequest) GetMinRate() *wrappers.DoubleValue {
+	if m != nil {
+		return m.MinRate
+	}
+	return nil
+}
+
+func (m *SubscribeForSessionRequest) GetFilter() string {
+	if m != nil {
+		return m.Filter
+	}
+	return ""
+}
+
+func (m *SubscribeForSessionRequest) GetRecomputeInterval() *duration.Duration {
+	if m != nil {
+		return m.RecomputeInterval
+	}
+	return nil
+}
+
+// SubscribeForServiceRequest is a request body for SubscribeForService request;
+// locations filters are the same as for SubscribeForSessionRequest
+type SubscribeForServiceRequest struct {
+	// service - identifier for a group of similar services
+	Service         string                `protobuf:"bytes,1,opt,name=service,proto3" json:"service,omitempty"`
+	Aggregation     AggregationMode       `protobuf:"varint,2,opt,name=aggregation,proto3,enum=schema.AggregationMode" json:"aggregation,omitempty"`
+	ModulePrefix    string                `protobuf:"bytes,3,opt,name=module_prefix,json=modulePrefix,proto3" json:"module_prefix,omitempty"`
+	SortBy          MemoryIndicator       `protobuf:"varint,4,opt,name=sort_by,json=sortBy,proto3,enum=schema.MemoryIndicator" json:"sort_by,omitempty"`
+	AveragingWindow *duration.Duration    `protobuf:"bytes,5,opt,name=averaging_window,json=averagingWindow,proto3" json:"averaging_window,omitempty"`
+	Offset          uint32                `protobuf:"varint,6,opt,name=offset,proto3" json:"offset,omitempty"`
+	Limit           uint32                `protobuf:"varint,7,opt,name=limit,proto3" json:"limit,omitempty"`
+	MinRate         *wrappers.DoubleValue `protobuf:"bytes,8,opt,name=min_rate,json=minRate,proto3" json:"min_rate,omitempty"`
+	Filter          string                `protobuf:"bytes,9,opt,name=filter,proto3" json:"filter,omitempty"`
+	// breakdown - if set, metrics of every instance are sent along with the merged ones
+	Breakdown            bool               `protobuf:"varint,10,opt,name=breakdown,proto3" json:"breakdown,omitempty"`
+	RecomputeInterval    *duration.Duration `protobuf:"bytes,11,opt,name=recompute_interval,json=recomputeInterval,proto3" json:"recompute_interval,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *SubscribeForServiceRequest) Reset()         { *m = SubscribeForServiceRequest{} }
+func (m *SubscribeForServiceRequest) String() string { return proto.CompactTextString(m) }
+func (*SubscribeForServiceRequest) ProtoMessage()    {}
+func (*SubscribeForServiceRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{7}
+}
+
+func (m *SubscribeForServiceRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SubscribeForServiceRequest.Unmarshal(m, b)
+}
+func (m *SubscribeForServiceRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SubscribeForServiceRequest.Marshal(b, m, deterministic)
+}
+func (m *SubscribeForServiceRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SubscribeForServiceRequest.Merge(m, src)
+}
+func (m *SubscribeForServiceRequest) XXX_Size() int {
+	return xxx_messageInfo_SubscribeForServiceRequest.Size(m)
+}
+func (m *SubscribeForServiceRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SubscribeForServiceRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SubscribeForServiceRequest proto.InternalMessageInfo
+
+func (m *SubscribeForServiceRequest) GetService() string {
+	if m != nil {
+		return m.Service
+	}
+	return ""
+}
+
+func (m *SubscribeForServiceRequest) GetAggregation() AggregationMode {
+	if m != nil {
+		return m.Aggregation
+	}
+	return AggregationMode_CALLSTACK
+}
+
+func (m *SubscribeForServiceRequest) GetModulePrefix() string {
+	if m != nil {
+		return m.ModulePrefix
+	}
+	return ""
+}
+
+func (m *SubscribeForServiceRequest) GetSortBy() MemoryIndicator {
+	if m != nil {
+		return m.SortBy
+	}
+	return MemoryIndicator_IN_USE_BYTES
+}
+
+func (m *SubscribeForServiceRequest) GetAveragingWindow() *duration.Duration {
+	if m != nil {
+		return m.AveragingWindow
+	}
+	return nil
+}
+
+func (m *SubscribeForServiceRequest) GetOffset() uint32 {
+	if m != nil {
+		return m.Offset
+	}
+	return 0
+}
+
+func (m *SubscribeForServiceRequest) GetLimit() uint32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+func (m *SubscribeForServiceRequest) GetMinRate() *wrappers.DoubleValue {
+	if m != nil {
+		return m.MinRate
+	}
+	return nil
+}
+
+func (m *SubscribeForServiceRequest) GetFilter() string {
+	if m != nil {
+		return m.Filter
+	}
+	return ""
+}
+
+func (m *SubscribeForServiceRequest) GetBreakdown() bool {
+	if m != nil {
+		return m.Breakdown
+	}
+	return false
+}
+
+func (m *SubscribeForServiceRequest) GetRecomputeInterval() *duration.Duration {
+	if m != nil {
+		return m.RecomputeInterval
+	}
+	return nil
+}
+
+// ServiceMetrics contains metrics merged across several sessions of a service
+type ServiceMetrics struct {
+	// total - rates of the same locations (callstacks) of all sessions are summed up;
+	// fit quality is not available for the merged rates
+	Total *SessionMetrics `protobuf:"bytes,1,opt,name=total,proto3" json:"total,omitempty"`
+	// instances - per-instance metrics, sent only if breakdown is requested
+	Instances            []*InstanceMetrics `protobuf:"bytes,2,rep,name=instances,proto3" json:"instances,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *ServiceMetrics) Reset()         { *m = ServiceMetrics{} }
+func (m *ServiceMetrics) String() string { return proto.CompactTextString(m) }
+func (*ServiceMetrics) ProtoMessage()    {}
+func (*ServiceMetrics) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{8}
+}
+
+func (m *ServiceMetrics) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ServiceMetrics.Unmarshal(m, b)
+}
+func (m *ServiceMetrics) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ServiceMetrics.Marshal(b, m, deterministic)
+}
+func (m *ServiceMetrics) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ServiceMetrics.Merge(m, src)
+}
+func (m *ServiceMetrics) XXX_Size() int {
+	return xxx_messageInfo_ServiceMetrics.Size(m)
+}
+func (m *ServiceMetrics) XXX_DiscardUnknown() {
+	xxx_messageInfo_ServiceMetrics.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ServiceMetrics proto.InternalMessageInfo
+
+func (m *ServiceMetrics) GetTotal() *SessionMetrics {
+	if m != nil {
+		return m.Total
+	}
+	return nil
+}
+
+func (m *ServiceMetrics) GetInstances() []*InstanceMetrics {
+	if m != nil {
+		return m.Instances
+	}
+	return nil
+}
+
+// InstanceMetrics contains metrics of a single service instance session
+type InstanceMetrics struct {
+	Session              *SessionDescription `protobuf:"bytes,1,opt,name=session,proto3" json:"session,omitempty"`
+	Metrics              *SessionMetrics     `protobuf:"bytes,2,opt,name=metrics,proto3" json:"metrics,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
+	XXX_unrecognized     []byte              `json:"-"`
+	XXX_sizecache        int32               `json:"-"`
+}
+
+func (m *InstanceMetrics) Reset()         { *m = InstanceMetrics{} }
+func (m *InstanceMetrics) String() string { return proto.CompactTextString(m) }
+func (*InstanceMetrics) ProtoMessage()    {}
+func (*InstanceMetrics) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{9}
+}
+
+func (m *InstanceMetrics) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_InstanceMetrics.Unmarshal(m, b)
+}
+func (m *InstanceMetrics) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_InstanceMetrics.Marshal(b, m, deterministic)
+}
+func (m *InstanceMetrics) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_InstanceMetrics.Merge(m, src)
+}
+func (m *InstanceMetrics) XXX_Size() int {
+	return xxx_messageInfo_InstanceMetrics.Size(m)
+}
+func (m *InstanceMetrics) XXX_DiscardUnknown() {
+	xxx_messageInfo_InstanceMetrics.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_InstanceMetrics proto.InternalMessageInfo
+
+func (m *InstanceMetrics) GetSession() *SessionDescription {
+	if m != nil {
+		return m.Session
+	}
+	return nil
+}
+
+func (m *InstanceMetrics) GetMetrics() *SessionMetrics {
+	if m != nil {
+		return m.Metrics
+	}
+	return nil
+}
+
 // MemoryUtilizationRate is a collection of rate values for memory consumption indicators.
 // Formally, the rate (or velocity) is the first time derivative of any memory consumption indicator.
 // For Bytes rate units are bytes per second, for Objects rate units are units per second
@@ -309,17 +740,21 @@ type MemoryUtilizationRate struct {
 	// span is a time span that is used to compute rates
 	Span *duration.Duration `protobuf:"bytes,1,opt,name=span,proto3" json:"span,omitempty"`
 	// values contains actual rates for a specified time span
-	Values               *MemoryUtilizationRate_Values `protobuf:"bytes,2,opt,name=values,proto3" json:"values,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}                      `json:"-"`
-	XXX_unrecognized     []byte                        `json:"-"`
-	XXX_sizecache        int32                         `json:"-"`
+	Values *MemoryUtilizationRate_Values `protobuf:"bytes,2,opt,name=values,proto3" json:"values,omitempty"`
+	// estimator - method used to estimate rates
+	Estimator TrendEstimator `protobuf:"varint,3,opt,name=estimator,proto3,enum=schema.TrendEstimator" json:"estimator,omitempty"`
+	// quality helps to decide if rates are reliable
+	Quality              *MemoryUtilizationRate_Quality `protobuf:"bytes,4,opt,name=quality,proto3" json:"quality,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                       `json:"-"`
+	XXX_unrecognized     []byte                         `json:"-"`
+	XXX_sizecache        int32                          `json:"-"`
 }
 
 func (m *MemoryUtilizationRate) Reset()         { *m = MemoryUtilizationRate{} }
 func (m *MemoryUtilizationRate) String() string { return proto.CompactTextString(m) }
 func (*MemoryUtilizationRate) ProtoMessage()    {}
 func (*MemoryUtilizationRate) Descriptor() ([]byte, []int) {
-	return fileDescriptor_eca3873955a29cfe, []int{7}
+	return fileDescriptor_eca3873955a29cfe, []int{10}
 }
 
 func (m *MemoryUtilizationRate) XXX_Unmarshal(b []byte) error {
@@ -354,6 +789,20 @@ func (m *MemoryUtilizationRate) GetValues() *MemoryUtilizationRate_Values {
 	return nil
 }
 
+func (m *MemoryUtilizationRate) GetEstimator() TrendEstimator {
+	if m != nil {
+		return m.Estimator
+	}
+	return TrendEstimator_OLS
+}
+
+func (m *MemoryUtilizationRate) GetQuality() *MemoryUtilizationRate_Quality {
+	if m != nil {
+		return m.Quality
+	}
+	return nil
+}
+
 // Values is a set of rate values
 type MemoryUtilizationRate_Values struct {
 	AllocObjects         float64  `protobuf:"fixed64,1,opt,name=alloc_objects,json=allocObjects,proto3" json:"alloc_objects,omitempty"`
@@ -362,6 +811,8 @@ type MemoryUtilizationRate_Values struct {
 	FreeBytes            float64  `protobuf:"fixed64,4,opt,name=free_bytes,json=freeBytes,proto3" json:"free_bytes,omitempty"`
 	InUseObjects         float64  `protobuf:"fixed64,5,opt,name=in_use_objects,json=inUseObjects,proto3" json:"in_use_objects,omitempty"`
 	InUseBytes           float64  `protobuf:"fixed64,6,opt,name=in_use_bytes,json=inUseBytes,proto3" json:"in_use_bytes,omitempty"`
+	InUseBytesBaseline   float64  `protobuf:"fixed64,7,opt,name=in_use_bytes_baseline,json=inUseBytesBaseline,proto3" json:"in_use_bytes_baseline,omitempty"`
+	InUseObjectsBaseline float64  `protobuf:"fixed64,8,opt,name=in_use_objects_baseline,json=inUseObjectsBaseline,proto3" json:"in_use_objects_baseline,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -371,7 +822,7 @@ func (m *MemoryUtilizationRate_Values) Reset()         { *m = MemoryUtilizationR
 func (m *MemoryUtilizationRate_Values) String() string { return proto.CompactTextString(m) }
 func (*MemoryUtilizationRate_Values) ProtoMessage()    {}
 func (*MemoryUtilizationRate_Values) Descriptor() ([]byte, []int) {
-	return fileDescriptor_eca3873955a29cfe, []int{7, 0}
+	return fileDescriptor_eca3873955a29cfe, []int{10, 0}
 }
 
 func (m *MemoryUtilizationRate_Values) XXX_Unmarshal(b []byte) error {
@@ -434,47 +885,216 @@ func (m *MemoryUtilizationRate_Values) GetInUseBytes() float64 {
 	return 0
 }
 
-// LocationMetrics is a set of memory allocation statistics
-// that happened on a particular line of source code
-type LocationMetrics struct {
-	// rates represents memory consumption rates estimated
-	// for some averaging window defined by server
-	Rates []*MemoryUtilizationRate `protobuf:"bytes,1,rep,name=rates,proto3" json:"rates,omitempty"`
-	// callstack describes location in code where the allocation happened
-	Callstack            *Callstack `protobuf:"bytes,2,opt,name=callstack,proto3" json:"callstack,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
-	XXX_unrecognized     []byte     `json:"-"`
-	XXX_sizecache        int32      `json:"-"`
+func (m *MemoryUtilizationRate_Values) GetInUseBytesBaseline() float64 {
+	if m != nil {
+		return m.InUseBytesBaseline
+	}
+	return 0
 }
 
-func (m *LocationMetrics) Reset()         { *m = LocationMetrics{} }
-func (m *LocationMetrics) String() string { return proto.CompactTextString(m) }
-func (*LocationMetrics) ProtoMessage()    {}
-func (*LocationMetrics) Descriptor() ([]byte, []int) {
-	return fileDescriptor_eca3873955a29cfe, []int{8}
+func (m *MemoryUtilizationRate_Values) GetInUseObjectsBaseline() float64 {
+	if m != nil {
+		return m.InUseObjectsBaseline
+	}
+	return 0
 }
 
-func (m *LocationMetrics) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_LocationMetrics.Unmarshal(m, b)
+// Quality is a set of goodness-of-fit values, one per every rate value
+type MemoryUtilizationRate_Quality struct {
+	AllocObjects         *FitQuality `protobuf:"bytes,1,opt,name=alloc_objects,json=allocObjects,proto3" json:"alloc_objects,omitempty"`
+	AllocBytes           *FitQuality `protobuf:"bytes,2,opt,name=alloc_bytes,json=allocBytes,proto3" json:"alloc_bytes,omitempty"`
+	FreeObjects          *FitQuality `protobuf:"bytes,3,opt,name=free_objects,json=freeObjects,proto3" json:"free_objects,omitempty"`
+	FreeBytes            *FitQuality `protobuf:"bytes,4,opt,name=free_bytes,json=freeBytes,proto3" json:"free_bytes,omitempty"`
+	InUseObjects         *FitQuality `protobuf:"bytes,5,opt,name=in_use_objects,json=inUseObjects,proto3" json:"in_use_objects,omitempty"`
+	InUseBytes           *FitQuality `protobuf:"bytes,6,opt,name=in_use_bytes,json=inUseBytes,proto3" json:"in_use_bytes,omitempty"`
+	InUseBytesBaseline   *FitQuality `protobuf:"bytes,7,opt,name=in_use_bytes_baseline,json=inUseBytesBaseline,proto3" json:"in_use_bytes_baseline,omitempty"`
+	InUseObjectsBaseline *FitQuality `protobuf:"bytes,8,opt,name=in_use_objects_baseline,json=inUseObjectsBaseline,proto3" json:"in_use_objects_baseline,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
 }
-func (m *LocationMetrics) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_LocationMetrics.Marshal(b, m, deterministic)
+
+func (m *MemoryUtilizationRate_Quality) Reset()         { *m = MemoryUtilizationRate_Quality{} }
+func (m *MemoryUtilizationRate_Quality) String() string { return proto.CompactTextString(m) }
+func (*MemoryUtilizationRate_Quality) ProtoMessage()    {}
+func (*MemoryUtilizationRate_Quality) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{10, 1}
 }
-func (m *LocationMetrics) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_LocationMetrics.Merge(m, src)
+
+func (m *MemoryUtilizationRate_Quality) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MemoryUtilizationRate_Quality.Unmarshal(m, b)
 }
-func (m *LocationMetrics) XXX_Size() int {
-	return xxx_messageInfo_LocationMetrics.Size(m)
+func (m *MemoryUtilizationRate_Quality) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MemoryUtilizationRate_Quality.Marshal(b, m, deterministic)
 }
-func (m *LocationMetrics) XXX_DiscardUnknown() {
-	xxx_messageInfo_LocationMetrics.DiscardUnknown(m)
+func (m *MemoryUtilizationRate_Quality) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MemoryUtilizationRate_Quality.Merge(m, src)
+}
+func (m *MemoryUtilizationRate_Quality) XXX_Size() int {
+	return xxx_messageInfo_MemoryUtilizationRate_Quality.Size(m)
+}
+func (m *MemoryUtilizationRate_Quality) XXX_DiscardUnknown() {
+	xxx_messageInfo_MemoryUtilizationRate_Quality.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_LocationMetrics proto.InternalMessageInfo
+var xxx_messageInfo_MemoryUtilizationRate_Quality proto.InternalMessageInfo
 
-func (m *LocationMetrics) GetRates() []*MemoryUtilizationRate {
+func (m *MemoryUtilizationRate_Quality) GetAllocObjects() *FitQuality {
 	if m != nil {
-		return m.Rates
+		return m.AllocObjects
+	}
+	return nil
+}
+
+func (m *MemoryUtilizationRate_Quality) GetAllocBytes() *FitQuality {
+	if m != nil {
+		return m.AllocBytes
+	}
+	return nil
+}
+
+func (m *MemoryUtilizationRate_Quality) GetFreeObjects() *FitQuality {
+	if m != nil {
+		return m.FreeObjects
+	}
+	return nil
+}
+
+func (m *MemoryUtilizationRate_Quality) GetFreeBytes() *FitQuality {
+	if m != nil {
+		return m.FreeBytes
+	}
+	return nil
+}
+
+func (m *MemoryUtilizationRate_Quality) GetInUseObjects() *FitQuality {
+	if m != nil {
+		return m.InUseObjects
+	}
+	return nil
+}
+
+func (m *MemoryUtilizationRate_Quality) GetInUseBytes() *FitQuality {
+	if m != nil {
+		return m.InUseBytes
+	}
+	return nil
+}
+
+func (m *MemoryUtilizationRate_Quality) GetInUseBytesBaseline() *FitQuality {
+	if m != nil {
+		return m.InUseBytesBaseline
+	}
+	return nil
+}
+
+func (m *MemoryUtilizationRate_Quality) GetInUseObjectsBaseline() *FitQuality {
+	if m != nil {
+		return m.InUseObjectsBaseline
+	}
+	return nil
+}
+
+// FitQuality describes how well the estimated trend fits the time series
+type FitQuality struct {
+	// r_squared - coefficient of determination (1 means perfect fit)
+	RSquared float64 `protobuf:"fixed64,1,opt,name=r_squared,json=rSquared,proto3" json:"r_squared,omitempty"`
+	// standard_error - standard error of the rate
+	StandardError float64 `protobuf:"fixed64,2,opt,name=standard_error,json=standardError,proto3" json:"standard_error,omitempty"`
+	// samples - number of points within averaging window
+	Samples              uint32   `protobuf:"varint,3,opt,name=samples,proto3" json:"samples,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FitQuality) Reset()         { *m = FitQuality{} }
+func (m *FitQuality) String() string { return proto.CompactTextString(m) }
+func (*FitQuality) ProtoMessage()    {}
+func (*FitQuality) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{11}
+}
+
+func (m *FitQuality) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FitQuality.Unmarshal(m, b)
+}
+func (m *FitQuality) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FitQuality.Marshal(b, m, deterministic)
+}
+func (m *FitQuality) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FitQuality.Merge(m, src)
+}
+func (m *FitQuality) XXX_Size() int {
+	return xxx_messageInfo_FitQuality.Size(m)
+}
+func (m *FitQuality) XXX_DiscardUnknown() {
+	xxx_messageInfo_FitQuality.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FitQuality proto.InternalMessageInfo
+
+func (m *FitQuality) GetRSquared() float64 {
+	if m != nil {
+		return m.RSquared
+	}
+	return 0
+}
+
+func (m *FitQuality) GetStandardError() float64 {
+	if m != nil {
+		return m.StandardError
+	}
+	return 0
+}
+
+func (m *FitQuality) GetSamples() uint32 {
+	if m != nil {
+		return m.Samples
+	}
+	return 0
+}
+
+// LocationMetrics is a set of memory allocation statistics
+// that happened on a particular line of source code
+type LocationMetrics struct {
+	// rates represents memory consumption rates estimated
+	// for some averaging window defined by server
+	Rates []*MemoryUtilizationRate `protobuf:"bytes,1,rep,name=rates,proto3" json:"rates,omitempty"`
+	// callstack describes location in code where the allocation happened
+	Callstack            *Callstack `protobuf:"bytes,2,opt,name=callstack,proto3" json:"callstack,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *LocationMetrics) Reset()         { *m = LocationMetrics{} }
+func (m *LocationMetrics) String() string { return proto.CompactTextString(m) }
+func (*LocationMetrics) ProtoMessage()    {}
+func (*LocationMetrics) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{12}
+}
+
+func (m *LocationMetrics) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LocationMetrics.Unmarshal(m, b)
+}
+func (m *LocationMetrics) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LocationMetrics.Marshal(b, m, deterministic)
+}
+func (m *LocationMetrics) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LocationMetrics.Merge(m, src)
+}
+func (m *LocationMetrics) XXX_Size() int {
+	return xxx_messageInfo_LocationMetrics.Size(m)
+}
+func (m *LocationMetrics) XXX_DiscardUnknown() {
+	xxx_messageInfo_LocationMetrics.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LocationMetrics proto.InternalMessageInfo
+
+func (m *LocationMetrics) GetRates() []*MemoryUtilizationRate {
+	if m != nil {
+		return m.Rates
 	}
 	return nil
 }
@@ -486,47 +1106,1031 @@ func (m *LocationMetrics) GetCallstack() *Callstack {
 	return nil
 }
 
-// SessionMetrics contains list of heap allocation metrics per every location
-type SessionMetrics struct {
-	Locations            []*LocationMetrics `protobuf:"bytes,1,rep,name=locations,proto3" json:"locations,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
-	XXX_unrecognized     []byte             `json:"-"`
-	XXX_sizecache        int32              `json:"-"`
+// SessionMetrics contains list of heap allocation metrics per every location
+type SessionMetrics struct {
+	Locations []*LocationMetrics `protobuf:"bytes,1,rep,name=locations,proto3" json:"locations,omitempty"`
+	// total_locations - number of locations matching subscription filters
+	// (may be greater than the number of locations sent)
+	TotalLocations       uint32   `protobuf:"varint,2,opt,name=total_locations,json=totalLocations,proto3" json:"total_locations,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SessionMetrics) Reset()         { *m = SessionMetrics{} }
+func (m *SessionMetrics) String() string { return proto.CompactTextString(m) }
+func (*SessionMetrics) ProtoMessage()    {}
+func (*SessionMetrics) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{13}
+}
+
+func (m *SessionMetrics) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SessionMetrics.Unmarshal(m, b)
+}
+func (m *SessionMetrics) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SessionMetrics.Marshal(b, m, deterministic)
+}
+func (m *SessionMetrics) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SessionMetrics.Merge(m, src)
+}
+func (m *SessionMetrics) XXX_Size() int {
+	return xxx_messageInfo_SessionMetrics.Size(m)
+}
+func (m *SessionMetrics) XXX_DiscardUnknown() {
+	xxx_messageInfo_SessionMetrics.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SessionMetrics proto.InternalMessageInfo
+
+func (m *SessionMetrics) GetLocations() []*LocationMetrics {
+	if m != nil {
+		return m.Locations
+	}
+	return nil
+}
+
+func (m *SessionMetrics) GetTotalLocations() uint32 {
+	if m != nil {
+		return m.TotalLocations
+	}
+	return 0
+}
+
+// GetFlameGraphRequest is a request body for GetFlameGraph method
+type GetFlameGraphRequest struct {
+	// session - session identifier
+	Session *SessionDescription `protobuf:"bytes,1,opt,name=session,proto3" json:"session,omitempty"`
+	// observed_at - the moment of time the flame graph is built for;
+	// if empty, the latest session measurement is used
+	ObservedAt *timestamp.Timestamp `protobuf:"bytes,2,opt,name=observed_at,json=observedAt,proto3" json:"observed_at,omitempty"`
+	// span - time span used to estimate growth rates;
+	// if empty, the shortest averaging window of the server is used
+	Span                 *duration.Duration `protobuf:"bytes,3,opt,name=span,proto3" json:"span,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *GetFlameGraphRequest) Reset()         { *m = GetFlameGraphRequest{} }
+func (m *GetFlameGraphRequest) String() string { return proto.CompactTextString(m) }
+func (*GetFlameGraphRequest) ProtoMessage()    {}
+func (*GetFlameGraphRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{14}
+}
+
+func (m *GetFlameGraphRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetFlameGraphRequest.Unmarshal(m, b)
+}
+func (m *GetFlameGraphRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetFlameGraphRequest.Marshal(b, m, deterministic)
+}
+func (m *GetFlameGraphRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetFlameGraphRequest.Merge(m, src)
+}
+func (m *GetFlameGraphRequest) XXX_Size() int {
+	return xxx_messageInfo_GetFlameGraphRequest.Size(m)
+}
+func (m *GetFlameGraphRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetFlameGraphRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetFlameGraphRequest proto.InternalMessageInfo
+
+func (m *GetFlameGraphRequest) GetSession() *SessionDescription {
+	if m != nil {
+		return m.Session
+	}
+	return nil
+}
+
+func (m *GetFlameGraphRequest) GetObservedAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.ObservedAt
+	}
+	return nil
+}
+
+func (m *GetFlameGraphRequest) GetSpan() *duration.Duration {
+	if m != nil {
+		return m.Span
+	}
+	return nil
+}
+
+// GetFlameGraphResponse is a response body for GetFlameGraph method
+type GetFlameGraphResponse struct {
+	FlameGraph           *FlameGraph `protobuf:"bytes,1,opt,name=flame_graph,json=flameGraph,proto3" json:"flame_graph,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *GetFlameGraphResponse) Reset()         { *m = GetFlameGraphResponse{} }
+func (m *GetFlameGraphResponse) String() string { return proto.CompactTextString(m) }
+func (*GetFlameGraphResponse) ProtoMessage()    {}
+func (*GetFlameGraphResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{15}
+}
+
+func (m *GetFlameGraphResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetFlameGraphResponse.Unmarshal(m, b)
+}
+func (m *GetFlameGraphResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetFlameGraphResponse.Marshal(b, m, deterministic)
+}
+func (m *GetFlameGraphResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetFlameGraphResponse.Merge(m, src)
+}
+func (m *GetFlameGraphResponse) XXX_Size() int {
+	return xxx_messageInfo_GetFlameGraphResponse.Size(m)
+}
+func (m *GetFlameGraphResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetFlameGraphResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetFlameGraphResponse proto.InternalMessageInfo
+
+func (m *GetFlameGraphResponse) GetFlameGraph() *FlameGraph {
+	if m != nil {
+		return m.FlameGraph
+	}
+	return nil
+}
+
+// FlameGraph is a merged call tree describing where the in-use memory lives
+type FlameGraph struct {
+	// observed_at - timestamp of the measurement used to build the tree
+	ObservedAt *timestamp.Timestamp `protobuf:"bytes,1,opt,name=observed_at,json=observedAt,proto3" json:"observed_at,omitempty"`
+	// root - synthetic root node containing totals for the whole session
+	Root *FlameGraphNode `protobuf:"bytes,2,opt,name=root,proto3" json:"root,omitempty"`
+	// folded - the same tree in folded stacks format ("caller;callee in_use_bytes" per line,
+	// the synthetic root is omitted), compatible with common flame graph tools
+	Folded               string   `protobuf:"bytes,3,opt,name=folded,proto3" json:"folded,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FlameGraph) Reset()         { *m = FlameGraph{} }
+func (m *FlameGraph) String() string { return proto.CompactTextString(m) }
+func (*FlameGraph) ProtoMessage()    {}
+func (*FlameGraph) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{16}
+}
+
+func (m *FlameGraph) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FlameGraph.Unmarshal(m, b)
+}
+func (m *FlameGraph) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FlameGraph.Marshal(b, m, deterministic)
+}
+func (m *FlameGraph) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FlameGraph.Merge(m, src)
+}
+func (m *FlameGraph) XXX_Size() int {
+	return xxx_messageInfo_FlameGraph.Size(m)
+}
+func (m *FlameGraph) XXX_DiscardUnknown() {
+	xxx_messageInfo_FlameGraph.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FlameGraph proto.InternalMessageInfo
+
+func (m *FlameGraph) GetObservedAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.ObservedAt
+	}
+	return nil
+}
+
+func (m *FlameGraph) GetRoot() *FlameGraphNode {
+	if m != nil {
+		return m.Root
+	}
+	return nil
+}
+
+func (m *FlameGraph) GetFolded() string {
+	if m != nil {
+		return m.Folded
+	}
+	return ""
+}
+
+// FlameGraphNode is a function in a merged call tree;
+// all values are aggregated over the node subtree
+type FlameGraphNode struct {
+	// frame - function described by the node (only name and file are set)
+	Frame        *StackFrame `protobuf:"bytes,1,opt,name=frame,proto3" json:"frame,omitempty"`
+	InUseBytes   int64       `protobuf:"varint,2,opt,name=in_use_bytes,json=inUseBytes,proto3" json:"in_use_bytes,omitempty"`
+	InUseObjects int64       `protobuf:"varint,3,opt,name=in_use_objects,json=inUseObjects,proto3" json:"in_use_objects,omitempty"`
+	// rates are measured in bytes per second and objects per second respectively
+	InUseBytesRate   float64 `protobuf:"fixed64,4,opt,name=in_use_bytes_rate,json=inUseBytesRate,proto3" json:"in_use_bytes_rate,omitempty"`
+	InUseObjectsRate float64 `protobuf:"fixed64,5,opt,name=in_use_objects_rate,json=inUseObjectsRate,proto3" json:"in_use_objects_rate,omitempty"`
+	// children - callees sorted by function name
+	Children             []*FlameGraphNode `protobuf:"bytes,6,rep,name=children,proto3" json:"children,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *FlameGraphNode) Reset()         { *m = FlameGraphNode{} }
+func (m *FlameGraphNode) String() string { return proto.CompactTextString(m) }
+func (*FlameGraphNode) ProtoMessage()    {}
+func (*FlameGraphNode) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{17}
+}
+
+func (m *FlameGraphNode) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FlameGraphNode.Unmarshal(m, b)
+}
+func (m *FlameGraphNode) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FlameGraphNode.Marshal(b, m, deterministic)
+}
+func (m *FlameGraphNode) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FlameGraphNode.Merge(m, src)
+}
+func (m *FlameGraphNode) XXX_Size() int {
+	return xxx_messageInfo_FlameGraphNode.Size(m)
+}
+func (m *FlameGraphNode) XXX_DiscardUnknown() {
+	xxx_messageInfo_FlameGraphNode.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FlameGraphNode proto.InternalMessageInfo
+
+func (m *FlameGraphNode) GetFrame() *StackFrame {
+	if m != nil {
+		return m.Frame
+	}
+	return nil
+}
+
+func (m *FlameGraphNode) GetInUseBytes() int64 {
+	if m != nil {
+		return m.InUseBytes
+	}
+	return 0
+}
+
+func (m *FlameGraphNode) GetInUseObjects() int64 {
+	if m != nil {
+		return m.InUseObjects
+	}
+	return 0
+}
+
+func (m *FlameGraphNode) GetInUseBytesRate() float64 {
+	if m != nil {
+		return m.InUseBytesRate
+	}
+	return 0
+}
+
+func (m *FlameGraphNode) GetInUseObjectsRate() float64 {
+	if m != nil {
+		return m.InUseObjectsRate
+	}
+	return 0
+}
+
+func (m *FlameGraphNode) GetChildren() []*FlameGraphNode {
+	if m != nil {
+		return m.Children
+	}
+	return nil
+}
+
+// GetForecastRequest is a request body for GetForecast method
+type GetForecastRequest struct {
+	// session - session identifier
+	Session *SessionDescription `protobuf:"bytes,1,opt,name=session,proto3" json:"session,omitempty"`
+	// memory_limit - memory limit [bytes]; if empty, the limit configured for the service is used
+	MemoryLimit uint64 `protobuf:"varint,2,opt,name=memory_limit,json=memoryLimit,proto3" json:"memory_limit,omitempty"`
+	// span - time span used to estimate growth rates;
+	// if empty, the longest averaging window of the server is used
+	Span *duration.Duration `protobuf:"bytes,3,opt,name=span,proto3" json:"span,omitempty"`
+	// confidence - confidence level of the prediction interval (0.95 by default)
+	Confidence           float64  `protobuf:"fixed64,4,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetForecastRequest) Reset()         { *m = GetForecastRequest{} }
+func (m *GetForecastRequest) String() string { return proto.CompactTextString(m) }
+func (*GetForecastRequest) ProtoMessage()    {}
+func (*GetForecastRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{18}
+}
+
+func (m *GetForecastRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetForecastRequest.Unmarshal(m, b)
+}
+func (m *GetForecastRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetForecastRequest.Marshal(b, m, deterministic)
+}
+func (m *GetForecastRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetForecastRequest.Merge(m, src)
+}
+func (m *GetForecastRequest) XXX_Size() int {
+	return xxx_messageInfo_GetForecastRequest.Size(m)
+}
+func (m *GetForecastRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetForecastRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetForecastRequest proto.InternalMessageInfo
+
+func (m *GetForecastRequest) GetSession() *SessionDescription {
+	if m != nil {
+		return m.Session
+	}
+	return nil
+}
+
+func (m *GetForecastRequest) GetMemoryLimit() uint64 {
+	if m != nil {
+		return m.MemoryLimit
+	}
+	return 0
+}
+
+func (m *GetForecastRequest) GetSpan() *duration.Duration {
+	if m != nil {
+		return m.Span
+	}
+	return nil
+}
+
+func (m *GetForecastRequest) GetConfidence() float64 {
+	if m != nil {
+		return m.Confidence
+	}
+	return 0
+}
+
+// GetForecastResponse is a response body for GetForecast method
+type GetForecastResponse struct {
+	Forecast             *Forecast `protobuf:"bytes,1,opt,name=forecast,proto3" json:"forecast,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *GetForecastResponse) Reset()         { *m = GetForecastResponse{} }
+func (m *GetForecastResponse) String() string { return proto.CompactTextString(m) }
+func (*GetForecastResponse) ProtoMessage()    {}
+func (*GetForecastResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{19}
+}
+
+func (m *GetForecastResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetForecastResponse.Unmarshal(m, b)
+}
+func (m *GetForecastResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetForecastResponse.Marshal(b, m, deterministic)
+}
+func (m *GetForecastResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetForecastResponse.Merge(m, src)
+}
+func (m *GetForecastResponse) XXX_Size() int {
+	return xxx_messageInfo_GetForecastResponse.Size(m)
+}
+func (m *GetForecastResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetForecastResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetForecastResponse proto.InternalMessageInfo
+
+func (m *GetForecastResponse) GetForecast() *Forecast {
+	if m != nil {
+		return m.Forecast
+	}
+	return nil
+}
+
+// Forecast predicts memory exhaustion assuming the linear growth of the total in-use bytes;
+// empty durations mean that memory limit will never be reached
+type Forecast struct {
+	// observed_at - the moment of time the forecast is made for
+	ObservedAt *timestamp.Timestamp `protobuf:"bytes,1,opt,name=observed_at,json=observedAt,proto3" json:"observed_at,omitempty"`
+	// memory_limit - memory limit [bytes]
+	MemoryLimit uint64 `protobuf:"varint,2,opt,name=memory_limit,json=memoryLimit,proto3" json:"memory_limit,omitempty"`
+	// in_use_bytes - the latest value of the total in-use bytes
+	InUseBytes float64 `protobuf:"fixed64,3,opt,name=in_use_bytes,json=inUseBytes,proto3" json:"in_use_bytes,omitempty"`
+	// in_use_bytes_rate - the growth rate of the total in-use bytes [bytes per second]
+	InUseBytesRate float64 `protobuf:"fixed64,4,opt,name=in_use_bytes_rate,json=inUseBytesRate,proto3" json:"in_use_bytes_rate,omitempty"`
+	// quality - goodness-of-fit values of the growth rate
+	Quality *FitQuality `protobuf:"bytes,5,opt,name=quality,proto3" json:"quality,omitempty"`
+	// time_to_exhaustion - the most probable time left until memory limit is reached
+	TimeToExhaustion *duration.Duration `protobuf:"bytes,6,opt,name=time_to_exhaustion,json=timeToExhaustion,proto3" json:"time_to_exhaustion,omitempty"`
+	// earliest_exhaustion - the lower bound of the prediction interval
+	EarliestExhaustion *duration.Duration `protobuf:"bytes,7,opt,name=earliest_exhaustion,json=earliestExhaustion,proto3" json:"earliest_exhaustion,omitempty"`
+	// latest_exhaustion - the upper bound of the prediction interval
+	LatestExhaustion *duration.Duration `protobuf:"bytes,8,opt,name=latest_exhaustion,json=latestExhaustion,proto3" json:"latest_exhaustion,omitempty"`
+	// confidence - confidence level of the prediction interval
+	Confidence float64 `protobuf:"fixed64,9,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	// contributions - per-location contributions to the total growth rate, in descending order
+	Contributions        []*LocationContribution `protobuf:"bytes,10,rep,name=contributions,proto3" json:"contributions,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                `json:"-"`
+	XXX_unrecognized     []byte                  `json:"-"`
+	XXX_sizecache        int32                   `json:"-"`
+}
+
+func (m *Forecast) Reset()         { *m = Forecast{} }
+func (m *Forecast) String() string { return proto.CompactTextString(m) }
+func (*Forecast) ProtoMessage()    {}
+func (*Forecast) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{20}
+}
+
+func (m *Forecast) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Forecast.Unmarshal(m, b)
+}
+func (m *Forecast) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Forecast.Marshal(b, m, deterministic)
+}
+func (m *Forecast) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Forecast.Merge(m, src)
+}
+func (m *Forecast) XXX_Size() int {
+	return xxx_messageInfo_Forecast.Size(m)
+}
+func (m *Forecast) XXX_DiscardUnknown() {
+	xxx_messageInfo_Forecast.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Forecast proto.InternalMessageInfo
+
+func (m *Forecast) GetObservedAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.ObservedAt
+	}
+	return nil
+}
+
+func (m *Forecast) GetMemoryLimit() uint64 {
+	if m != nil {
+		return m.MemoryLimit
+	}
+	return 0
+}
+
+func (m *Forecast) GetInUseBytes() float64 {
+	if m != nil {
+		return m.InUseBytes
+	}
+	return 0
+}
+
+func (m *Forecast) GetInUseBytesRate() float64 {
+	if m != nil {
+		return m.InUseBytesRate
+	}
+	return 0
+}
+
+func (m *Forecast) GetQuality() *FitQuality {
+	if m != nil {
+		return m.Quality
+	}
+	return nil
+}
+
+func (m *Forecast) GetTimeToExhaustion() *duration.Duration {
+	if m != nil {
+		return m.TimeToExhaustion
+	}
+	return nil
+}
+
+func (m *Forecast) GetEarliestExhaustion() *duration.Duration {
+	if m != nil {
+		return m.EarliestExhaustion
+	}
+	return nil
+}
+
+func (m *Forecast) GetLatestExhaustion() *duration.Duration {
+	if m != nil {
+		return m.LatestExhaustion
+	}
+	return nil
+}
+
+func (m *Forecast) GetConfidence() float64 {
+	if m != nil {
+		return m.Confidence
+	}
+	return 0
+}
+
+func (m *Forecast) GetContributions() []*LocationContribution {
+	if m != nil {
+		return m.Contributions
+	}
+	return nil
+}
+
+// LocationContribution describes how much a location contributes to the total in-use bytes growth
+type LocationContribution struct {
+	Callstack *Callstack `protobuf:"bytes,1,opt,name=callstack,proto3" json:"callstack,omitempty"`
+	// in_use_bytes - the latest value of the location in-use bytes
+	InUseBytes float64 `protobuf:"fixed64,2,opt,name=in_use_bytes,json=inUseBytes,proto3" json:"in_use_bytes,omitempty"`
+	// in_use_bytes_rate - the growth rate of the location in-use bytes [bytes per second]
+	InUseBytesRate float64 `protobuf:"fixed64,3,opt,name=in_use_bytes_rate,json=inUseBytesRate,proto3" json:"in_use_bytes_rate,omitempty"`
+	// share - the fraction of the total growth rate
+	Share                float64  `protobuf:"fixed64,4,opt,name=share,proto3" json:"share,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *LocationContribution) Reset()         { *m = LocationContribution{} }
+func (m *LocationContribution) String() string { return proto.CompactTextString(m) }
+func (*LocationContribution) ProtoMessage()    {}
+func (*LocationContribution) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{21}
+}
+
+func (m *LocationContribution) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LocationContribution.Unmarshal(m, b)
+}
+func (m *LocationContribution) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LocationContribution.Marshal(b, m, deterministic)
+}
+func (m *LocationContribution) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LocationContribution.Merge(m, src)
+}
+func (m *LocationContribution) XXX_Size() int {
+	return xxx_messageInfo_LocationContribution.Size(m)
+}
+func (m *LocationContribution) XXX_DiscardUnknown() {
+	xxx_messageInfo_LocationContribution.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LocationContribution proto.InternalMessageInfo
+
+func (m *LocationContribution) GetCallstack() *Callstack {
+	if m != nil {
+		return m.Callstack
+	}
+	return nil
+}
+
+func (m *LocationContribution) GetInUseBytes() float64 {
+	if m != nil {
+		return m.InUseBytes
+	}
+	return 0
+}
+
+func (m *LocationContribution) GetInUseBytesRate() float64 {
+	if m != nil {
+		return m.InUseBytesRate
+	}
+	return 0
+}
+
+func (m *LocationContribution) GetShare() float64 {
+	if m != nil {
+		return m.Share
+	}
+	return 0
+}
+
+// GetAnnotationsRequest is a request body for GetAnnotations method
+type GetAnnotationsRequest struct {
+	// session - session identifier
+	Session              *SessionDescription `protobuf:"bytes,1,opt,name=session,proto3" json:"session,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
+	XXX_unrecognized     []byte              `json:"-"`
+	XXX_sizecache        int32               `json:"-"`
+}
+
+func (m *GetAnnotationsRequest) Reset()         { *m = GetAnnotationsRequest{} }
+func (m *GetAnnotationsRequest) String() string { return proto.CompactTextString(m) }
+func (*GetAnnotationsRequest) ProtoMessage()    {}
+func (*GetAnnotationsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{22}
+}
+
+func (m *GetAnnotationsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetAnnotationsRequest.Unmarshal(m, b)
+}
+func (m *GetAnnotationsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetAnnotationsRequest.Marshal(b, m, deterministic)
+}
+func (m *GetAnnotationsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetAnnotationsRequest.Merge(m, src)
+}
+func (m *GetAnnotationsRequest) XXX_Size() int {
+	return xxx_messageInfo_GetAnnotationsRequest.Size(m)
+}
+func (m *GetAnnotationsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetAnnotationsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetAnnotationsRequest proto.InternalMessageInfo
+
+func (m *GetAnnotationsRequest) GetSession() *SessionDescription {
+	if m != nil {
+		return m.Session
+	}
+	return nil
+}
+
+// GetAnnotationsResponse is a response body for GetAnnotations method
+type GetAnnotationsResponse struct {
+	// annotations - session annotations sorted by time
+	Annotations          []*Annotation `protobuf:"bytes,1,rep,name=annotations,proto3" json:"annotations,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *GetAnnotationsResponse) Reset()         { *m = GetAnnotationsResponse{} }
+func (m *GetAnnotationsResponse) String() string { return proto.CompactTextString(m) }
+func (*GetAnnotationsResponse) ProtoMessage()    {}
+func (*GetAnnotationsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{23}
+}
+
+func (m *GetAnnotationsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetAnnotationsResponse.Unmarshal(m, b)
+}
+func (m *GetAnnotationsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetAnnotationsResponse.Marshal(b, m, deterministic)
+}
+func (m *GetAnnotationsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetAnnotationsResponse.Merge(m, src)
+}
+func (m *GetAnnotationsResponse) XXX_Size() int {
+	return xxx_messageInfo_GetAnnotationsResponse.Size(m)
+}
+func (m *GetAnnotationsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetAnnotationsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetAnnotationsResponse proto.InternalMessageInfo
+
+func (m *GetAnnotationsResponse) GetAnnotations() []*Annotation {
+	if m != nil {
+		return m.Annotations
+	}
+	return nil
+}
+
+// GetAnomaliesRequest is a request body for GetAnomalies method
+type GetAnomaliesRequest struct {
+	// service - identifier for a group of similar services
+	Service string `protobuf:"bytes,1,opt,name=service,proto3" json:"service,omitempty"`
+	// indicator - memory indicator whose rates are compared
+	Indicator MemoryIndicator `protobuf:"varint,2,opt,name=indicator,proto3,enum=schema.MemoryIndicator" json:"indicator,omitempty"`
+	// span - averaging window of the compared rates; must be one of the server averaging windows,
+	// the longest one is used by default
+	Span *duration.Duration `protobuf:"bytes,3,opt,name=span,proto3" json:"span,omitempty"`
+	// threshold - modified z-score value above which rate is considered to be an outlier (3.5 by default)
+	Threshold            float64  `protobuf:"fixed64,4,opt,name=threshold,proto3" json:"threshold,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetAnomaliesRequest) Reset()         { *m = GetAnomaliesRequest{} }
+func (m *GetAnomaliesRequest) String() string { return proto.CompactTextString(m) }
+func (*GetAnomaliesRequest) ProtoMessage()    {}
+func (*GetAnomaliesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{24}
+}
+
+func (m *GetAnomaliesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetAnomaliesRequest.Unmarshal(m, b)
+}
+func (m *GetAnomaliesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetAnomaliesRequest.Marshal(b, m, deterministic)
+}
+func (m *GetAnomaliesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetAnomaliesRequest.Merge(m, src)
+}
+func (m *GetAnomaliesRequest) XXX_Size() int {
+	return xxx_messageInfo_GetAnomaliesRequest.Size(m)
+}
+func (m *GetAnomaliesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetAnomaliesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetAnomaliesRequest proto.InternalMessageInfo
+
+func (m *GetAnomaliesRequest) GetService() string {
+	if m != nil {
+		return m.Service
+	}
+	return ""
+}
+
+func (m *GetAnomaliesRequest) GetIndicator() MemoryIndicator {
+	if m != nil {
+		return m.Indicator
+	}
+	return MemoryIndicator_IN_USE_BYTES
+}
+
+func (m *GetAnomaliesRequest) GetSpan() *duration.Duration {
+	if m != nil {
+		return m.Span
+	}
+	return nil
+}
+
+func (m *GetAnomaliesRequest) GetThreshold() float64 {
+	if m != nil {
+		return m.Threshold
+	}
+	return 0
+}
+
+// GetAnomaliesResponse is a response body for GetAnomalies method
+type GetAnomaliesResponse struct {
+	// anomalies - outliers sorted by the absolute score in descending order
+	Anomalies            []*Anomaly `protobuf:"bytes,1,rep,name=anomalies,proto3" json:"anomalies,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *GetAnomaliesResponse) Reset()         { *m = GetAnomaliesResponse{} }
+func (m *GetAnomaliesResponse) String() string { return proto.CompactTextString(m) }
+func (*GetAnomaliesResponse) ProtoMessage()    {}
+func (*GetAnomaliesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{25}
+}
+
+func (m *GetAnomaliesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetAnomaliesResponse.Unmarshal(m, b)
+}
+func (m *GetAnomaliesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetAnomaliesResponse.Marshal(b, m, deterministic)
+}
+func (m *GetAnomaliesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetAnomaliesResponse.Merge(m, src)
+}
+func (m *GetAnomaliesResponse) XXX_Size() int {
+	return xxx_messageInfo_GetAnomaliesResponse.Size(m)
+}
+func (m *GetAnomaliesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetAnomaliesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetAnomaliesResponse proto.InternalMessageInfo
+
+func (m *GetAnomaliesResponse) GetAnomalies() []*Anomaly {
+	if m != nil {
+		return m.Anomalies
+	}
+	return nil
+}
+
+// Anomaly describes the rate that differs significantly from the rates of the other instances
+type Anomaly struct {
+	// session - the live session of the outlying instance
+	Session *SessionDescription `protobuf:"bytes,1,opt,name=session,proto3" json:"session,omitempty"`
+	// callstack - the outlying location; empty value means the whole instance (the sum of all locations)
+	Callstack *Callstack `protobuf:"bytes,2,opt,name=callstack,proto3" json:"callstack,omitempty"`
+	// rate - the outlying rate value
+	Rate float64 `protobuf:"fixed64,3,opt,name=rate,proto3" json:"rate,omitempty"`
+	// peers_median - the median rate of all instances
+	PeersMedian float64 `protobuf:"fixed64,4,opt,name=peers_median,json=peersMedian,proto3" json:"peers_median,omitempty"`
+	// score - robust (modified) z-score of the rate
+	Score float64 `protobuf:"fixed64,5,opt,name=score,proto3" json:"score,omitempty"`
+	// peers - number of compared instances
+	Peers                uint32   `protobuf:"varint,6,opt,name=peers,proto3" json:"peers,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Anomaly) Reset()         { *m = Anomaly{} }
+func (m *Anomaly) String() string { return proto.CompactTextString(m) }
+func (*Anomaly) ProtoMessage()    {}
+func (*Anomaly) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{26}
+}
+
+func (m *Anomaly) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Anomaly.Unmarshal(m, b)
+}
+func (m *Anomaly) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Anomaly.Marshal(b, m, deterministic)
+}
+func (m *Anomaly) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Anomaly.Merge(m, src)
+}
+func (m *Anomaly) XXX_Size() int {
+	return xxx_messageInfo_Anomaly.Size(m)
+}
+func (m *Anomaly) XXX_DiscardUnknown() {
+	xxx_messageInfo_Anomaly.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Anomaly proto.InternalMessageInfo
+
+func (m *Anomaly) GetSession() *SessionDescription {
+	if m != nil {
+		return m.Session
+	}
+	return nil
+}
+
+func (m *Anomaly) GetCallstack() *Callstack {
+	if m != nil {
+		return m.Callstack
+	}
+	return nil
+}
+
+func (m *Anomaly) GetRate() float64 {
+	if m != nil {
+		return m.Rate
+	}
+	return 0
 }
 
-func (m *SessionMetrics) Reset()         { *m = SessionMetrics{} }
-func (m *SessionMetrics) String() string { return proto.CompactTextString(m) }
-func (*SessionMetrics) ProtoMessage()    {}
-func (*SessionMetrics) Descriptor() ([]byte, []int) {
-	return fileDescriptor_eca3873955a29cfe, []int{9}
+func (m *Anomaly) GetPeersMedian() float64 {
+	if m != nil {
+		return m.PeersMedian
+	}
+	return 0
 }
 
-func (m *SessionMetrics) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_SessionMetrics.Unmarshal(m, b)
+func (m *Anomaly) GetScore() float64 {
+	if m != nil {
+		return m.Score
+	}
+	return 0
 }
-func (m *SessionMetrics) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_SessionMetrics.Marshal(b, m, deterministic)
+
+func (m *Anomaly) GetPeers() uint32 {
+	if m != nil {
+		return m.Peers
+	}
+	return 0
 }
-func (m *SessionMetrics) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_SessionMetrics.Merge(m, src)
+
+// GetJanitorStatsRequest is a request body for GetJanitorStats method
+type GetJanitorStatsRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
-func (m *SessionMetrics) XXX_Size() int {
-	return xxx_messageInfo_SessionMetrics.Size(m)
+
+func (m *GetJanitorStatsRequest) Reset()         { *m = GetJanitorStatsRequest{} }
+func (m *GetJanitorStatsRequest) String() string { return proto.CompactTextString(m) }
+func (*GetJanitorStatsRequest) ProtoMessage()    {}
+func (*GetJanitorStatsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{27}
 }
-func (m *SessionMetrics) XXX_DiscardUnknown() {
-	xxx_messageInfo_SessionMetrics.DiscardUnknown(m)
+
+func (m *GetJanitorStatsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetJanitorStatsRequest.Unmarshal(m, b)
+}
+func (m *GetJanitorStatsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetJanitorStatsRequest.Marshal(b, m, deterministic)
+}
+func (m *GetJanitorStatsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetJanitorStatsRequest.Merge(m, src)
+}
+func (m *GetJanitorStatsRequest) XXX_Size() int {
+	return xxx_messageInfo_GetJanitorStatsRequest.Size(m)
+}
+func (m *GetJanitorStatsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetJanitorStatsRequest.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_SessionMetrics proto.InternalMessageInfo
+var xxx_messageInfo_GetJanitorStatsRequest proto.InternalMessageInfo
 
-func (m *SessionMetrics) GetLocations() []*LocationMetrics {
+// GetJanitorStatsResponse is a response body for GetJanitorStats method
+type GetJanitorStatsResponse struct {
+	Stats                *JanitorStats `protobuf:"bytes,1,opt,name=stats,proto3" json:"stats,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *GetJanitorStatsResponse) Reset()         { *m = GetJanitorStatsResponse{} }
+func (m *GetJanitorStatsResponse) String() string { return proto.CompactTextString(m) }
+func (*GetJanitorStatsResponse) ProtoMessage()    {}
+func (*GetJanitorStatsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{28}
+}
+
+func (m *GetJanitorStatsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetJanitorStatsResponse.Unmarshal(m, b)
+}
+func (m *GetJanitorStatsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetJanitorStatsResponse.Marshal(b, m, deterministic)
+}
+func (m *GetJanitorStatsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetJanitorStatsResponse.Merge(m, src)
+}
+func (m *GetJanitorStatsResponse) XXX_Size() int {
+	return xxx_messageInfo_GetJanitorStatsResponse.Size(m)
+}
+func (m *GetJanitorStatsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetJanitorStatsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetJanitorStatsResponse proto.InternalMessageInfo
+
+func (m *GetJanitorStatsResponse) GetStats() *JanitorStats {
 	if m != nil {
-		return m.Locations
+		return m.Stats
+	}
+	return nil
+}
+
+// JanitorStats contains statistics of the expired sessions removal since the server start
+type JanitorStats struct {
+	// runs - number of retention checks
+	Runs uint64 `protobuf:"varint,1,opt,name=runs,proto3" json:"runs,omitempty"`
+	// deleted_sessions - number of deleted sessions
+	DeletedSessions uint64 `protobuf:"varint,2,opt,name=deleted_sessions,json=deletedSessions,proto3" json:"deleted_sessions,omitempty"`
+	// reclaimed_bytes - disk space reclaimed by deleting sessions data [bytes]
+	ReclaimedBytes int64 `protobuf:"varint,3,opt,name=reclaimed_bytes,json=reclaimedBytes,proto3" json:"reclaimed_bytes,omitempty"`
+	// last_run - the moment of the latest check
+	LastRun *timestamp.Timestamp `protobuf:"bytes,4,opt,name=last_run,json=lastRun,proto3" json:"last_run,omitempty"`
+	// last_error - error occurred during the latest check, if any
+	LastError            string   `protobuf:"bytes,5,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *JanitorStats) Reset()         { *m = JanitorStats{} }
+func (m *JanitorStats) String() string { return proto.CompactTextString(m) }
+func (*JanitorStats) ProtoMessage()    {}
+func (*JanitorStats) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{29}
+}
+
+func (m *JanitorStats) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_JanitorStats.Unmarshal(m, b)
+}
+func (m *JanitorStats) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_JanitorStats.Marshal(b, m, deterministic)
+}
+func (m *JanitorStats) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_JanitorStats.Merge(m, src)
+}
+func (m *JanitorStats) XXX_Size() int {
+	return xxx_messageInfo_JanitorStats.Size(m)
+}
+func (m *JanitorStats) XXX_DiscardUnknown() {
+	xxx_messageInfo_JanitorStats.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_JanitorStats proto.InternalMessageInfo
+
+func (m *JanitorStats) GetRuns() uint64 {
+	if m != nil {
+		return m.Runs
+	}
+	return 0
+}
+
+func (m *JanitorStats) GetDeletedSessions() uint64 {
+	if m != nil {
+		return m.DeletedSessions
+	}
+	return 0
+}
+
+func (m *JanitorStats) GetReclaimedBytes() int64 {
+	if m != nil {
+		return m.ReclaimedBytes
+	}
+	return 0
+}
+
+func (m *JanitorStats) GetLastRun() *timestamp.Timestamp {
+	if m != nil {
+		return m.LastRun
 	}
 	return nil
 }
 
+func (m *JanitorStats) GetLastError() string {
+	if m != nil {
+		return m.LastError
+	}
+	return ""
+}
+
 func init() {
+	proto.RegisterEnum("schema.MemoryIndicator", MemoryIndicator_name, MemoryIndicator_value)
+	proto.RegisterEnum("schema.AggregationMode", AggregationMode_name, AggregationMode_value)
+	proto.RegisterEnum("schema.TrendEstimator", TrendEstimator_name, TrendEstimator_value)
 	proto.RegisterType((*GetServicesRequest)(nil), "schema.GetServicesRequest")
 	proto.RegisterType((*GetServicesResponse)(nil), "schema.GetServicesResponse")
 	proto.RegisterType((*GetInstancesRequest)(nil), "schema.GetInstancesRequest")
@@ -534,54 +2138,170 @@ func init() {
 	proto.RegisterType((*GetSessionsRequest)(nil), "schema.GetSessionsRequest")
 	proto.RegisterType((*GetSessionsResponse)(nil), "schema.GetSessionsResponse")
 	proto.RegisterType((*SubscribeForSessionRequest)(nil), "schema.SubscribeForSessionRequest")
+	proto.RegisterType((*SubscribeForServiceRequest)(nil), "schema.SubscribeForServiceRequest")
+	proto.RegisterType((*ServiceMetrics)(nil), "schema.ServiceMetrics")
+	proto.RegisterType((*InstanceMetrics)(nil), "schema.InstanceMetrics")
 	proto.RegisterType((*MemoryUtilizationRate)(nil), "schema.MemoryUtilizationRate")
 	proto.RegisterType((*MemoryUtilizationRate_Values)(nil), "schema.MemoryUtilizationRate.Values")
+	proto.RegisterType((*MemoryUtilizationRate_Quality)(nil), "schema.MemoryUtilizationRate.Quality")
+	proto.RegisterType((*FitQuality)(nil), "schema.FitQuality")
 	proto.RegisterType((*LocationMetrics)(nil), "schema.LocationMetrics")
 	proto.RegisterType((*SessionMetrics)(nil), "schema.SessionMetrics")
+	proto.RegisterType((*GetFlameGraphRequest)(nil), "schema.GetFlameGraphRequest")
+	proto.RegisterType((*GetFlameGraphResponse)(nil), "schema.GetFlameGraphResponse")
+	proto.RegisterType((*FlameGraph)(nil), "schema.FlameGraph")
+	proto.RegisterType((*FlameGraphNode)(nil), "schema.FlameGraphNode")
+	proto.RegisterType((*GetForecastRequest)(nil), "schema.GetForecastRequest")
+	proto.RegisterType((*GetForecastResponse)(nil), "schema.GetForecastResponse")
+	proto.RegisterType((*Forecast)(nil), "schema.Forecast")
+	proto.RegisterType((*LocationContribution)(nil), "schema.LocationContribution")
+	proto.RegisterType((*GetAnnotationsRequest)(nil), "schema.GetAnnotationsRequest")
+	proto.RegisterType((*GetAnnotationsResponse)(nil), "schema.GetAnnotationsResponse")
+	proto.RegisterType((*GetAnomaliesRequest)(nil), "schema.GetAnomaliesRequest")
+	proto.RegisterType((*GetAnomaliesResponse)(nil), "schema.GetAnomaliesResponse")
+	proto.RegisterType((*Anomaly)(nil), "schema.Anomaly")
+	proto.RegisterType((*GetJanitorStatsRequest)(nil), "schema.GetJanitorStatsRequest")
+	proto.RegisterType((*GetJanitorStatsResponse)(nil), "schema.GetJanitorStatsResponse")
+	proto.RegisterType((*JanitorStats)(nil), "schema.JanitorStats")
 }
 
 func init() { proto.RegisterFile("frontend.proto", fileDescriptor_eca3873955a29cfe) }
 
 var fileDescriptor_eca3873955a29cfe = []byte{
-	// 604 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x94, 0x5d, 0x6f, 0xd3, 0x3e,
-	0x14, 0xc6, 0x97, 0xbd, 0xf4, 0xbf, 0x9c, 0x76, 0x9d, 0xfe, 0xee, 0x80, 0x92, 0x31, 0x28, 0x66,
-	0x17, 0x93, 0x10, 0x29, 0x6c, 0x20, 0x84, 0xc4, 0xd5, 0x98, 0x36, 0x10, 0x54, 0x08, 0x4f, 0xe5,
-	0x76, 0x4a, 0x32, 0x77, 0x98, 0xa5, 0x71, 0xb1, 0x9d, 0xa1, 0xf1, 0x1d, 0xb9, 0xe7, 0x8e, 0xaf,
-	0x82, 0xe2, 0xb7, 0xb6, 0xa1, 0xc0, 0x65, 0x1e, 0xff, 0xfc, 0x9c, 0xc7, 0xc7, 0x39, 0x86, 0xf6,
-	0x48, 0xf0, 0x42, 0xd1, 0xe2, 0x3c, 0x9e, 0x08, 0xae, 0x38, 0x6a, 0xc8, 0xec, 0x13, 0x1d, 0x27,
-	0x51, 0x2b, 0xe3, 0xe3, 0x31, 0x2f, 0x8c, 0x1a, 0x6d, 0xa4, 0x49, 0x76, 0xe9, 0xa1, 0xe8, 0xee,
-	0x05, 0xe7, 0x17, 0x39, 0xed, 0xeb, 0xaf, 0xb4, 0x1c, 0xf5, 0xcf, 0x4b, 0x91, 0x28, 0xe6, 0x70,
-	0xbc, 0x05, 0xe8, 0x84, 0xaa, 0x53, 0x2a, 0xae, 0x58, 0x46, 0x25, 0xa1, 0x5f, 0x4a, 0x2a, 0x15,
-	0x7e, 0x02, 0x9d, 0x39, 0x55, 0x4e, 0x78, 0x21, 0x29, 0x8a, 0x60, 0x5d, 0x5a, 0xad, 0x1b, 0xf4,
-	0x56, 0xf6, 0x42, 0xe2, 0xbf, 0x71, 0x5f, 0x6f, 0x79, 0x53, 0x48, 0x95, 0x14, 0x53, 0x27, 0xd4,
-	0x85, 0xff, 0x2c, 0xd2, 0x0d, 0x7a, 0xc1, 0x5e, 0x48, 0xdc, 0x27, 0xfe, 0x00, 0x5b, 0xf3, 0x1b,
-	0x6c, 0x91, 0x17, 0x10, 0x32, 0x27, 0xea, 0x2a, 0xcd, 0xfd, 0xed, 0xd8, 0x1c, 0x35, 0x76, 0xf4,
-	0x11, 0x95, 0x99, 0x60, 0x93, 0xea, 0x1c, 0x64, 0x4a, 0xe3, 0x81, 0x3d, 0x8c, 0x94, 0x8c, 0x17,
-	0x3e, 0xc2, 0x73, 0x58, 0x77, 0x88, 0xce, 0xf0, 0x0f, 0x3f, 0x0f, 0xe3, 0x43, 0xdb, 0x05, 0x67,
-	0x67, 0x03, 0x3e, 0xac, 0xba, 0x60, 0x34, 0x9b, 0x6f, 0xd3, 0xf9, 0x59, 0x96, 0x78, 0x00, 0x13,
-	0x88, 0x4e, 0xcb, 0xb4, 0x72, 0x4f, 0xe9, 0x31, 0x17, 0x0e, 0xb0, 0xd1, 0x9e, 0x56, 0xdd, 0xd1,
-	0x8a, 0x4d, 0x16, 0xd5, 0x9c, 0x66, 0x83, 0x39, 0x14, 0xff, 0x5c, 0x86, 0x1b, 0x03, 0x3a, 0xe6,
-	0xe2, 0x7a, 0xa8, 0x58, 0xce, 0xbe, 0xe9, 0xfb, 0x24, 0x89, 0xa2, 0xe8, 0x11, 0xac, 0xca, 0x49,
-	0xe2, 0xcc, 0x6e, 0xc7, 0xe6, 0xf2, 0x63, 0x77, 0xf9, 0xf1, 0x91, 0xbd, 0x7c, 0xa2, 0x31, 0xf4,
-	0x12, 0x1a, 0x57, 0x49, 0x5e, 0x52, 0xd9, 0x5d, 0xd6, 0x1b, 0x76, 0x5d, 0xf5, 0x85, 0xee, 0xf1,
-	0x47, 0xcd, 0x12, 0xbb, 0x27, 0xfa, 0x11, 0x40, 0xc3, 0x48, 0xe8, 0x01, 0x6c, 0x24, 0x79, 0xce,
-	0xb3, 0x33, 0x9e, 0x7e, 0xa6, 0x99, 0x92, 0x3a, 0x40, 0x40, 0x5a, 0x5a, 0x7c, 0x6f, 0x34, 0x74,
-	0x0f, 0x9a, 0x06, 0x4a, 0xaf, 0x95, 0x2d, 0x19, 0x10, 0xd0, 0xd2, 0x61, 0xa5, 0xa0, 0xfb, 0xd0,
-	0x1a, 0x09, 0x4a, 0xbd, 0xc9, 0x8a, 0x26, 0x9a, 0x95, 0xe6, 0x3c, 0x76, 0x00, 0x34, 0x62, 0x2c,
-	0x56, 0x35, 0x10, 0x56, 0x8a, 0x71, 0xd8, 0x85, 0x36, 0x2b, 0xce, 0x4a, 0x39, 0xf5, 0x58, 0x33,
-	0x41, 0x58, 0x31, 0x94, 0xde, 0xa4, 0x07, 0x2d, 0x4b, 0x19, 0x9b, 0x86, 0x49, 0xa2, 0x19, 0xed,
-	0x83, 0xbf, 0xc2, 0xe6, 0x3b, 0x9e, 0xe9, 0x93, 0x0f, 0xa8, 0x12, 0x2c, 0x93, 0xe8, 0x00, 0xd6,
-	0x44, 0xa2, 0xfc, 0x2f, 0xb9, 0xf3, 0xd7, 0x56, 0x11, 0xc3, 0xa2, 0x3e, 0x84, 0x59, 0x92, 0xe7,
-	0x52, 0x25, 0xd9, 0xa5, 0xed, 0xf1, 0xff, 0x6e, 0xe3, 0x2b, 0xb7, 0x40, 0xa6, 0x0c, 0x3e, 0x81,
-	0xb6, 0xbd, 0x79, 0x57, 0xf7, 0x19, 0x84, 0xb9, 0x8d, 0xe2, 0x6a, 0xdf, 0x72, 0x16, 0xb5, 0x8c,
-	0x64, 0x4a, 0xee, 0x7f, 0x5f, 0x86, 0xce, 0x80, 0x8e, 0x27, 0x82, 0x8f, 0x58, 0x4e, 0xc5, 0xb1,
-	0x7d, 0x3a, 0xd0, 0x6b, 0x68, 0xce, 0x4c, 0x36, 0xf2, 0xff, 0xdb, 0xef, 0x8f, 0x40, 0xb4, 0xbd,
-	0x70, 0xcd, 0x0c, 0x01, 0x5e, 0x42, 0x6f, 0xa1, 0x35, 0x3b, 0xbf, 0x68, 0x16, 0xaf, 0x3f, 0x03,
-	0xd1, 0x9d, 0xc5, 0x8b, 0xde, 0xcc, 0xc5, 0x32, 0x53, 0x53, 0x8b, 0x35, 0x37, 0xce, 0xb5, 0x58,
-	0xf3, 0xb3, 0x89, 0x97, 0xd0, 0x10, 0x3a, 0x0b, 0x06, 0x0e, 0x61, 0x3f, 0x58, 0x7f, 0x9c, 0xc6,
-	0xe8, 0x66, 0x6d, 0xf8, 0x6c, 0x5b, 0xf1, 0xd2, 0xe3, 0x20, 0x6d, 0xe8, 0x19, 0x3a, 0xf8, 0x15,
-	0x00, 0x00, 0xff, 0xff, 0x7e, 0x68, 0x70, 0x9e, 0x85, 0x05, 0x00, 0x00,
+	// 2123 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xec, 0x58, 0xcd, 0x72, 0xdb, 0xc8,
+	0xf1, 0x37, 0x44, 0x8a, 0x1f, 0x4d, 0x89, 0xa2, 0x47, 0xb2, 0xcd, 0xa5, 0x64, 0x5b, 0x8b, 0xff,
+	0x6e, 0xfd, 0xbd, 0x8e, 0x57, 0xb6, 0x65, 0xbb, 0x36, 0x5b, 0x49, 0x55, 0x8a, 0x92, 0x29, 0x5b,
+	0x36, 0x25, 0xad, 0x41, 0x29, 0x5b, 0x39, 0xa1, 0x86, 0xc4, 0x50, 0xc2, 0x1a, 0xc0, 0xd0, 0x83,
+	0xa1, 0xbd, 0xca, 0x3b, 0xa4, 0x2a, 0x4f, 0xb1, 0x87, 0x5c, 0x93, 0x54, 0xe5, 0x90, 0x53, 0xce,
+	0xb9, 0xe5, 0x11, 0xf2, 0x08, 0xa9, 0xdc, 0x53, 0x98, 0x0f, 0x7c, 0x11, 0x94, 0xfc, 0x51, 0xb9,
+	0xe5, 0x86, 0xe9, 0xf9, 0xf5, 0x6f, 0x7a, 0xba, 0x7b, 0xba, 0x07, 0x03, 0xcd, 0x31, 0xa3, 0x01,
+	0x27, 0x81, 0xb3, 0x35, 0x61, 0x94, 0x53, 0x54, 0x09, 0x47, 0x67, 0xc4, 0xc7, 0x9d, 0xa5, 0x11,
+	0xf5, 0x7d, 0x1a, 0x48, 0x69, 0x67, 0x79, 0x88, 0x47, 0xaf, 0x63, 0x50, 0xe7, 0xd6, 0x29, 0xa5,
+	0xa7, 0x1e, 0xb9, 0x2f, 0x46, 0xc3, 0xe9, 0xf8, 0xbe, 0x33, 0x65, 0x98, 0xbb, 0x31, 0xfc, 0x76,
+	0x7e, 0x9e, 0xbb, 0x3e, 0x09, 0x39, 0xf6, 0x27, 0xf3, 0x08, 0xde, 0x31, 0x3c, 0x99, 0x10, 0x16,
+	0xca, 0x79, 0x73, 0x0d, 0xd0, 0x33, 0xc2, 0x07, 0x84, 0xbd, 0x75, 0x47, 0x24, 0xb4, 0xc8, 0x9b,
+	0x29, 0x09, 0xb9, 0xf9, 0x10, 0x56, 0x33, 0xd2, 0x70, 0x42, 0x83, 0x90, 0xa0, 0x0e, 0xd4, 0x42,
+	0x25, 0x6b, 0x1b, 0x9b, 0xa5, 0x3b, 0x75, 0x2b, 0x1e, 0x9b, 0xf7, 0x85, 0xca, 0x7e, 0x10, 0x72,
+	0x1c, 0x24, 0x4c, 0xa8, 0x0d, 0x55, 0x05, 0x69, 0x1b, 0x9b, 0xc6, 0x9d, 0xba, 0xa5, 0x87, 0xe6,
+	0x2b, 0x58, 0xcb, 0x2a, 0xa8, 0x45, 0xbe, 0x85, 0xba, 0xab, 0x85, 0x62, 0x95, 0xc6, 0xf6, 0xfa,
+	0x96, 0xf4, 0xd5, 0x96, 0x46, 0x3f, 0x25, 0xe1, 0x88, 0xb9, 0x93, 0xc8, 0x11, 0x56, 0x82, 0x36,
+	0x0f, 0xd4, 0x66, 0xc2, 0xd0, 0xa5, 0x41, 0x6c, 0xc2, 0x37, 0x50, 0xd3, 0x10, 0x61, 0xc3, 0x25,
+	0x7c, 0x31, 0xd8, 0xdc, 0x81, 0xd5, 0x0c, 0x9d, 0x32, 0xf0, 0x67, 0x91, 0x17, 0xa4, 0x4c, 0xd9,
+	0xb7, 0xa2, 0xf9, 0x14, 0xd6, 0x8a, 0x01, 0xe6, 0xbf, 0x4a, 0xd0, 0x19, 0x4c, 0x87, 0x11, 0xfd,
+	0x90, 0xec, 0x51, 0xa6, 0x11, 0xca, 0xb6, 0xc7, 0x91, 0x7b, 0x84, 0x44, 0x99, 0xd6, 0xc9, 0x51,
+	0xa5, 0x2d, 0xd3, 0x50, 0xf4, 0x2d, 0x34, 0xf0, 0xe9, 0x29, 0x23, 0xa7, 0x22, 0x15, 0xda, 0x0b,
+	0x9b, 0xc6, 0x9d, 0xe6, 0xf6, 0x0d, 0xad, 0xd9, 0x4d, 0xa6, 0x0e, 0xa8, 0x43, 0xac, 0x34, 0x16,
+	0xfd, 0x1f, 0x2c, 0xfb, 0xd4, 0x99, 0x7a, 0xc4, 0x9e, 0x30, 0x32, 0x76, 0x7f, 0x6c, 0x97, 0x44,
+	0x54, 0x96, 0xa4, 0xf0, 0x3b, 0x21, 0x43, 0x0f, 0xa0, 0x1a, 0x52, 0xc6, 0xed, 0xe1, 0x79, 0xbb,
+	0x9c, 0xe5, 0x3e, 0x20, 0x3e, 0x65, 0xe7, 0xfb, 0x81, 0xe3, 0x8e, 0x30, 0xa7, 0xcc, 0xaa, 0x44,
+	0xb8, 0x9d, 0x73, 0xf4, 0x14, 0x5a, 0xf8, 0x2d, 0x61, 0xf8, 0xd4, 0x0d, 0x4e, 0xed, 0x77, 0x6e,
+	0xe0, 0xd0, 0x77, 0xed, 0x45, 0xb1, 0xa1, 0xcf, 0xb6, 0x64, 0x06, 0x6e, 0xe9, 0x0c, 0xdc, 0x7a,
+	0xaa, 0x52, 0xd8, 0x5a, 0x89, 0x55, 0xbe, 0x17, 0x1a, 0xe8, 0x3a, 0x54, 0xe8, 0x78, 0x1c, 0x12,
+	0xde, 0xae, 0x6c, 0x1a, 0x77, 0x96, 0x2d, 0x35, 0x42, 0x6b, 0xb0, 0xe8, 0xb9, 0xbe, 0xcb, 0xdb,
+	0x55, 0x21, 0x96, 0x83, 0x28, 0xae, 0xbe, 0x1b, 0xd8, 0x0c, 0x73, 0xd2, 0xae, 0x89, 0xb5, 0x36,
+	0x66, 0xd7, 0xa2, 0xd3, 0xa1, 0x47, 0x7e, 0x8d, 0xbd, 0x29, 0xb1, 0xaa, 0xbe, 0x1b, 0x58, 0x98,
+	0x93, 0x68, 0x99, 0xb1, 0xeb, 0x71, 0xc2, 0xda, 0x75, 0xb1, 0x79, 0x35, 0x42, 0xcf, 0x01, 0x31,
+	0x32, 0xa2, 0xfe, 0x64, 0xca, 0x89, 0xed, 0x06, 0x9c, 0xb0, 0xb7, 0xd8, 0x6b, 0xc3, 0x65, 0xdb,
+	0xb8, 0x1a, 0x2b, 0xed, 0x2b, 0x1d, 0xf3, 0xdf, 0x33, 0x51, 0x17, 0x39, 0x7f, 0xe9, 0xa1, 0xf8,
+	0x5f, 0x64, 0xff, 0x3b, 0x91, 0xdd, 0x80, 0xfa, 0x90, 0x11, 0xfc, 0xda, 0xa1, 0xef, 0x02, 0x11,
+	0xd0, 0x9a, 0x95, 0x08, 0xe6, 0xc4, 0xbd, 0xf1, 0x11, 0x71, 0x9f, 0x42, 0x53, 0x85, 0xfa, 0x80,
+	0x70, 0xe6, 0x8e, 0x42, 0x74, 0x0f, 0x16, 0x39, 0xe5, 0xd8, 0x53, 0xc7, 0xfb, 0x7a, 0xee, 0x78,
+	0x2b, 0x98, 0x25, 0x41, 0xe8, 0x49, 0xba, 0xf6, 0x2d, 0x88, 0xda, 0x72, 0x23, 0x5f, 0xab, 0xb4,
+	0x4a, 0xaa, 0xee, 0x9d, 0xc3, 0x4a, 0x6e, 0xf6, 0x23, 0x0b, 0xcb, 0x03, 0xa8, 0xfa, 0x92, 0xa0,
+	0xbd, 0x70, 0xa1, 0xbd, 0x1a, 0x66, 0xfe, 0x54, 0x83, 0x6b, 0x32, 0x75, 0x4e, 0xb8, 0xeb, 0xb9,
+	0xbf, 0x95, 0xae, 0x89, 0x62, 0xf1, 0x35, 0x94, 0xc3, 0x09, 0xd6, 0xcb, 0x5f, 0xe0, 0x47, 0x01,
+	0x43, 0xbf, 0x84, 0xca, 0xdb, 0x28, 0x98, 0x7a, 0xe5, 0x2f, 0xb2, 0x89, 0x99, 0x63, 0xdf, 0x12,
+	0x81, 0x0f, 0x2d, 0xa5, 0x83, 0x1e, 0x43, 0x9d, 0x84, 0xdc, 0xf5, 0xa3, 0xd4, 0x15, 0x89, 0xdf,
+	0x4c, 0x4c, 0x3f, 0x66, 0x24, 0x70, 0x7a, 0x7a, 0xd6, 0x4a, 0x80, 0xe8, 0x57, 0x50, 0x7d, 0x33,
+	0xc5, 0x9e, 0xcb, 0xe5, 0x69, 0x68, 0x6c, 0x7f, 0x79, 0xf1, 0xa2, 0xaf, 0x24, 0xd8, 0xd2, 0x5a,
+	0x9d, 0xbf, 0x2d, 0x40, 0x45, 0x5a, 0x12, 0x1d, 0x3f, 0xec, 0x79, 0x74, 0x64, 0xd3, 0xe1, 0x0f,
+	0x64, 0xc4, 0x43, 0xb1, 0x6f, 0xc3, 0x5a, 0x12, 0xc2, 0x23, 0x29, 0x43, 0xb7, 0xa1, 0x21, 0x41,
+	0xc3, 0x73, 0xae, 0x76, 0x6a, 0x58, 0x20, 0x44, 0x3b, 0x91, 0x04, 0x7d, 0x0e, 0x4b, 0x63, 0x46,
+	0x48, 0x4c, 0x52, 0x12, 0x88, 0x46, 0x24, 0xd3, 0x1c, 0x37, 0x01, 0x04, 0x44, 0x52, 0x94, 0x05,
+	0xa0, 0x1e, 0x49, 0x24, 0xc3, 0x17, 0xd0, 0x74, 0x03, 0x7b, 0x1a, 0x26, 0x1c, 0x8b, 0xd2, 0x10,
+	0x37, 0x38, 0x09, 0x63, 0x92, 0x4d, 0x58, 0x52, 0x28, 0x49, 0x53, 0x91, 0x96, 0x08, 0x8c, 0xe4,
+	0x79, 0x08, 0xd7, 0xd2, 0x08, 0x7b, 0x88, 0x43, 0xe2, 0xb9, 0x01, 0x11, 0x27, 0xd5, 0xb0, 0x50,
+	0x02, 0xdd, 0x51, 0x33, 0xe8, 0x09, 0xdc, 0xc8, 0x2e, 0x9d, 0x28, 0xd5, 0x84, 0xd2, 0x5a, 0xda,
+	0x06, 0xad, 0xd6, 0xf9, 0x67, 0x09, 0xaa, 0xca, 0xb3, 0xe8, 0x9b, 0x22, 0x2f, 0x36, 0xb6, 0x91,
+	0x8e, 0xcb, 0x9e, 0xcb, 0x75, 0x10, 0xb2, 0x9e, 0x7d, 0x34, 0xeb, 0xd9, 0x62, 0xb5, 0xb4, 0xb7,
+	0x9f, 0x14, 0x78, 0xbb, 0x58, 0x2b, 0x13, 0x81, 0x87, 0x33, 0x11, 0x28, 0x56, 0x4a, 0x45, 0xe5,
+	0xe7, 0x85, 0x51, 0x99, 0xb3, 0xb1, 0x4c, 0xa4, 0x1e, 0x17, 0x44, 0x6a, 0xce, 0xce, 0x52, 0xd1,
+	0xeb, 0x5d, 0x14, 0xbd, 0x62, 0xf5, 0xa2, 0x88, 0xee, 0x5f, 0x1c, 0xd1, 0x62, 0xa2, 0xc2, 0x28,
+	0x9b, 0x3f, 0x00, 0x24, 0x18, 0xb4, 0x0e, 0x75, 0x66, 0x87, 0x6f, 0xa6, 0x98, 0x11, 0x47, 0x9d,
+	0x94, 0x1a, 0x1b, 0xc8, 0x31, 0xfa, 0x12, 0x9a, 0x51, 0x31, 0x73, 0x30, 0x73, 0x6c, 0xc2, 0x18,
+	0x65, 0xea, 0xa0, 0x2c, 0x6b, 0x69, 0x2f, 0x12, 0x8a, 0x2e, 0x8a, 0xfd, 0x89, 0x47, 0x64, 0xe0,
+	0x96, 0x2d, 0x3d, 0x34, 0xdf, 0xc1, 0x4a, 0x9f, 0x8e, 0x64, 0x9f, 0x54, 0xf5, 0xf0, 0x11, 0x2c,
+	0x46, 0xed, 0x44, 0xdf, 0xd8, 0x6e, 0x5e, 0x78, 0xd0, 0x2d, 0x89, 0x45, 0xf7, 0xa1, 0x3e, 0xc2,
+	0x9e, 0x17, 0x72, 0x3c, 0x7a, 0xad, 0x52, 0xea, 0xaa, 0x56, 0xdc, 0xd5, 0x13, 0x56, 0x82, 0x31,
+	0x27, 0xd0, 0xcc, 0x16, 0xca, 0xa8, 0xa2, 0x7b, 0xca, 0x14, 0xbd, 0x76, 0x5c, 0xd1, 0x73, 0x36,
+	0x5a, 0x09, 0x12, 0xfd, 0x3f, 0xac, 0x88, 0x8e, 0x60, 0x27, 0xca, 0x0b, 0x62, 0x8f, 0x4d, 0x21,
+	0xd6, 0x9a, 0xa1, 0xf9, 0x17, 0x43, 0x5c, 0xa3, 0xf7, 0x3c, 0xec, 0x93, 0x67, 0x0c, 0x4f, 0xce,
+	0x3e, 0xed, 0x66, 0xf9, 0x0b, 0x68, 0xd0, 0x61, 0x74, 0x19, 0x21, 0x8e, 0x8d, 0xb9, 0xda, 0x73,
+	0x67, 0xa6, 0x76, 0x1f, 0xeb, 0xbf, 0x0c, 0x0b, 0x34, 0xbc, 0xcb, 0xe3, 0x8a, 0x5f, 0x7a, 0xaf,
+	0x8a, 0x6f, 0xf6, 0xe1, 0x5a, 0xce, 0x72, 0x75, 0xc1, 0x7e, 0x04, 0x8d, 0x71, 0x24, 0xb5, 0x4f,
+	0x23, 0xf1, 0x4c, 0x09, 0x48, 0x14, 0x60, 0x1c, 0x7f, 0x9b, 0xbf, 0x33, 0x00, 0x92, 0xa9, 0xfc,
+	0x46, 0x8c, 0x0f, 0xda, 0xc8, 0x5d, 0x28, 0x33, 0x4a, 0x79, 0xbe, 0x07, 0x26, 0xf4, 0x87, 0xd1,
+	0xed, 0x4b, 0x60, 0xc4, 0x95, 0x83, 0x7a, 0x0e, 0x71, 0xd4, 0x7d, 0x4b, 0x8d, 0xcc, 0xdf, 0x2f,
+	0x40, 0x33, 0xab, 0x80, 0xee, 0xc0, 0xe2, 0x98, 0x61, 0x9f, 0xe4, 0x77, 0x34, 0x88, 0x72, 0x67,
+	0x2f, 0x9a, 0xb1, 0x24, 0x60, 0xa6, 0x3c, 0x47, 0x86, 0x94, 0x32, 0x07, 0x7c, 0xb6, 0xcc, 0x97,
+	0x04, 0x26, 0x5b, 0x3c, 0xbe, 0x82, 0xab, 0x99, 0x32, 0x20, 0x6e, 0x54, 0xb2, 0x65, 0x34, 0x13,
+	0x32, 0xd5, 0xae, 0x57, 0x73, 0x47, 0x5d, 0x80, 0x65, 0xf3, 0x68, 0xa5, 0x59, 0x05, 0x7c, 0x1b,
+	0x6a, 0xa3, 0x33, 0xd7, 0x73, 0x18, 0x09, 0xda, 0x95, 0xcd, 0xd2, 0x05, 0x6e, 0x8a, 0x71, 0xe6,
+	0x5f, 0x0d, 0xf1, 0x7f, 0xb6, 0x47, 0x19, 0x19, 0xe1, 0x90, 0x7f, 0x5a, 0xa6, 0x7e, 0x0e, 0x4b,
+	0xbe, 0x38, 0xbb, 0xb6, 0xbc, 0x40, 0x46, 0x2e, 0x2a, 0x5b, 0x0d, 0x29, 0xeb, 0x47, 0xa2, 0x0f,
+	0xcc, 0x47, 0x74, 0x0b, 0x60, 0x44, 0x83, 0xb1, 0xeb, 0x90, 0x60, 0xa4, 0xbd, 0x94, 0x92, 0x98,
+	0xbb, 0xb0, 0x9a, 0xb1, 0x5e, 0x65, 0xeb, 0x3d, 0xa8, 0x8d, 0x95, 0x4c, 0xd9, 0xdf, 0x8a, 0x3d,
+	0xa1, 0xb1, 0x31, 0xc2, 0xfc, 0xa9, 0x0c, 0x35, 0x2d, 0xfe, 0xb4, 0x24, 0x7d, 0x0f, 0x07, 0xe4,
+	0xd3, 0xa8, 0x34, 0xd3, 0xe5, 0x3f, 0x20, 0x41, 0xee, 0x25, 0x97, 0xa5, 0xf9, 0xbd, 0x4b, 0x43,
+	0xd0, 0x33, 0x40, 0xd1, 0x53, 0x84, 0xcd, 0xa9, 0x4d, 0x7e, 0x3c, 0xc3, 0xd3, 0x50, 0xfc, 0xcf,
+	0x54, 0x2e, 0x8b, 0x44, 0x2b, 0x52, 0x3a, 0xa6, 0xbd, 0x58, 0x05, 0xbd, 0x80, 0x55, 0x82, 0x99,
+	0xe7, 0x92, 0x90, 0xa7, 0x99, 0xaa, 0x97, 0x31, 0x21, 0xad, 0x95, 0xe2, 0xda, 0x83, 0xab, 0x1e,
+	0xe6, 0x39, 0xa6, 0xda, 0xa5, 0x36, 0x49, 0x9d, 0x14, 0x4f, 0x36, 0x53, 0xea, 0xf9, 0x4c, 0x41,
+	0x3b, 0xb0, 0x3c, 0xa2, 0x01, 0x67, 0xee, 0x70, 0x2a, 0x6b, 0x37, 0x88, 0x13, 0xb2, 0x91, 0x2f,
+	0xfc, 0xbb, 0x29, 0x90, 0x95, 0x55, 0x31, 0xff, 0x60, 0xc0, 0x5a, 0x11, 0x2e, 0xdb, 0x94, 0x8c,
+	0xcb, 0x9b, 0x52, 0x61, 0x31, 0x79, 0x8f, 0x2c, 0x28, 0x15, 0x66, 0xc1, 0x1a, 0x2c, 0x86, 0x67,
+	0x98, 0xe9, 0x24, 0x91, 0x03, 0xf3, 0x40, 0x94, 0xf2, 0x6e, 0x10, 0x50, 0x2e, 0xfb, 0xd2, 0x27,
+	0x9d, 0x6d, 0xf3, 0x10, 0xae, 0xe7, 0xe9, 0xd4, 0x61, 0x7b, 0x0c, 0x0d, 0x9c, 0x88, 0x55, 0x43,
+	0x8d, 0x13, 0x31, 0xd1, 0xb0, 0xd2, 0x30, 0xf3, 0x8f, 0x86, 0x38, 0xba, 0xdd, 0x80, 0xfa, 0x38,
+	0xca, 0x89, 0xcb, 0xff, 0xc3, 0xc5, 0x8f, 0x98, 0xfa, 0x15, 0xce, 0xff, 0x85, 0xe7, 0xff, 0x94,
+	0x13, 0xe4, 0x87, 0x56, 0x9c, 0x0d, 0xa8, 0xf3, 0x33, 0x46, 0xc2, 0x33, 0xea, 0x39, 0xfa, 0x26,
+	0x1f, 0x0b, 0xcc, 0x1e, 0xac, 0x65, 0x8d, 0x56, 0x3e, 0xf8, 0x1a, 0xea, 0x58, 0x0b, 0xf3, 0x0f,
+	0x50, 0x12, 0x7d, 0x6e, 0x25, 0x08, 0xf3, 0x1f, 0x06, 0x54, 0x95, 0xf8, 0x23, 0x4b, 0xed, 0x87,
+	0x5e, 0x83, 0x10, 0x82, 0x72, 0x2a, 0x85, 0xc4, 0x77, 0x54, 0xae, 0x26, 0x84, 0xb0, 0xd0, 0xf6,
+	0x89, 0xe3, 0xe2, 0x40, 0x6d, 0xb7, 0x21, 0x64, 0x07, 0x42, 0x24, 0x72, 0x6b, 0x44, 0x99, 0x6e,
+	0x3a, 0x72, 0x10, 0x49, 0x05, 0x48, 0xbd, 0x1c, 0xc8, 0x81, 0xd9, 0x16, 0x29, 0xf2, 0x02, 0x07,
+	0x2e, 0xa7, 0x6c, 0xc0, 0x31, 0x8f, 0xdf, 0x2e, 0x7b, 0x70, 0x63, 0x66, 0x46, 0x79, 0xee, 0x2e,
+	0x2c, 0x86, 0x91, 0x40, 0x6d, 0x7e, 0x4d, 0x6f, 0x22, 0x03, 0x96, 0x10, 0xf3, 0xef, 0x06, 0x2c,
+	0xa5, 0xe5, 0x62, 0x53, 0xd3, 0x40, 0xea, 0x96, 0x2d, 0xf1, 0x8d, 0xbe, 0x82, 0x96, 0x43, 0x3c,
+	0xc2, 0x89, 0x63, 0xc7, 0x4f, 0x82, 0xb2, 0x0e, 0xaf, 0x28, 0xb9, 0xf2, 0xab, 0xb8, 0xd1, 0x31,
+	0x32, 0xf2, 0xb0, 0xeb, 0x13, 0x27, 0x55, 0x8e, 0x4b, 0x56, 0x33, 0x16, 0xeb, 0x9f, 0x92, 0x9a,
+	0x87, 0x43, 0x6e, 0xb3, 0x69, 0xd0, 0x2e, 0x5f, 0xda, 0x11, 0xaa, 0x11, 0xd6, 0x9a, 0x06, 0xd1,
+	0x6f, 0xa1, 0x50, 0x93, 0x17, 0xe6, 0x45, 0x91, 0xce, 0xf5, 0x48, 0x22, 0x2e, 0xcb, 0x77, 0xff,
+	0x6c, 0xc0, 0x4a, 0x2e, 0x71, 0x51, 0x0b, 0x96, 0xf6, 0x0f, 0xed, 0x93, 0x41, 0xcf, 0xde, 0xf9,
+	0xcd, 0x71, 0x6f, 0xd0, 0xba, 0x82, 0x10, 0x34, 0x95, 0xe4, 0x68, 0xe7, 0x45, 0x6f, 0xf7, 0x78,
+	0xd0, 0x32, 0xd0, 0x0a, 0x34, 0xba, 0xfd, 0xfe, 0xd1, 0xae, 0x02, 0x2d, 0xa0, 0xab, 0xb0, 0x2c,
+	0x05, 0x1a, 0x53, 0x42, 0x4d, 0x80, 0x3d, 0xab, 0xa7, 0x79, 0xca, 0x11, 0xb3, 0x18, 0x6b, 0xc4,
+	0x22, 0xfa, 0x0c, 0xae, 0xa5, 0xd7, 0xb2, 0x77, 0xba, 0x83, 0x5e, 0x7f, 0xff, 0xb0, 0xd7, 0xaa,
+	0xa0, 0x75, 0xb8, 0x91, 0x5d, 0x34, 0x99, 0xac, 0xde, 0x7d, 0x05, 0x2b, 0xb9, 0x57, 0x2f, 0xb4,
+	0x0c, 0xf5, 0xdd, 0x6e, 0xbf, 0x3f, 0x38, 0xee, 0xee, 0xbe, 0x6c, 0x5d, 0x41, 0x4b, 0x50, 0xdb,
+	0x3b, 0x39, 0xdc, 0x3d, 0xde, 0x3f, 0x3a, 0x6c, 0x19, 0xa8, 0x06, 0xe5, 0xbd, 0xfd, 0x7e, 0xaf,
+	0xb5, 0x80, 0x1a, 0x50, 0xfd, 0xae, 0xbb, 0xfb, 0xb2, 0xfb, 0xac, 0xd7, 0x2a, 0x21, 0x80, 0xca,
+	0xc1, 0xd1, 0xd3, 0x93, 0x7e, 0xaf, 0x55, 0xbe, 0xbb, 0x0d, 0xcd, 0xec, 0x93, 0x00, 0xaa, 0x42,
+	0xe9, 0xa8, 0x1f, 0xed, 0x7f, 0x19, 0xea, 0xc7, 0xcf, 0x7b, 0xfb, 0x7d, 0x7b, 0xd0, 0x53, 0x64,
+	0xbd, 0xef, 0x0f, 0xba, 0xad, 0x85, 0xed, 0x3f, 0x55, 0x60, 0xf5, 0x80, 0xf8, 0x13, 0x46, 0xc7,
+	0xae, 0x47, 0xd8, 0x9e, 0x7a, 0xca, 0x47, 0xcf, 0xa1, 0x91, 0x7a, 0x28, 0x47, 0xf1, 0x71, 0x9a,
+	0x7d, 0x53, 0xef, 0xac, 0x17, 0xce, 0xc9, 0xcc, 0x34, 0xaf, 0xa0, 0x97, 0xb0, 0x94, 0x7e, 0x0e,
+	0x47, 0x69, 0x78, 0xfe, 0x55, 0xbd, 0xb3, 0x51, 0x3c, 0x19, 0x93, 0x69, 0xb3, 0x54, 0xee, 0x65,
+	0xcd, 0xca, 0xbc, 0x8e, 0x77, 0xd6, 0x0b, 0xe7, 0x62, 0xa6, 0x13, 0x58, 0x2d, 0x78, 0xbe, 0x46,
+	0x66, 0x5c, 0x37, 0xe6, 0xbe, 0x6d, 0x77, 0xe6, 0xbc, 0x1d, 0x99, 0x57, 0x1e, 0x18, 0xb3, 0xb4,
+	0xb2, 0xec, 0xce, 0xa1, 0x4d, 0x3f, 0x9e, 0xa6, 0x69, 0xd3, 0x2f, 0x6d, 0x82, 0xf6, 0x10, 0x96,
+	0x33, 0xbf, 0x14, 0x28, 0xed, 0xa8, 0x99, 0x7f, 0xa4, 0xce, 0xcd, 0x39, 0xb3, 0x39, 0x3f, 0xc6,
+	0xf7, 0xb5, 0xb4, 0x1f, 0x73, 0xb7, 0xd8, 0xce, 0x7a, 0xe1, 0x5c, 0xcc, 0xf4, 0x0a, 0x9a, 0xd9,
+	0x96, 0x86, 0xd2, 0x8b, 0xcf, 0x76, 0xce, 0xce, 0xad, 0x79, 0xd3, 0xb9, 0x8c, 0x89, 0xfb, 0x43,
+	0x26, 0x63, 0xf2, 0xad, 0xae, 0xb3, 0x51, 0x3c, 0x19, 0x93, 0x1d, 0xc3, 0x4a, 0xae, 0x6a, 0xa2,
+	0xb4, 0x05, 0x05, 0x85, 0xb6, 0x73, 0x7b, 0xee, 0xbc, 0x66, 0x1d, 0x56, 0x44, 0xc5, 0x7a, 0xf4,
+	0x9f, 0x01, 0x00, 0xc8, 0xde, 0x7c, 0x0a, 0xfc, 0x1a, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -604,6 +2324,20 @@ type MemprofilerFrontendClient interface {
 	GetSessions(ctx context.Context, in *GetSessionsRequest, opts ...grpc.CallOption) (*GetSessionsResponse, error)
 	// SubscribeForSession returns the stream of session updates with fresh trend values, if it's still alive
 	SubscribeForSession(ctx context.Context, in *SubscribeForSessionRequest, opts ...grpc.CallOption) (MemprofilerFrontend_SubscribeForSessionClient, error)
+	// SubscribeForService returns the stream of metrics merged across the live sessions of all service instances
+	SubscribeForService(ctx context.Context, in *SubscribeForServiceRequest, opts ...grpc.CallOption) (MemprofilerFrontend_SubscribeForServiceClient, error)
+	// GetFlameGraph returns merged call tree of the session in-use memory
+	GetFlameGraph(ctx context.Context, in *GetFlameGraphRequest, opts ...grpc.CallOption) (*GetFlameGraphResponse, error)
+	// GetForecast predicts when the session in-use memory reaches the memory limit
+	GetForecast(ctx context.Context, in *GetForecastRequest, opts ...grpc.CallOption) (*GetForecastResponse, error)
+	// GetAnnotations returns all annotations stored for the session;
+	// change points of the session in-use memory are stored when the session is finished
+	GetAnnotations(ctx context.Context, in *GetAnnotationsRequest, opts ...grpc.CallOption) (*GetAnnotationsResponse, error)
+	// GetAnomalies compares rates of the live sessions of a service
+	// and returns instances and locations that are outliers relative to their peers
+	GetAnomalies(ctx context.Context, in *GetAnomaliesRequest, opts ...grpc.CallOption) (*GetAnomaliesResponse, error)
+	// GetJanitorStats returns statistics of the expired sessions removal
+	GetJanitorStats(ctx context.Context, in *GetJanitorStatsRequest, opts ...grpc.CallOption) (*GetJanitorStatsResponse, error)
 }
 
 type memprofilerFrontendClient struct {
@@ -673,6 +2407,83 @@ func (x *memprofilerFrontendSubscribeForSessionClient) Recv() (*SessionMetrics,
 	return m, nil
 }
 
+func (c *memprofilerFrontendClient) SubscribeForService(ctx context.Context, in *SubscribeForServiceRequest, opts ...grpc.CallOption) (MemprofilerFrontend_SubscribeForServiceClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_MemprofilerFrontend_serviceDesc.Streams[1], "/schema.MemprofilerFrontend/SubscribeForService", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &memprofilerFrontendSubscribeForServiceClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type MemprofilerFrontend_SubscribeForServiceClient interface {
+	Recv() (*ServiceMetrics, error)
+	grpc.ClientStream
+}
+
+type memprofilerFrontendSubscribeForServiceClient struct {
+	grpc.ClientStream
+}
+
+func (x *memprofilerFrontendSubscribeForServiceClient) Recv() (*ServiceMetrics, error) {
+	m := new(ServiceMetrics)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *memprofilerFrontendClient) GetFlameGraph(ctx context.Context, in *GetFlameGraphRequest, opts ...grpc.CallOption) (*GetFlameGraphResponse, error) {
+	out := new(GetFlameGraphResponse)
+	err := c.cc.Invoke(ctx, "/schema.MemprofilerFrontend/GetFlameGraph", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *memprofilerFrontendClient) GetForecast(ctx context.Context, in *GetForecastRequest, opts ...grpc.CallOption) (*GetForecastResponse, error) {
+	out := new(GetForecastResponse)
+	err := c.cc.Invoke(ctx, "/schema.MemprofilerFrontend/GetForecast", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *memprofilerFrontendClient) GetAnnotations(ctx context.Context, in *GetAnnotationsRequest, opts ...grpc.CallOption) (*GetAnnotationsResponse, error) {
+	out := new(GetAnnotationsResponse)
+	err := c.cc.Invoke(ctx, "/schema.MemprofilerFrontend/GetAnnotations", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *memprofilerFrontendClient) GetAnomalies(ctx context.Context, in *GetAnomaliesRequest, opts ...grpc.CallOption) (*GetAnomaliesResponse, error) {
+	out := new(GetAnomaliesResponse)
+	err := c.cc.Invoke(ctx, "/schema.MemprofilerFrontend/GetAnomalies", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *memprofilerFrontendClient) GetJanitorStats(ctx context.Context, in *GetJanitorStatsRequest, opts ...grpc.CallOption) (*GetJanitorStatsResponse, error) {
+	out := new(GetJanitorStatsResponse)
+	err := c.cc.Invoke(ctx, "/schema.MemprofilerFrontend/GetJanitorStats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // MemprofilerFrontendServer is the server API for MemprofilerFrontend service.
 type MemprofilerFrontendServer interface {
 	// GetServices returns the list of registered services
@@ -683,6 +2494,20 @@ type MemprofilerFrontendServer interface {
 	GetSessions(context.Context, *GetSessionsRequest) (*GetSessionsResponse, error)
 	// SubscribeForSession returns the stream of session updates with fresh trend values, if it's still alive
 	SubscribeForSession(*SubscribeForSessionRequest, MemprofilerFrontend_SubscribeForSessionServer) error
+	// SubscribeForService returns the stream of metrics merged across the live sessions of all service instances
+	SubscribeForService(*SubscribeForServiceRequest, MemprofilerFrontend_SubscribeForServiceServer) error
+	// GetFlameGraph returns merged call tree of the session in-use memory
+	GetFlameGraph(context.Context, *GetFlameGraphRequest) (*GetFlameGraphResponse, error)
+	// GetForecast predicts when the session in-use memory reaches the memory limit
+	GetForecast(context.Context, *GetForecastRequest) (*GetForecastResponse, error)
+	// GetAnnotations returns all annotations stored for the session;
+	// change points of the session in-use memory are stored when the session is finished
+	GetAnnotations(context.Context, *GetAnnotationsRequest) (*GetAnnotationsResponse, error)
+	// GetAnomalies compares rates of the live sessions of a service
+	// and returns instances and locations that are outliers relative to their peers
+	GetAnomalies(context.Context, *GetAnomaliesRequest) (*GetAnomaliesResponse, error)
+	// GetJanitorStats returns statistics of the expired sessions removal
+	GetJanitorStats(context.Context, *GetJanitorStatsRequest) (*GetJanitorStatsResponse, error)
 }
 
 // UnimplementedMemprofilerFrontendServer can be embedded to have forward compatible implementations.
@@ -701,6 +2526,24 @@ func (*UnimplementedMemprofilerFrontendServer) GetSessions(ctx context.Context,
 func (*UnimplementedMemprofilerFrontendServer) SubscribeForSession(req *SubscribeForSessionRequest, srv MemprofilerFrontend_SubscribeForSessionServer) error {
 	return status.Errorf(codes.Unimplemented, "method SubscribeForSession not implemented")
 }
+func (*UnimplementedMemprofilerFrontendServer) SubscribeForService(req *SubscribeForServiceRequest, srv MemprofilerFrontend_SubscribeForServiceServer) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeForService not implemented")
+}
+func (*UnimplementedMemprofilerFrontendServer) GetFlameGraph(ctx context.Context, req *GetFlameGraphRequest) (*GetFlameGraphResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFlameGraph not implemented")
+}
+func (*UnimplementedMemprofilerFrontendServer) GetForecast(ctx context.Context, req *GetForecastRequest) (*GetForecastResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetForecast not implemented")
+}
+func (*UnimplementedMemprofilerFrontendServer) GetAnnotations(ctx context.Context, req *GetAnnotationsRequest) (*GetAnnotationsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAnnotations not implemented")
+}
+func (*UnimplementedMemprofilerFrontendServer) GetAnomalies(ctx context.Context, req *GetAnomaliesRequest) (*GetAnomaliesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAnomalies not implemented")
+}
+func (*UnimplementedMemprofilerFrontendServer) GetJanitorStats(ctx context.Context, req *GetJanitorStatsRequest) (*GetJanitorStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetJanitorStats not implemented")
+}
 
 func RegisterMemprofilerFrontendServer(s *grpc.Server, srv MemprofilerFrontendServer) {
 	s.RegisterService(&_MemprofilerFrontend_serviceDesc, srv)
@@ -781,6 +2624,117 @@ func (x *memprofilerFrontendSubscribeForSessionServer) Send(m *SessionMetrics) e
 	return x.ServerStream.SendMsg(m)
 }
 
+func _MemprofilerFrontend_SubscribeForService_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeForServiceRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MemprofilerFrontendServer).SubscribeForService(m, &memprofilerFrontendSubscribeForServiceServer{stream})
+}
+
+type MemprofilerFrontend_SubscribeForServiceServer interface {
+	Send(*ServiceMetrics) error
+	grpc.ServerStream
+}
+
+type memprofilerFrontendSubscribeForServiceServer struct {
+	grpc.ServerStream
+}
+
+func (x *memprofilerFrontendSubscribeForServiceServer) Send(m *ServiceMetrics) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _MemprofilerFrontend_GetFlameGraph_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetFlameGraphRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MemprofilerFrontendServer).GetFlameGraph(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/schema.MemprofilerFrontend/GetFlameGraph",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MemprofilerFrontendServer).GetFlameGraph(ctx, req.(*GetFlameGraphRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MemprofilerFrontend_GetForecast_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetForecastRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MemprofilerFrontendServer).GetForecast(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/schema.MemprofilerFrontend/GetForecast",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MemprofilerFrontendServer).GetForecast(ctx, req.(*GetForecastRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MemprofilerFrontend_GetAnnotations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAnnotationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MemprofilerFrontendServer).GetAnnotations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/schema.MemprofilerFrontend/GetAnnotations",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MemprofilerFrontendServer).GetAnnotations(ctx, req.(*GetAnnotationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MemprofilerFrontend_GetAnomalies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAnomaliesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MemprofilerFrontendServer).GetAnomalies(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/schema.MemprofilerFrontend/GetAnomalies",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MemprofilerFrontendServer).GetAnomalies(ctx, req.(*GetAnomaliesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MemprofilerFrontend_GetJanitorStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetJanitorStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MemprofilerFrontendServer).GetJanitorStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/schema.MemprofilerFrontend/GetJanitorStats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MemprofilerFrontendServer).GetJanitorStats(ctx, req.(*GetJanitorStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _MemprofilerFrontend_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "schema.MemprofilerFrontend",
 	HandlerType: (*MemprofilerFrontendServer)(nil),
@@ -797,6 +2751,26 @@ var _MemprofilerFrontend_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetSessions",
 			Handler:    _MemprofilerFrontend_GetSessions_Handler,
 		},
+		{
+			MethodName: "GetFlameGraph",
+			Handler:    _MemprofilerFrontend_GetFlameGraph_Handler,
+		},
+		{
+			MethodName: "GetForecast",
+			Handler:    _MemprofilerFrontend_GetForecast_Handler,
+		},
+		{
+			MethodName: "GetAnnotations",
+			Handler:    _MemprofilerFrontend_GetAnnotations_Handler,
+		},
+		{
+			MethodName: "GetAnomalies",
+			Handler:    _MemprofilerFrontend_GetAnomalies_Handler,
+		},
+		{
+			MethodName: "GetJanitorStats",
+			Handler:    _MemprofilerFrontend_GetJanitorStats_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -804,6 +2778,11 @@ var _MemprofilerFrontend_serviceDesc = grpc.ServiceDesc{
 			Handler:       _MemprofilerFrontend_SubscribeForSession_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "SubscribeForService",
+			Handler:       _MemprofilerFrontend_SubscribeForService_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "frontend.proto",
 }
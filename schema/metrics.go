@@ -0,0 +1,127 @@
+package schema
+
+// Metric describes a memory indicator tracked for every location;
+// storages, metrics computer and API share the Metrics table,
+// so adding new indicator requires just a single declaration
+type Metric struct {
+	// Indicator identifies metric within API
+	Indicator MemoryIndicator
+	// Name is used to identify metric within storages, so it must never be changed
+	Name string
+	// Stored is true if metric values are persisted; others are derived from the stored ones
+	Stored bool
+	// Baseline is true if metric is a lower envelope (rolling minimum) of the Source metric;
+	// such metrics are computed from the history of the Source metric values
+	Baseline bool
+	// Source is a metric the baseline is computed for
+	Source MemoryIndicator
+	// Value extracts metric value from memory usage (all metrics except baselines)
+	Value func(mu *MemoryUsage) float64
+	// SetValue puts metric value into memory usage (stored metrics only)
+	SetValue func(mu *MemoryUsage, value float64)
+	// Rate extracts metric rate from rate values
+	Rate func(values *MemoryUtilizationRate_Values) float64
+	// SetRate puts metric rate into rate values
+	SetRate func(values *MemoryUtilizationRate_Values, rate float64)
+	// Quality extracts goodness-of-fit values of metric rate
+	Quality func(quality *MemoryUtilizationRate_Quality) *FitQuality
+	// SetQuality puts goodness-of-fit values of metric rate
+	SetQuality func(quality *MemoryUtilizationRate_Quality, fit *FitQuality)
+}
+
+// Metrics is a table of all memory indicators indexed by MemoryIndicator values
+var Metrics = []*Metric{
+	MemoryIndicator_IN_USE_BYTES: {
+		Indicator:  MemoryIndicator_IN_USE_BYTES,
+		Name:       "InUseBytes",
+		Value:      func(mu *MemoryUsage) float64 { return float64(mu.GetAllocBytes()) - float64(mu.GetFreeBytes()) },
+		Rate:       func(v *MemoryUtilizationRate_Values) float64 { return v.GetInUseBytes() },
+		SetRate:    func(v *MemoryUtilizationRate_Values, rate float64) { v.InUseBytes = rate },
+		Quality:    func(q *MemoryUtilizationRate_Quality) *FitQuality { return q.GetInUseBytes() },
+		SetQuality: func(q *MemoryUtilizationRate_Quality, fit *FitQuality) { q.InUseBytes = fit },
+	},
+	MemoryIndicator_IN_USE_OBJECTS: {
+		Indicator:  MemoryIndicator_IN_USE_OBJECTS,
+		Name:       "InUseObjects",
+		Value:      func(mu *MemoryUsage) float64 { return float64(mu.GetAllocObjects()) - float64(mu.GetFreeObjects()) },
+		Rate:       func(v *MemoryUtilizationRate_Values) float64 { return v.GetInUseObjects() },
+		SetRate:    func(v *MemoryUtilizationRate_Values, rate float64) { v.InUseObjects = rate },
+		Quality:    func(q *MemoryUtilizationRate_Quality) *FitQuality { return q.GetInUseObjects() },
+		SetQuality: func(q *MemoryUtilizationRate_Quality, fit *FitQuality) { q.InUseObjects = fit },
+	},
+	MemoryIndicator_ALLOC_BYTES: {
+		Indicator:  MemoryIndicator_ALLOC_BYTES,
+		Name:       "AllocBytes",
+		Stored:     true,
+		Value:      func(mu *MemoryUsage) float64 { return float64(mu.GetAllocBytes()) },
+		SetValue:   func(mu *MemoryUsage, value float64) { mu.AllocBytes = int64(value) },
+		Rate:       func(v *MemoryUtilizationRate_Values) float64 { return v.GetAllocBytes() },
+		SetRate:    func(v *MemoryUtilizationRate_Values, rate float64) { v.AllocBytes = rate },
+		Quality:    func(q *MemoryUtilizationRate_Quality) *FitQuality { return q.GetAllocBytes() },
+		SetQuality: func(q *MemoryUtilizationRate_Quality, fit *FitQuality) { q.AllocBytes = fit },
+	},
+	MemoryIndicator_ALLOC_OBJECTS: {
+		Indicator:  MemoryIndicator_ALLOC_OBJECTS,
+		Name:       "AllocObjects",
+		Stored:     true,
+		Value:      func(mu *MemoryUsage) float64 { return float64(mu.GetAllocObjects()) },
+		SetValue:   func(mu *MemoryUsage, value float64) { mu.AllocObjects = int64(value) },
+		Rate:       func(v *MemoryUtilizationRate_Values) float64 { return v.GetAllocObjects() },
+		SetRate:    func(v *MemoryUtilizationRate_Values, rate float64) { v.AllocObjects = rate },
+		Quality:    func(q *MemoryUtilizationRate_Quality) *FitQuality { return q.GetAllocObjects() },
+		SetQuality: func(q *MemoryUtilizationRate_Quality, fit *FitQuality) { q.AllocObjects = fit },
+	},
+	MemoryIndicator_FREE_BYTES: {
+		Indicator:  MemoryIndicator_FREE_BYTES,
+		Name:       "FreeBytes",
+		Stored:     true,
+		Value:      func(mu *MemoryUsage) float64 { return float64(mu.GetFreeBytes()) },
+		SetValue:   func(mu *MemoryUsage, value float64) { mu.FreeBytes = int64(value) },
+		Rate:       func(v *MemoryUtilizationRate_Values) float64 { return v.GetFreeBytes() },
+		SetRate:    func(v *MemoryUtilizationRate_Values, rate float64) { v.FreeBytes = rate },
+		Quality:    func(q *MemoryUtilizationRate_Quality) *FitQuality { return q.GetFreeBytes() },
+		SetQuality: func(q *MemoryUtilizationRate_Quality, fit *FitQuality) { q.FreeBytes = fit },
+	},
+	MemoryIndicator_FREE_OBJECTS: {
+		Indicator:  MemoryIndicator_FREE_OBJECTS,
+		Name:       "FreeObjects",
+		Stored:     true,
+		Value:      func(mu *MemoryUsage) float64 { return float64(mu.GetFreeObjects()) },
+		SetValue:   func(mu *MemoryUsage, value float64) { mu.FreeObjects = int64(value) },
+		Rate:       func(v *MemoryUtilizationRate_Values) float64 { return v.GetFreeObjects() },
+		SetRate:    func(v *MemoryUtilizationRate_Values, rate float64) { v.FreeObjects = rate },
+		Quality:    func(q *MemoryUtilizationRate_Quality) *FitQuality { return q.GetFreeObjects() },
+		SetQuality: func(q *MemoryUtilizationRate_Quality, fit *FitQuality) { q.FreeObjects = fit },
+	},
+	MemoryIndicator_IN_USE_BYTES_BASELINE: {
+		Indicator:  MemoryIndicator_IN_USE_BYTES_BASELINE,
+		Name:       "InUseBytesBaseline",
+		Baseline:   true,
+		Source:     MemoryIndicator_IN_USE_BYTES,
+		Rate:       func(v *MemoryUtilizationRate_Values) float64 { return v.GetInUseBytesBaseline() },
+		SetRate:    func(v *MemoryUtilizationRate_Values, rate float64) { v.InUseBytesBaseline = rate },
+		Quality:    func(q *MemoryUtilizationRate_Quality) *FitQuality { return q.GetInUseBytesBaseline() },
+		SetQuality: func(q *MemoryUtilizationRate_Quality, fit *FitQuality) { q.InUseBytesBaseline = fit },
+	},
+	MemoryIndicator_IN_USE_OBJECTS_BASELINE: {
+		Indicator:  MemoryIndicator_IN_USE_OBJECTS_BASELINE,
+		Name:       "InUseObjectsBaseline",
+		Baseline:   true,
+		Source:     MemoryIndicator_IN_USE_OBJECTS,
+		Rate:       func(v *MemoryUtilizationRate_Values) float64 { return v.GetInUseObjectsBaseline() },
+		SetRate:    func(v *MemoryUtilizationRate_Values, rate float64) { v.InUseObjectsBaseline = rate },
+		Quality:    func(q *MemoryUtilizationRate_Quality) *FitQuality { return q.GetInUseObjectsBaseline() },
+		SetQuality: func(q *MemoryUtilizationRate_Quality, fit *FitQuality) { q.InUseObjectsBaseline = fit },
+	},
+}
+
+// StoredMetrics returns metrics that are persisted by storages
+func StoredMetrics() []*Metric {
+	result := make([]*Metric, 0, len(Metrics))
+	for _, m := range Metrics {
+		if m.Stored {
+			result = append(result, m)
+		}
+	}
+	return result
+}
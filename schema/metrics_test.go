@@ -0,0 +1,49 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Metrics table must cover every memory indicator and every rate value
+func TestMetrics_Consistency(t *testing.T) {
+	assert.Len(t, Metrics, len(MemoryIndicator_name))
+
+	ratesType := reflect.TypeOf(MemoryUtilizationRate_Values{})
+	rateFields := 0
+	for i := 0; i < ratesType.NumField(); i++ {
+		if ratesType.Field(i).Type.Kind() == reflect.Float64 {
+			rateFields++
+		}
+	}
+	assert.Len(t, Metrics, rateFields)
+
+	for i, metric := range Metrics {
+		assert.Equal(t, MemoryIndicator(i), metric.Indicator)
+
+		values := &MemoryUtilizationRate_Values{}
+		metric.SetRate(values, float64(i+1))
+		assert.Equal(t, float64(i+1), metric.Rate(values))
+		assert.Equal(t, float64(i+1), reflect.ValueOf(values).Elem().FieldByName(metric.Name).Float(), metric.Name)
+
+		quality := &MemoryUtilizationRate_Quality{}
+		fit := &FitQuality{Samples: uint32(i + 1)}
+		metric.SetQuality(quality, fit)
+		assert.Equal(t, fit, metric.Quality(quality))
+		assert.Equal(t, fit, reflect.ValueOf(quality).Elem().FieldByName(metric.Name).Interface(), metric.Name)
+
+		if metric.Baseline {
+			assert.False(t, Metrics[metric.Source].Baseline)
+		} else {
+			assert.NotNil(t, metric.Value)
+		}
+
+		if metric.Stored {
+			mu := &MemoryUsage{}
+			metric.SetValue(mu, float64(i+1))
+			assert.Equal(t, float64(i+1), metric.Value(mu))
+		}
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/memprofiler/memprofiler/server/locator"
 	"github.com/memprofiler/memprofiler/server/metrics"
 	"github.com/memprofiler/memprofiler/server/storage/data"
+	"github.com/memprofiler/memprofiler/server/storage/metadata"
 )
 
 // saveState implements state pattern for handling save requests
@@ -28,6 +29,7 @@ type saveProtocol interface {
 	getSessionDescription() *schema.SessionDescription
 	getStorage() data.Storage
 	getComputer() metrics.Computer
+	getMetadataStorage() metadata.Storage
 	setLogger(logger *zerolog.Logger)
 	getLogger() *zerolog.Logger
 	setDataSaver(data.Saver) error
@@ -49,6 +51,7 @@ type defaultSaveProtocol struct {
 	dataSaver          data.Saver
 	storage            data.Storage
 	computer           metrics.Computer
+	metadataStorage    metadata.Storage
 	logger             *zerolog.Logger
 }
 
@@ -74,6 +77,8 @@ func (p *defaultSaveProtocol) getComputer() metrics.Computer { return p.computer
 
 func (p *defaultSaveProtocol) getStorage() data.Storage { return p.storage }
 
+func (p *defaultSaveProtocol) getMetadataStorage() metadata.Storage { return p.metadataStorage }
+
 func (p *defaultSaveProtocol) getLogger() *zerolog.Logger { return p.logger }
 
 func (p *defaultSaveProtocol) setLogger(l *zerolog.Logger) { p.logger = l }
@@ -91,9 +96,10 @@ func (p *defaultSaveProtocol) getDataSaver() data.Saver { return p.dataSaver }
 func newSaveProtocol(locator *locator.Locator) saveProtocol {
 
 	p := &defaultSaveProtocol{
-		storage:  locator.DataStorage,
-		computer: locator.Computer,
-		logger:   locator.Logger,
+		storage:         locator.DataStorage,
+		computer:        locator.Computer,
+		metadataStorage: locator.MetadataStorage,
+		logger:          locator.Logger,
 	}
 
 	// waiting for header message first
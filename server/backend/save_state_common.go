@@ -1,8 +1,11 @@
 package backend
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/pkg/errors"
+
 	"github.com/memprofiler/memprofiler/schema"
 	"github.com/memprofiler/memprofiler/utils"
 )
@@ -23,8 +26,27 @@ func (s *saveStateCommon) addMeasurement(*schema.Measurement) error {
 }
 
 func (s *saveStateCommon) close() error {
-	if dataSaver := s.p.getDataSaver(); dataSaver != nil {
-		return dataSaver.Close()
+	dataSaver := s.p.getDataSaver()
+	if dataSaver == nil {
+		return nil
+	}
+	if err := dataSaver.Close(); err != nil {
+		return err
+	}
+	return s.annotateSession()
+}
+
+// annotateSession stores change points of the finished session, so frontend only reads them
+func (s *saveStateCommon) annotateSession() error {
+	ctx := context.Background()
+	sd := s.p.getSessionDescription()
+
+	changePoints, err := s.p.getComputer().SessionChangePoints(ctx, sd)
+	if err != nil {
+		return errors.Wrap(err, "detect change points")
+	}
+	if err := s.p.getMetadataStorage().PutAnnotations(ctx, sd, changePoints); err != nil {
+		return errors.Wrap(err, "store annotations")
 	}
 	return nil
 }
@@ -16,6 +16,8 @@ type Config struct {
 	Logging         *LoggingConfig         `yaml:"logging"`
 	DataStorage     *DataStorageConfig     `yaml:"data_storage"`
 	MetadataStorage *MetadataStorageConfig `yaml:"metadata_storage"`
+	Retention       *RetentionConfig       `yaml:"retention"`
+	Downsampling    *DownsamplingConfig    `yaml:"downsampling"`
 }
 
 // Verify checks config
@@ -39,6 +41,12 @@ func (c *Config) Verify() error {
 	if err := c.MetadataStorage.Verify(); err != nil {
 		return errors.Wrap(err, "metadata_storage")
 	}
+	if err := c.Retention.Verify(); err != nil {
+		return errors.Wrap(err, "retention")
+	}
+	if err := c.Downsampling.Verify(); err != nil {
+		return errors.Wrap(err, "downsampling")
+	}
 
 	return nil
 }
@@ -55,13 +55,59 @@ type FilesystemStorageConfig struct {
 	DataDir string `yaml:"data_dir"`
 	// SyncWrite enables fsync after every write
 	SyncWrite bool `yaml:"sync_write"`
+	// Format is a format of new data files: "json" (default) or "binary";
+	// files are self-describing, so files written in other formats are still readable
+	Format FilesystemFormat `yaml:"format"`
+	// Compression is applied to records of binary files: "none" (default) or "gzip"
+	Compression FilesystemCompression `yaml:"compression"`
 }
 
+// FilesystemFormat is a serialization format of measurement records
+type FilesystemFormat string
+
+const (
+	// JSONFormat is a newline-delimited JSON
+	JSONFormat FilesystemFormat = "json"
+	// BinaryFormat is a sequence of varint length-prefixed protobuf messages
+	BinaryFormat FilesystemFormat = "binary"
+)
+
+// FilesystemCompression is a compression algorithm of measurement records
+type FilesystemCompression string
+
+const (
+	// NoCompression keeps records as is
+	NoCompression FilesystemCompression = "none"
+	// GzipCompression compresses blocks of consecutive records with gzip
+	GzipCompression FilesystemCompression = "gzip"
+)
+
 // Verify verifies config
 func (c *FilesystemStorageConfig) Verify() error {
 	if c.DataDir == "" {
 		return fmt.Errorf("empty data_dir")
 	}
+
+	switch c.Format {
+	case "":
+		c.Format = JSONFormat
+	case JSONFormat, BinaryFormat:
+	default:
+		return fmt.Errorf("unknown format: %s", c.Format)
+	}
+
+	switch c.Compression {
+	case "":
+		c.Compression = NoCompression
+	case NoCompression:
+	case GzipCompression:
+		if c.Format != BinaryFormat {
+			return fmt.Errorf("compression is supported only for binary format")
+		}
+	default:
+		return fmt.Errorf("unknown compression: %s", c.Compression)
+	}
+
 	return nil
 }
 
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+const defaultDownsamplingCheckInterval = time.Hour
+
+// DownsamplingTier defines the resolution of measurements of a certain age
+type DownsamplingTier struct {
+	// After is an age of measurements (counted from now) the tier is applied to
+	After time.Duration `yaml:"after"`
+	// Resolution is a time span covered by a single downsampled measurement
+	Resolution time.Duration `yaml:"resolution"`
+}
+
+// DownsamplingConfig contains settings of the job that rewrites old
+// measurements of finished sessions into coarser resolution
+type DownsamplingConfig struct {
+	// Tiers are applied to the measurements of increasing age
+	Tiers []DownsamplingTier `yaml:"tiers"`
+	// CheckInterval is a time between two consecutive job runs
+	CheckInterval time.Duration `yaml:"check_interval"`
+}
+
+// Verify checks config; empty config means that downsampling is disabled
+func (c *DownsamplingConfig) Verify() error {
+	if c == nil {
+		return nil
+	}
+
+	if len(c.Tiers) == 0 {
+		return fmt.Errorf("no tiers configured")
+	}
+
+	sort.Slice(c.Tiers, func(i, j int) bool { return c.Tiers[i].After < c.Tiers[j].After })
+	for i, tier := range c.Tiers {
+		if tier.After <= 0 {
+			return fmt.Errorf("invalid after value: %v", tier.After)
+		}
+		if tier.Resolution <= 0 {
+			return fmt.Errorf("invalid resolution value: %v", tier.Resolution)
+		}
+		if i > 0 && tier.Resolution <= c.Tiers[i-1].Resolution {
+			return fmt.Errorf("resolution of older measurements must be coarser: %v", tier.Resolution)
+		}
+	}
+
+	if c.CheckInterval < 0 {
+		return fmt.Errorf("invalid check_interval value: %v", c.CheckInterval)
+	}
+
+	if c.CheckInterval == 0 {
+		c.CheckInterval = defaultDownsamplingCheckInterval
+	}
+
+	return nil
+}
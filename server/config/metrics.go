@@ -2,10 +2,16 @@ package config
 
 import (
 	"fmt"
+	"runtime"
 	"sort"
+	"strings"
 	"time"
+
+	"github.com/memprofiler/memprofiler/schema"
 )
 
+const defaultBaselinePeriod = time.Minute
+
 // MetricsConfig contains settings for a task runner
 // which computes session metrics in a background
 type MetricsConfig struct {
@@ -13,6 +19,21 @@ type MetricsConfig struct {
 	// be used for session metrics computation. Client may want to have
 	// trend values for last 5 sec, 1 min and 1 hour, for example.
 	AveragingWindows []time.Duration `yaml:"averaging_windows"`
+	// RecomputeInterval is a minimal time between two consecutive recomputations
+	// of session metrics; zero value means that metrics are recomputed on every measurement.
+	// Subscribers may request a longer interval for their own updates
+	RecomputeInterval time.Duration `yaml:"recompute_interval"`
+	// Workers is a number of goroutines shared by all sessions to compute metrics;
+	// if not set, it's equal to the number of CPUs
+	Workers int `yaml:"workers"`
+	// BaselinePeriod is a period of rolling minimum used to compute baseline series (lower envelope
+	// of the sawtooth caused by garbage collection); it should be longer than a typical GC cycle
+	BaselinePeriod time.Duration `yaml:"baseline_period"`
+	// MemoryLimits maps service names to their memory limits [bytes]; they are used to forecast memory exhaustion
+	MemoryLimits map[string]uint64 `yaml:"memory_limits"`
+	// Estimator is a method used to estimate trends: "ols" (default), "theil_sen" or "ewma"
+	Estimator      string `yaml:"estimator"`
+	trendEstimator schema.TrendEstimator
 }
 
 // Verify checks config
@@ -26,7 +47,38 @@ func (c *MetricsConfig) Verify() error {
 		return fmt.Errorf("too many (more than 5) averaging_windows configured: this will cause high CPU consumption")
 	}
 
+	if c.RecomputeInterval < 0 {
+		return fmt.Errorf("invalid recompute_interval value: %v", c.RecomputeInterval)
+	}
+
+	if c.Workers < 0 {
+		return fmt.Errorf("invalid workers value: %d", c.Workers)
+	}
+
+	if c.Workers == 0 {
+		c.Workers = runtime.NumCPU()
+	}
+
+	if c.BaselinePeriod < 0 {
+		return fmt.Errorf("invalid baseline_period value: %v", c.BaselinePeriod)
+	}
+
+	if c.BaselinePeriod == 0 {
+		c.BaselinePeriod = defaultBaselinePeriod
+	}
+
+	if c.Estimator != "" {
+		value, exists := schema.TrendEstimator_value[strings.ToUpper(c.Estimator)]
+		if !exists {
+			return fmt.Errorf("unknown estimator: %s", c.Estimator)
+		}
+		c.trendEstimator = schema.TrendEstimator(value)
+	}
+
 	sort.Slice(c.AveragingWindows, func(i, j int) bool { return c.AveragingWindows[i] < c.AveragingWindows[j] })
 
 	return nil
 }
+
+// TrendEstimator returns the defined trend estimator
+func (c *MetricsConfig) TrendEstimator() schema.TrendEstimator { return c.trendEstimator }
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const defaultRetentionCheckInterval = 10 * time.Minute
+
+// RetentionPolicy defines when the data of finished sessions should be deleted;
+// zero values mean no limit
+type RetentionPolicy struct {
+	// MaxAge is a maximal time passed since the session was finished
+	MaxAge time.Duration `yaml:"max_age"`
+	// MaxSize is a maximal total size of sessions data [bytes]; the oldest sessions are deleted first
+	MaxSize int64 `yaml:"max_size"`
+}
+
+// Verify checks config
+func (p *RetentionPolicy) Verify() error {
+	if p.MaxAge < 0 {
+		return fmt.Errorf("invalid max_age value: %v", p.MaxAge)
+	}
+	if p.MaxSize < 0 {
+		return fmt.Errorf("invalid max_size value: %d", p.MaxSize)
+	}
+	return nil
+}
+
+// RetentionConfig contains settings of the janitor deleting expired sessions;
+// the global policy limits the size of all sessions, while per-service policies
+// override the global one for the sessions of particular services
+type RetentionConfig struct {
+	RetentionPolicy `yaml:",inline"`
+	// Services maps service names to their own retention policies
+	Services map[string]*RetentionPolicy `yaml:"services"`
+	// CheckInterval is a time between two consecutive janitor runs
+	CheckInterval time.Duration `yaml:"check_interval"`
+}
+
+// Verify checks config; empty config means that retention is disabled
+func (c *RetentionConfig) Verify() error {
+	if c == nil {
+		return nil
+	}
+
+	if err := c.RetentionPolicy.Verify(); err != nil {
+		return err
+	}
+
+	for service, policy := range c.Services {
+		if policy == nil {
+			return fmt.Errorf("empty retention policy for service '%s'", service)
+		}
+		if err := policy.Verify(); err != nil {
+			return errors.Wrapf(err, "service '%s'", service)
+		}
+	}
+
+	if c.CheckInterval < 0 {
+		return fmt.Errorf("invalid check_interval value: %v", c.CheckInterval)
+	}
+
+	if c.CheckInterval == 0 {
+		c.CheckInterval = defaultRetentionCheckInterval
+	}
+
+	return nil
+}
+
+// ServicePolicy returns retention policy of a particular service
+func (c *RetentionConfig) ServicePolicy(service string) RetentionPolicy {
+	result := c.RetentionPolicy
+	if policy, exists := c.Services[service]; exists {
+		if policy.MaxAge != 0 {
+			result.MaxAge = policy.MaxAge
+		}
+		// the global size limit is applied to all sessions, so it's not inherited
+		result.MaxSize = policy.MaxSize
+	} else {
+		result.MaxSize = 0
+	}
+	return result
+}
@@ -0,0 +1,145 @@
+package downsampler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	"github.com/memprofiler/memprofiler/schema"
+	"github.com/memprofiler/memprofiler/server/common"
+	"github.com/memprofiler/memprofiler/server/config"
+	"github.com/memprofiler/memprofiler/server/storage/data"
+	"github.com/memprofiler/memprofiler/server/storage/metadata"
+)
+
+// Downsampler rewrites old measurements of finished sessions into coarser resolution in a background
+type Downsampler interface {
+	common.Subsystem
+}
+
+var _ Downsampler = (*defaultDownsampler)(nil)
+
+type defaultDownsampler struct {
+	dataStorage     data.Storage
+	metadataStorage metadata.Storage
+	cfg             *config.DownsamplingConfig
+	logger          *zerolog.Logger
+	ctx             context.Context
+	cancel          context.CancelFunc
+	wg              sync.WaitGroup
+}
+
+func (d *defaultDownsampler) loop() {
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(d.cfg.CheckInterval)
+	defer ticker.Stop()
+
+	// all sessions are checked after the server start
+	var previous time.Time
+	for {
+		now := time.Now()
+		if err := d.run(d.ctx, previous, now); err != nil {
+			d.logger.Error().Err(err).Msg("Failed to downsample sessions")
+		} else {
+			previous = now
+		}
+
+		select {
+		case <-ticker.C:
+		case <-d.ctx.Done():
+			return
+		}
+	}
+}
+
+// run downsamples finished sessions that got measurements crossing tiers thresholds since the previous run
+func (d *defaultDownsampler) run(ctx context.Context, previous, now time.Time) error {
+	services, err := d.metadataStorage.GetServices(ctx)
+	if err != nil {
+		return errors.Wrap(err, "get services")
+	}
+
+	var sessionDescs []*schema.SessionDescription
+	for _, service := range services {
+		instances, err := d.metadataStorage.GetInstances(ctx, service)
+		if err != nil {
+			return errors.Wrap(err, "get instances")
+		}
+		for _, instance := range instances {
+			sessions, err := d.metadataStorage.GetSessions(ctx, instance)
+			if err != nil {
+				return errors.Wrap(err, "get sessions")
+			}
+			for _, session := range sessions {
+				// live sessions are being written, so they're skipped
+				if session.GetMetadata().GetFinishedAt() == nil {
+					continue
+				}
+				startedAt, err := ptypes.Timestamp(session.GetMetadata().GetStartedAt())
+				if err != nil {
+					return errors.Wrap(err, "convert started_at timestamp to time")
+				}
+				finishedAt, err := ptypes.Timestamp(session.GetMetadata().GetFinishedAt())
+				if err != nil {
+					return errors.Wrap(err, "convert finished_at timestamp to time")
+				}
+				if !crossesTiers(d.cfg.Tiers, startedAt, finishedAt, previous, now) {
+					continue
+				}
+				sessionDescs = append(sessionDescs, session.GetDescription())
+			}
+		}
+	}
+
+	if len(sessionDescs) == 0 {
+		return nil
+	}
+	// sessions are downsampled at once, so storage can reclaim disk space once per run
+	return d.dataStorage.Downsample(ctx, sessionDescs, d.cfg.Tiers, now)
+}
+
+// crossesTiers checks if some session measurements have become older than any tier threshold
+// within (previous, now] time interval; zero previous value means the beginning of time
+func crossesTiers(tiers []config.DownsamplingTier, startedAt, finishedAt, previous, now time.Time) bool {
+	for _, tier := range tiers {
+		upper := now.Add(-1 * tier.After)
+		if startedAt.After(upper) {
+			continue
+		}
+		if previous.IsZero() || previous.Add(-1*tier.After).Before(finishedAt) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *defaultDownsampler) Quit() {
+	d.cancel()
+	d.wg.Wait()
+}
+
+// NewDownsampler runs new downsampler
+func NewDownsampler(
+	logger *zerolog.Logger,
+	cfg *config.DownsamplingConfig,
+	dataStorage data.Storage,
+	metadataStorage metadata.Storage,
+) Downsampler {
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &defaultDownsampler{
+		dataStorage:     dataStorage,
+		metadataStorage: metadataStorage,
+		cfg:             cfg,
+		logger:          logger,
+		ctx:             ctx,
+		cancel:          cancel,
+	}
+	d.wg.Add(1)
+	go d.loop()
+	return d
+}
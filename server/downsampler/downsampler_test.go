@@ -0,0 +1,36 @@
+package downsampler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/memprofiler/memprofiler/server/config"
+)
+
+func TestCrossesTiers(t *testing.T) {
+	var (
+		now   = time.Unix(100000, 0)
+		tiers = []config.DownsamplingTier{
+			{After: time.Hour, Resolution: time.Minute},
+			{After: 24 * time.Hour, Resolution: time.Hour},
+		}
+		previous = now.Add(-1 * time.Hour)
+	)
+
+	// session is too young to be downsampled
+	assert.False(t, crossesTiers(tiers, now.Add(-30*time.Minute), now.Add(-10*time.Minute), time.Time{}, now))
+
+	// the first run checks every session old enough
+	assert.True(t, crossesTiers(tiers, now.Add(-30*time.Hour), now.Add(-29*time.Hour), time.Time{}, now))
+
+	// session measurements crossed the first tier threshold since the previous run
+	assert.True(t, crossesTiers(tiers, now.Add(-3*time.Hour), now.Add(-90*time.Minute), previous, now))
+
+	// session has been fully processed by the previous run
+	assert.False(t, crossesTiers(tiers, now.Add(-10*time.Hour), now.Add(-9*time.Hour), previous, now))
+
+	// session measurements crossed the second tier threshold since the previous run
+	assert.True(t, crossesTiers(tiers, now.Add(-26*time.Hour), now.Add(-24*time.Hour-30*time.Minute), previous, now))
+}
@@ -1 +1,125 @@
 package frontend
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/memprofiler/memprofiler/schema"
+)
+
+func TestLocationsFilter(t *testing.T) {
+	var (
+		short = ptypes.DurationProto(time.Second)
+		long  = ptypes.DurationProto(time.Minute)
+	)
+
+	location := func(id, function string, shortRate, longRate float64) *schema.LocationMetrics {
+		return &schema.LocationMetrics{
+			Callstack: &schema.Callstack{
+				Id:     id,
+				Frames: []*schema.StackFrame{{Name: function, File: id + ".go"}},
+			},
+			Rates: []*schema.MemoryUtilizationRate{
+				{Span: short, Values: &schema.MemoryUtilizationRate_Values{InUseBytes: shortRate, AllocObjects: -shortRate}},
+				{Span: long, Values: &schema.MemoryUtilizationRate_Values{InUseBytes: longRate, AllocObjects: -longRate}},
+			},
+		}
+	}
+
+	msg := &schema.SessionMetrics{
+		Locations: []*schema.LocationMetrics{
+			location("a", "pkg.A", 1, 40),
+			location("b", "pkg.B", math.NaN(), 30),
+			location("c", "other.C", 3, 20),
+			location("d", "other.D", 2, 10),
+		},
+	}
+
+	ids := func(sm *schema.SessionMetrics) []string {
+		var result []string
+		for _, l := range sm.Locations {
+			result = append(result, l.Callstack.Id)
+		}
+		return result
+	}
+
+	testCases := []struct {
+		name     string
+		request  *schema.SubscribeForSessionRequest
+		expected []string
+		total    uint32
+	}{
+		{
+			name:     "default",
+			request:  &schema.SubscribeForSessionRequest{},
+			expected: []string{"c", "d", "a", "b"},
+			total:    4,
+		},
+		{
+			name:     "averaging window",
+			request:  &schema.SubscribeForSessionRequest{AveragingWindow: long},
+			expected: []string{"a", "b", "c", "d"},
+			total:    4,
+		},
+		{
+			name:     "sort by",
+			request:  &schema.SubscribeForSessionRequest{SortBy: schema.MemoryIndicator_ALLOC_OBJECTS},
+			expected: []string{"a", "d", "c", "b"},
+			total:    4,
+		},
+		{
+			name:     "top k",
+			request:  &schema.SubscribeForSessionRequest{Offset: 1, Limit: 2},
+			expected: []string{"d", "a"},
+			total:    4,
+		},
+		{
+			name:     "min rate",
+			request:  &schema.SubscribeForSessionRequest{MinRate: &wrappers.DoubleValue{Value: 2}},
+			expected: []string{"c", "d"},
+			total:    2,
+		},
+		{
+			name:     "substring",
+			request:  &schema.SubscribeForSessionRequest{Filter: "pkg.", Limit: 1},
+			expected: []string{"a"},
+			total:    2,
+		},
+		{
+			name:     "offset out of range",
+			request:  &schema.SubscribeForSessionRequest{Offset: 10},
+			expected: nil,
+			total:    4,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			filter, err := newLocationsFilter(tc.request)
+			if !assert.NoError(t, err) {
+				return
+			}
+			result, err := filter.apply(msg)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tc.expected, ids(result))
+			assert.Equal(t, tc.total, result.TotalLocations)
+		})
+	}
+
+	// source message must stay untouched
+	assert.Equal(t, "a", msg.Locations[0].Callstack.Id)
+
+	// unknown averaging window
+	filter, err := newLocationsFilter(&schema.SubscribeForSessionRequest{AveragingWindow: ptypes.DurationProto(time.Hour)})
+	assert.NoError(t, err)
+	_, err = filter.apply(msg)
+	assert.Error(t, err)
+}
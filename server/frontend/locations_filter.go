@@ -0,0 +1,141 @@
+package frontend
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/duration"
+	"github.com/golang/protobuf/ptypes/wrappers"
+
+	"github.com/memprofiler/memprofiler/schema"
+)
+
+// locationsFilter selects and orders session locations according to the subscription request
+type locationsFilter struct {
+	sortBy          schema.MemoryIndicator
+	averagingWindow *duration.Duration // nil means the shortest averaging window
+	offset          int
+	limit           int
+	minRate         *float64
+	substring       string
+}
+
+// apply builds new SessionMetrics; the source message is shared between subscribers, so it's never modified
+func (f *locationsFilter) apply(msg *schema.SessionMetrics) (*schema.SessionMetrics, error) {
+
+	type item struct {
+		location *schema.LocationMetrics
+		rate     float64
+	}
+
+	items := make([]item, 0, len(msg.GetLocations()))
+	for _, location := range msg.GetLocations() {
+		if !f.matches(location.GetCallstack()) {
+			continue
+		}
+
+		values, err := f.rateValues(location)
+		if err != nil {
+			return nil, err
+		}
+
+		rate := indicatorRate(values, f.sortBy)
+		if f.minRate != nil && !(rate >= *f.minRate) {
+			continue
+		}
+		items = append(items, item{location: location, rate: rate})
+	}
+
+	// sort in descending order; NaN values (when there are not enough data to estimate the rate) go last
+	sort.SliceStable(items, func(i, j int) bool {
+		if math.IsNaN(items[j].rate) {
+			return !math.IsNaN(items[i].rate)
+		}
+		return items[i].rate > items[j].rate
+	})
+
+	// take a requested page
+	first, last := f.offset, len(items)
+	if first > last {
+		first = last
+	}
+	if f.limit > 0 && first+f.limit < last {
+		last = first + f.limit
+	}
+
+	result := &schema.SessionMetrics{
+		Locations:      make([]*schema.LocationMetrics, 0, last-first),
+		TotalLocations: uint32(len(items)),
+	}
+	for _, it := range items[first:last] {
+		result.Locations = append(result.Locations, it.location)
+	}
+	return result, nil
+}
+
+// matches checks if any stack frame contains filter substring in function or file name
+func (f *locationsFilter) matches(cs *schema.Callstack) bool {
+	if f.substring == "" {
+		return true
+	}
+	for _, frame := range cs.GetFrames() {
+		if strings.Contains(frame.GetName(), f.substring) || strings.Contains(frame.GetFile(), f.substring) {
+			return true
+		}
+	}
+	return false
+}
+
+// rateValues returns rates estimated for a requested averaging window
+func (f *locationsFilter) rateValues(location *schema.LocationMetrics) (*schema.MemoryUtilizationRate_Values, error) {
+	rates := location.GetRates()
+	if len(rates) == 0 {
+		return nil, fmt.Errorf("no rates for location %s", location.GetCallstack().GetId())
+	}
+	if f.averagingWindow == nil {
+		return rates[0].GetValues(), nil
+	}
+	for _, rate := range rates {
+		if proto.Equal(rate.GetSpan(), f.averagingWindow) {
+			return rate.GetValues(), nil
+		}
+	}
+	return nil, fmt.Errorf("averaging window %v is not configured on the server", f.averagingWindow)
+}
+
+// indicatorRate extracts rate of a particular memory indicator
+func indicatorRate(values *schema.MemoryUtilizationRate_Values, indicator schema.MemoryIndicator) float64 {
+	return schema.Metrics[indicator].Rate(values)
+}
+
+// locationsFilterRequest is implemented by subscription requests
+type locationsFilterRequest interface {
+	GetSortBy() schema.MemoryIndicator
+	GetAveragingWindow() *duration.Duration
+	GetOffset() uint32
+	GetLimit() uint32
+	GetFilter() string
+	GetMinRate() *wrappers.DoubleValue
+}
+
+func newLocationsFilter(request locationsFilterRequest) (*locationsFilter, error) {
+	if _, exists := schema.MemoryIndicator_name[int32(request.GetSortBy())]; !exists {
+		return nil, fmt.Errorf("unknown memory indicator %d", request.GetSortBy())
+	}
+
+	f := &locationsFilter{
+		sortBy:          request.GetSortBy(),
+		averagingWindow: request.GetAveragingWindow(),
+		offset:          int(request.GetOffset()),
+		limit:           int(request.GetLimit()),
+		substring:       request.GetFilter(),
+	}
+	if request.GetMinRate() != nil {
+		minRate := request.GetMinRate().GetValue()
+		f.minRate = &minRate
+	}
+	return f, nil
+}
@@ -2,14 +2,18 @@ package frontend
 
 import (
 	"context"
+	"fmt"
 	"net"
-	"sort"
+	"time"
 
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/duration"
 	"github.com/rs/zerolog"
 
 	"github.com/memprofiler/memprofiler/schema"
 	"github.com/memprofiler/memprofiler/server/common"
 	"github.com/memprofiler/memprofiler/server/config"
+	"github.com/memprofiler/memprofiler/server/janitor"
 	"github.com/memprofiler/memprofiler/server/locator"
 	"github.com/memprofiler/memprofiler/server/metrics"
 	"github.com/memprofiler/memprofiler/server/storage/data"
@@ -28,6 +32,7 @@ type server struct {
 	computer        metrics.Computer
 	dataStorage     data.Storage
 	metadataStorage metadata.Storage
+	janitor         janitor.Janitor
 	errChan         chan<- error
 	logger          *zerolog.Logger
 }
@@ -64,6 +69,12 @@ func (s *server) GetSessions(
 		// TODO: think about google.golang.org/grpc/status
 		return nil, err
 	}
+	// sessions with unknown bounds are listed anyway
+	for _, session := range sessions {
+		if session.Bounds, err = s.dataStorage.SessionBounds(ctx, session.GetDescription()); err != nil {
+			s.logger.Error().Err(err).Int64("session_id", session.GetDescription().GetId()).Msg("Failed to get session bounds")
+		}
+	}
 	return &schema.GetSessionsResponse{Sessions: sessions}, nil
 }
 
@@ -71,10 +82,31 @@ func (s *server) SubscribeForSession(
 	request *schema.SubscribeForSessionRequest,
 	stream schema.MemprofilerFrontend_SubscribeForSessionServer) error {
 
+	filter, err := newLocationsFilter(request)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Invalid subscription request")
+		return err
+	}
+
+	aggregation := metrics.Aggregation{
+		Mode:         request.GetAggregation(),
+		ModulePrefix: request.GetModulePrefix(),
+	}
+
+	interval, err := recomputeInterval(request)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Invalid subscription request")
+		return err
+	}
+
 	// make subscription for a requested service
-	subscription, err := s.computer.SessionSubscribe(stream.Context(), request.GetSession())
+	subscription, err := s.computer.SessionSubscribe(stream.Context(), request.GetSession(), aggregation, interval)
 	if subscription != nil {
-		defer subscription.Unsubscribe()
+		defer func() {
+			// slow clients receive only the most recent updates
+			s.logger.Debug().Uint64("dropped_updates", subscription.Dropped()).Msg("Unsubscribe from session")
+			subscription.Unsubscribe()
+		}()
 	}
 	if err != nil {
 		s.logger.Error().Err(err).Msg("Failed to subscribe for session")
@@ -90,11 +122,13 @@ func (s *server) SubscribeForSession(
 				s.logger.Warn().Msg("Session terminated by subscription broker")
 				return nil
 			}
-			// sort trend values by InUseBytes rate, since it the most relevant indicator for memory leak
-			sort.Slice(msg.Locations, func(i, j int) bool {
-				// descending order
-				return msg.Locations[i].Rates[0].Values.InUseBytes > msg.Locations[j].Rates[0].Values.InUseBytes
-			})
+			// select and sort locations requested by client
+			// (InUseBytes rate is used by default, since it the most relevant indicator for memory leak)
+			msg, err = filter.apply(msg)
+			if err != nil {
+				s.logger.Error().Err(err).Msg("Failed to filter session metrics")
+				return err
+			}
 			if err := stream.Send(msg); err != nil {
 				s.logger.Error().Err(err).Msg("Failed to send msg to stream")
 				return err
@@ -106,6 +140,232 @@ func (s *server) SubscribeForSession(
 	}
 }
 
+// recomputeInterval returns the interval between subscription updates requested by client;
+// zero value means the server recompute interval
+func recomputeInterval(request interface {
+	GetRecomputeInterval() *duration.Duration
+}) (time.Duration, error) {
+	if request.GetRecomputeInterval() == nil {
+		return 0, nil
+	}
+	return ptypes.Duration(request.GetRecomputeInterval())
+}
+
+func (s *server) SubscribeForService(
+	request *schema.SubscribeForServiceRequest,
+	stream schema.MemprofilerFrontend_SubscribeForServiceServer) error {
+
+	filter, err := newLocationsFilter(request)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Invalid subscription request")
+		return err
+	}
+
+	aggregation := metrics.Aggregation{
+		Mode:         request.GetAggregation(),
+		ModulePrefix: request.GetModulePrefix(),
+	}
+
+	interval, err := recomputeInterval(request)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Invalid subscription request")
+		return err
+	}
+
+	sessions, err := s.liveSessions(stream.Context(), request.GetService())
+	if err != nil {
+		return err
+	}
+	if len(sessions) == 0 {
+		return fmt.Errorf("there are no live sessions of service '%s'", request.GetService())
+	}
+
+	// make subscription for all live sessions of a requested service
+	subscription, err := s.computer.ServiceSubscribe(stream.Context(), sessions, aggregation, interval)
+	if subscription != nil {
+		defer func() {
+			s.logger.Debug().Uint64("dropped_updates", subscription.Dropped()).Msg("Unsubscribe from service")
+			subscription.Unsubscribe()
+		}()
+	}
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to subscribe for service")
+		return err
+	}
+
+	// push service metrics to the client
+	for {
+		select {
+		case msg, ok := <-subscription.Updates():
+			if !ok {
+				s.logger.Warn().Msg("All service sessions terminated by subscription broker")
+				return nil
+			}
+			result := &schema.ServiceMetrics{}
+			if result.Total, err = filter.apply(msg.GetTotal()); err != nil {
+				s.logger.Error().Err(err).Msg("Failed to filter service metrics")
+				return err
+			}
+			if request.GetBreakdown() {
+				for _, instance := range msg.GetInstances() {
+					instanceMetrics, err := filter.apply(instance.GetMetrics())
+					if err != nil {
+						s.logger.Error().Err(err).Msg("Failed to filter instance metrics")
+						return err
+					}
+					result.Instances = append(
+						result.Instances,
+						&schema.InstanceMetrics{Session: instance.GetSession(), Metrics: instanceMetrics},
+					)
+				}
+			}
+			if err := stream.Send(result); err != nil {
+				s.logger.Error().Err(err).Msg("Failed to send msg to stream")
+				return err
+			}
+		case <-stream.Context().Done():
+			s.logger.Warn().Err(stream.Context().Err()).Msg("Context done")
+			return nil
+		}
+	}
+}
+
+func (s *server) GetFlameGraph(
+	ctx context.Context,
+	request *schema.GetFlameGraphRequest,
+) (*schema.GetFlameGraphResponse, error) {
+	var (
+		observedAt time.Time
+		span       time.Duration
+		err        error
+	)
+	if request.GetObservedAt() != nil {
+		if observedAt, err = ptypes.Timestamp(request.GetObservedAt()); err != nil {
+			return nil, err
+		}
+	}
+	if request.GetSpan() != nil {
+		if span, err = ptypes.Duration(request.GetSpan()); err != nil {
+			return nil, err
+		}
+	}
+
+	flameGraph, err := s.computer.SessionFlameGraph(ctx, request.GetSession(), observedAt, span)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to build flame graph")
+		return nil, err
+	}
+	return &schema.GetFlameGraphResponse{FlameGraph: flameGraph}, nil
+}
+
+func (s *server) GetForecast(
+	ctx context.Context,
+	request *schema.GetForecastRequest,
+) (*schema.GetForecastResponse, error) {
+	var (
+		span time.Duration
+		err  error
+	)
+	if request.GetSpan() != nil {
+		if span, err = ptypes.Duration(request.GetSpan()); err != nil {
+			return nil, err
+		}
+	}
+
+	forecast, err := s.computer.SessionForecast(
+		ctx,
+		request.GetSession(),
+		request.GetMemoryLimit(),
+		span,
+		request.GetConfidence(),
+	)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to make forecast")
+		return nil, err
+	}
+	return &schema.GetForecastResponse{Forecast: forecast}, nil
+}
+
+func (s *server) GetAnnotations(
+	ctx context.Context,
+	request *schema.GetAnnotationsRequest,
+) (*schema.GetAnnotationsResponse, error) {
+	annotations, err := s.metadataStorage.GetAnnotations(ctx, request.GetSession())
+	if err != nil {
+		return nil, err
+	}
+	return &schema.GetAnnotationsResponse{Annotations: annotations}, nil
+}
+
+func (s *server) GetAnomalies(
+	ctx context.Context,
+	request *schema.GetAnomaliesRequest,
+) (*schema.GetAnomaliesResponse, error) {
+	var (
+		span time.Duration
+		err  error
+	)
+	if request.GetSpan() != nil {
+		if span, err = ptypes.Duration(request.GetSpan()); err != nil {
+			return nil, err
+		}
+	}
+
+	sessions, err := s.liveSessions(ctx, request.GetService())
+	if err != nil {
+		return nil, err
+	}
+
+	anomalies, err := s.computer.SessionsAnomalies(
+		ctx,
+		sessions,
+		request.GetIndicator(),
+		span,
+		request.GetThreshold(),
+	)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to score anomalies")
+		return nil, err
+	}
+	return &schema.GetAnomaliesResponse{Anomalies: anomalies}, nil
+}
+
+// liveSessions returns the latest unfinished session of every service instance
+func (s *server) liveSessions(ctx context.Context, service string) ([]*schema.SessionDescription, error) {
+	instances, err := s.metadataStorage.GetInstances(ctx, service)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*schema.SessionDescription
+	for _, instance := range instances {
+		sessions, err := s.metadataStorage.GetSessions(ctx, instance)
+		if err != nil {
+			return nil, err
+		}
+		var live *schema.SessionDescription
+		for _, session := range sessions {
+			if session.GetMetadata().GetFinishedAt() == nil && session.GetDescription().GetId() > live.GetId() {
+				live = session.GetDescription()
+			}
+		}
+		if live != nil {
+			result = append(result, live)
+		}
+	}
+	return result, nil
+}
+
+func (s *server) GetJanitorStats(
+	ctx context.Context,
+	request *schema.GetJanitorStatsRequest,
+) (*schema.GetJanitorStatsResponse, error) {
+	if s.janitor == nil {
+		return nil, fmt.Errorf("retention is not configured")
+	}
+	return &schema.GetJanitorStatsResponse{Stats: s.janitor.Stats()}, nil
+}
+
 func (s *server) Start() { s.errChan <- s.grpcServer.Serve(s.listener) }
 
 func (s *server) Stop() { s.grpcServer.GracefulStop() }
@@ -130,6 +390,7 @@ func NewServer(
 		computer:        locator.Computer,
 		dataStorage:     locator.DataStorage,
 		metadataStorage: locator.MetadataStorage,
+		janitor:         locator.Janitor,
 		logger:          &subLogger,
 		errChan:         errChan,
 		listener:        listener,
@@ -0,0 +1,252 @@
+package janitor
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	"github.com/memprofiler/memprofiler/schema"
+	"github.com/memprofiler/memprofiler/server/common"
+	"github.com/memprofiler/memprofiler/server/config"
+	"github.com/memprofiler/memprofiler/server/storage/data"
+	"github.com/memprofiler/memprofiler/server/storage/metadata"
+)
+
+// Janitor deletes the data of expired sessions in a background
+type Janitor interface {
+	// Stats returns statistics of the deleted data
+	Stats() *schema.JanitorStats
+	common.Subsystem
+}
+
+var _ Janitor = (*defaultJanitor)(nil)
+
+type defaultJanitor struct {
+	dataStorage     data.Storage
+	metadataStorage metadata.Storage
+	cfg             *config.RetentionConfig
+	stats           *schema.JanitorStats
+	statsMutex      sync.Mutex
+	logger          *zerolog.Logger
+	ctx             context.Context
+	cancel          context.CancelFunc
+	wg              sync.WaitGroup
+}
+
+func (j *defaultJanitor) Stats() *schema.JanitorStats {
+	j.statsMutex.Lock()
+	defer j.statsMutex.Unlock()
+
+	return proto.Clone(j.stats).(*schema.JanitorStats)
+}
+
+func (j *defaultJanitor) loop() {
+	defer j.wg.Done()
+
+	ticker := time.NewTicker(j.cfg.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		j.run()
+
+		select {
+		case <-ticker.C:
+		case <-j.ctx.Done():
+			return
+		}
+	}
+}
+
+// run performs a single check and updates statistics
+func (j *defaultJanitor) run() {
+	now := time.Now()
+	deleted, reclaimed, err := j.deleteExpired(j.ctx, now)
+
+	j.statsMutex.Lock()
+	defer j.statsMutex.Unlock()
+
+	j.stats.Runs++
+	j.stats.DeletedSessions += uint64(deleted)
+	j.stats.ReclaimedBytes += reclaimed
+	j.stats.LastRun, _ = ptypes.TimestampProto(now)
+	j.stats.LastError = ""
+	if err != nil {
+		j.stats.LastError = err.Error()
+		j.logger.Error().Err(err).Msg("Failed to delete expired sessions")
+		return
+	}
+	if deleted > 0 {
+		j.logger.Info().Int("deleted_sessions", deleted).Int64("reclaimed_bytes", reclaimed).Msg("Expired sessions deleted")
+	}
+}
+
+// deleteExpired removes the data of expired sessions first, and their metadata then,
+// so the data that failed to be deleted will be found again during the next check
+func (j *defaultJanitor) deleteExpired(ctx context.Context, now time.Time) (int, int64, error) {
+	sessions, err := j.collectSessions(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	expired := selectExpired(sessions, j.cfg, now)
+	if len(expired) == 0 {
+		return 0, 0, nil
+	}
+
+	descriptions := make([]*schema.SessionDescription, 0, len(expired))
+	for _, session := range expired {
+		descriptions = append(descriptions, session.description)
+	}
+
+	reclaimed, err := j.dataStorage.DeleteSessions(ctx, descriptions)
+	if err != nil {
+		return 0, reclaimed, errors.Wrap(err, "delete sessions data")
+	}
+
+	for i, description := range descriptions {
+		if err := j.metadataStorage.DeleteSession(ctx, description); err != nil {
+			return i, reclaimed, errors.Wrap(err, "delete session metadata")
+		}
+	}
+	return len(descriptions), reclaimed, nil
+}
+
+// collectSessions lists sessions of all services with their sizes
+func (j *defaultJanitor) collectSessions(ctx context.Context) ([]*sessionInfo, error) {
+	services, err := j.metadataStorage.GetServices(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "get services")
+	}
+
+	var result []*sessionInfo
+	for _, service := range services {
+		instances, err := j.metadataStorage.GetInstances(ctx, service)
+		if err != nil {
+			return nil, errors.Wrap(err, "get instances")
+		}
+		for _, instance := range instances {
+			sessions, err := j.metadataStorage.GetSessions(ctx, instance)
+			if err != nil {
+				return nil, errors.Wrap(err, "get sessions")
+			}
+			for _, session := range sessions {
+				info := &sessionInfo{description: session.GetDescription(), live: true}
+				if finishedAt := session.GetMetadata().GetFinishedAt(); finishedAt != nil {
+					info.live = false
+					if info.finishedAt, err = ptypes.Timestamp(finishedAt); err != nil {
+						return nil, errors.Wrap(err, "convert finished_at timestamp to time")
+					}
+				}
+				if info.size, err = j.dataStorage.SessionSize(info.description); err != nil {
+					return nil, errors.Wrap(err, "get session size")
+				}
+				result = append(result, info)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (j *defaultJanitor) Quit() {
+	j.cancel()
+	j.wg.Wait()
+}
+
+// sessionInfo describes session stored on the server
+type sessionInfo struct {
+	description *schema.SessionDescription
+	live        bool // live sessions are never deleted
+	finishedAt  time.Time
+	size        int64
+}
+
+// selectExpired returns sessions violating retention policies; the age limits are checked first,
+// then the oldest sessions are chosen until the sizes of services and the total size fit the limits
+func selectExpired(sessions []*sessionInfo, cfg *config.RetentionConfig, now time.Time) []*sessionInfo {
+	var (
+		ordered  = make([]*sessionInfo, 0, len(sessions))
+		expired  = make(map[*sessionInfo]bool)
+		services = make(map[string][]*sessionInfo)
+	)
+
+	// finished sessions go first, from the oldest to the newest
+	ordered = append(ordered, sessions...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].live != ordered[j].live {
+			return !ordered[i].live
+		}
+		return ordered[i].finishedAt.Before(ordered[j].finishedAt)
+	})
+
+	for _, session := range ordered {
+		service := session.description.GetInstanceDescription().GetServiceName()
+		services[service] = append(services[service], session)
+
+		maxAge := cfg.ServicePolicy(service).MaxAge
+		if !session.live && maxAge > 0 && now.Sub(session.finishedAt) > maxAge {
+			expired[session] = true
+		}
+	}
+
+	shrink := func(items []*sessionInfo, maxSize int64) {
+		if maxSize <= 0 {
+			return
+		}
+		var size int64
+		for _, session := range items {
+			if !expired[session] {
+				size += session.size
+			}
+		}
+		for _, session := range items {
+			if size <= maxSize || session.live {
+				break
+			}
+			if !expired[session] {
+				expired[session] = true
+				size -= session.size
+			}
+		}
+	}
+
+	for service, items := range services {
+		shrink(items, cfg.ServicePolicy(service).MaxSize)
+	}
+	shrink(ordered, cfg.MaxSize)
+
+	var result []*sessionInfo
+	for _, session := range ordered {
+		if expired[session] {
+			result = append(result, session)
+		}
+	}
+	return result
+}
+
+// NewJanitor runs new janitor
+func NewJanitor(
+	logger *zerolog.Logger,
+	cfg *config.RetentionConfig,
+	dataStorage data.Storage,
+	metadataStorage metadata.Storage,
+) Janitor {
+	ctx, cancel := context.WithCancel(context.Background())
+	j := &defaultJanitor{
+		dataStorage:     dataStorage,
+		metadataStorage: metadataStorage,
+		cfg:             cfg,
+		stats:           &schema.JanitorStats{},
+		logger:          logger,
+		ctx:             ctx,
+		cancel:          cancel,
+	}
+	j.wg.Add(1)
+	go j.loop()
+	return j
+}
@@ -0,0 +1,63 @@
+package janitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/memprofiler/memprofiler/schema"
+	"github.com/memprofiler/memprofiler/server/config"
+)
+
+func TestSelectExpired(t *testing.T) {
+	now := time.Now()
+
+	session := func(id int64, service string, age time.Duration, size int64) *sessionInfo {
+		return &sessionInfo{
+			description: &schema.SessionDescription{
+				Id:                  id,
+				InstanceDescription: &schema.InstanceDescription{ServiceName: service, InstanceName: "instance"},
+			},
+			live:       age == 0,
+			finishedAt: now.Add(-1 * age),
+			size:       size,
+		}
+	}
+
+	sessions := []*sessionInfo{
+		session(1, "a", 3*time.Hour, 10),
+		session(2, "a", 2*time.Hour, 10),
+		session(3, "a", 0, 10),
+		session(4, "b", 5*time.Hour, 100),
+		session(5, "b", 30*time.Minute, 100),
+		session(6, "c", 4*time.Hour, 1000),
+		session(7, "c", 1*time.Hour, 1000),
+		session(8, "c", 0, 1000),
+	}
+
+	ids := func(items []*sessionInfo) []int64 {
+		var result []int64
+		for _, item := range items {
+			result = append(result, item.description.Id)
+		}
+		return result
+	}
+
+	// sessions older than 4 hours are deleted, but "b" sessions live only for an hour;
+	// "a" sessions must fit 15 bytes, the live session is never deleted
+	cfg := &config.RetentionConfig{
+		RetentionPolicy: config.RetentionPolicy{MaxAge: 4*time.Hour + time.Minute},
+		Services: map[string]*config.RetentionPolicy{
+			"a": {MaxSize: 15},
+			"b": {MaxAge: time.Hour},
+		},
+	}
+	assert.NoError(t, cfg.Verify())
+	assert.Equal(t, []int64{4, 1, 2}, ids(selectExpired(sessions, cfg, now)))
+
+	// the oldest sessions are deleted to fit the total size limit
+	cfg = &config.RetentionConfig{RetentionPolicy: config.RetentionPolicy{MaxSize: 2000}}
+	assert.NoError(t, cfg.Verify())
+	assert.Equal(t, []int64{4, 6, 1, 2, 7}, ids(selectExpired(sessions, cfg, now)))
+}
@@ -6,6 +6,8 @@ import (
 	"google.golang.org/grpc/grpclog"
 
 	"github.com/memprofiler/memprofiler/server/config"
+	"github.com/memprofiler/memprofiler/server/downsampler"
+	"github.com/memprofiler/memprofiler/server/janitor"
 	"github.com/memprofiler/memprofiler/server/metrics"
 	"github.com/memprofiler/memprofiler/server/storage/data"
 	"github.com/memprofiler/memprofiler/server/storage/data/filesystem"
@@ -19,6 +21,8 @@ type Locator struct {
 	DataStorage     data.Storage
 	MetadataStorage metadata.Storage
 	Computer        metrics.Computer
+	Janitor         janitor.Janitor         // nil if retention is not configured
+	Downsampler     downsampler.Downsampler // nil if downsampling is not configured
 	Logger          *zerolog.Logger
 }
 
@@ -37,7 +41,7 @@ func NewLocator(logger *zerolog.Logger, cfg *config.Config) (*Locator, error) {
 
 	// 2. run metadata storage
 	l.Logger.Debug().Msg("Starting metadata storage")
-	l.MetadataStorage, err = metadata.NewStorageSQLite(l.Logger, cfg.MetadataStorage)
+	l.MetadataStorage, err = metadata.NewStorage(l.Logger, cfg.MetadataStorage)
 	if err != nil {
 		return nil, errors.Wrap(err, "metadata storage")
 	}
@@ -56,13 +60,36 @@ func NewLocator(logger *zerolog.Logger, cfg *config.Config) (*Locator, error) {
 
 	// 4. run measurement collector
 	l.Logger.Debug().Msg("Starting metrics computer")
-	l.Computer = metrics.NewComputer(l.Logger, l.DataStorage, cfg.Metrics)
+	l.Computer, err = metrics.NewComputer(l.Logger, l.DataStorage, cfg.Metrics)
+	if err != nil {
+		return nil, errors.Wrap(err, "metrics computer")
+	}
+
+	// 5. run janitor
+	if cfg.Retention != nil {
+		l.Logger.Debug().Msg("Starting janitor")
+		l.Janitor = janitor.NewJanitor(l.Logger, cfg.Retention, l.DataStorage, l.MetadataStorage)
+	}
+
+	// 6. run downsampler
+	if cfg.Downsampling != nil {
+		l.Logger.Debug().Msg("Starting downsampler")
+		l.Downsampler = downsampler.NewDownsampler(l.Logger, cfg.Downsampling, l.DataStorage, l.MetadataStorage)
+	}
 
 	return &l, err
 }
 
 // Quit terminates subsystems gracefully
 func (l *Locator) Quit() {
+	if l.Downsampler != nil {
+		l.Logger.Debug().Msg("Stopping downsampler")
+		l.Downsampler.Quit()
+	}
+	if l.Janitor != nil {
+		l.Logger.Debug().Msg("Stopping janitor")
+		l.Janitor.Quit()
+	}
 	l.Logger.Debug().Msg("Stopping storage")
 	l.DataStorage.Quit()
 	l.Logger.Debug().Msg("Stopping metrics computer")
@@ -0,0 +1,162 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/memprofiler/memprofiler/schema"
+	"github.com/memprofiler/memprofiler/utils"
+)
+
+// Aggregation describes how locations are grouped before rate computation
+type Aggregation struct {
+	// Mode defines the grouping key
+	Mode schema.AggregationMode
+	// ModulePrefix is a function name prefix of the user's own code, used by MODULE mode
+	ModulePrefix string
+}
+
+// Verify checks aggregation parameters
+func (a Aggregation) Verify() error {
+	if _, exists := schema.AggregationMode_name[int32(a.Mode)]; !exists {
+		return fmt.Errorf("unknown aggregation mode %d", a.Mode)
+	}
+	if a.Mode == schema.AggregationMode_MODULE && a.ModulePrefix == "" {
+		return fmt.Errorf("module prefix is required for %s aggregation mode", a.Mode)
+	}
+	return nil
+}
+
+// groupCallstack returns synthetic callstack that represents the group the original callstack belongs to
+func (a Aggregation) groupCallstack(cs *schema.Callstack) *schema.Callstack {
+	if len(cs.GetFrames()) == 0 {
+		return cs
+	}
+
+	leaf := cs.Frames[0]
+
+	var frame *schema.StackFrame
+	switch a.Mode {
+	case schema.AggregationMode_FUNCTION:
+		frame = &schema.StackFrame{Name: leaf.GetName(), File: leaf.GetFile()}
+	case schema.AggregationMode_FILE:
+		frame = &schema.StackFrame{File: leaf.GetFile()}
+	case schema.AggregationMode_PACKAGE:
+		frame = &schema.StackFrame{Name: functionPackage(leaf.GetName())}
+	case schema.AggregationMode_MODULE:
+		// if there are no frames from user's code, keep the allocation site
+		frame = leaf
+		for _, sf := range cs.Frames {
+			if strings.HasPrefix(sf.GetName(), a.ModulePrefix) {
+				frame = sf
+				break
+			}
+		}
+	default:
+		return cs
+	}
+
+	return &schema.Callstack{
+		Id:     fmt.Sprintf("%s:%s", a.Mode, utils.DumpStackFrame(frame)),
+		Frames: []*schema.StackFrame{frame},
+	}
+}
+
+// functionPackage extracts package path from the fully qualified function name,
+// like "github.com/org/project/pkg.(*Type).Method" -> "github.com/org/project/pkg"
+func functionPackage(name string) string {
+	lastSlash := strings.LastIndexByte(name, '/')
+	dot := strings.IndexByte(name[lastSlash+1:], '.')
+	if dot < 0 {
+		return name
+	}
+	return name[:lastSlash+1+dot]
+}
+
+// aggregateLocations groups locations according to the aggregation mode
+// and sums up time series within every group
+func aggregateLocations(
+	aggregation Aggregation,
+	locations map[string]*locationData,
+	lifetime time.Duration,
+) map[string]*locationData {
+
+	if aggregation.Mode == schema.AggregationMode_CALLSTACK {
+		return locations
+	}
+
+	var (
+		callStacks = make(map[string]*schema.Callstack)
+		groups     = make(map[string][]*locationData)
+	)
+	for _, ld := range locations {
+		cs := aggregation.groupCallstack(ld.callStack)
+		callStacks[cs.Id] = cs
+		groups[cs.Id] = append(groups[cs.Id], ld)
+	}
+
+	result := make(map[string]*locationData, len(groups))
+	for id, group := range groups {
+		result[id] = sumLocationData(callStacks[id], lifetime, group)
+	}
+	return result
+}
+
+// sumLocationData builds new locationData with series equal to the sum of the given ones;
+// series are aligned by timestamps, missing points are treated as zeroes
+func sumLocationData(callStack *schema.Callstack, lifetime time.Duration, items []*locationData) *locationData {
+	// series are copied, so the result doesn't share any state with the original location
+	if len(items) == 1 {
+		result := newLocationData(callStack, lifetime, items[0].baseline)
+		result.Timestamps = append([]time.Time(nil), items[0].Timestamps...)
+		for j, metric := range schema.Metrics {
+			if !metric.Baseline {
+				result.Series[j] = append([]float64(nil), items[0].Series[j]...)
+			}
+		}
+		result.fillBaselines()
+		return result
+	}
+
+	// build the union of timestamps
+	indices := make(map[int64]int)
+	var timestamps []time.Time
+	for _, item := range items {
+		for _, ts := range item.Timestamps {
+			if _, exists := indices[ts.UnixNano()]; !exists {
+				indices[ts.UnixNano()] = 0
+				timestamps = append(timestamps, ts)
+			}
+		}
+	}
+	sort.Slice(timestamps, func(i, j int) bool { return timestamps[i].Before(timestamps[j]) })
+	for i, ts := range timestamps {
+		indices[ts.UnixNano()] = i
+	}
+
+	result := newLocationData(callStack, lifetime, items[0].baseline)
+	result.Timestamps = timestamps
+	for j, metric := range schema.Metrics {
+		if !metric.Baseline {
+			result.Series[j] = make([]float64, len(timestamps))
+		}
+	}
+
+	for _, item := range items {
+		for i, ts := range item.Timestamps {
+			ix := indices[ts.UnixNano()]
+			for j, metric := range schema.Metrics {
+				if !metric.Baseline {
+					result.Series[j][ix] += item.Series[j][i]
+				}
+			}
+		}
+	}
+
+	// the sum of minimums is not a minimum of sums, so baselines are computed from scratch
+	result.fillBaselines()
+
+	return result
+}
@@ -0,0 +1,164 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+
+	"github.com/memprofiler/memprofiler/schema"
+)
+
+const (
+	// defaultAnomalyThreshold is a modified z-score value above which rate is considered to be an outlier
+	defaultAnomalyThreshold = 3.5
+	// minAnomalyPeers is a minimal number of instances required to tell outliers from peers
+	minAnomalyPeers = 3
+)
+
+// anomalySample contains rates of a single session locations
+type anomalySample struct {
+	session    *schema.SessionDescription
+	rates      map[string]float64 // callstack ID <-> rate
+	callstacks map[string]*schema.Callstack
+}
+
+// total returns the rate of the whole session
+func (s *anomalySample) total() float64 {
+	var result float64
+	for _, rate := range s.rates {
+		result += rate
+	}
+	return result
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// modifiedZScores computes robust z-scores (Iglewicz and Hoaglin) based on the median absolute deviation;
+// if more than a half of values are equal, the mean absolute deviation is used instead
+func modifiedZScores(values []float64) ([]float64, float64) {
+	var (
+		m          = median(values)
+		deviations = make([]float64, len(values))
+		meanAD     float64
+	)
+	for i, value := range values {
+		deviations[i] = math.Abs(value - m)
+		meanAD += deviations[i] / float64(len(values))
+	}
+
+	scale := median(deviations) / 0.6745
+	if scale == 0 {
+		scale = meanAD * 1.253314
+	}
+
+	scores := make([]float64, len(values))
+	if scale == 0 {
+		return scores, m
+	}
+	for i, value := range values {
+		scores[i] = (value - m) / scale
+	}
+	return scores, m
+}
+
+// scoreAnomalies compares rates of the sessions with each other: the whole sessions are compared first,
+// then every location shared by enough sessions; outliers are sorted by the absolute score in descending order
+func scoreAnomalies(samples []*anomalySample, threshold float64) []*schema.Anomaly {
+	var result []*schema.Anomaly
+
+	collect := func(group []*anomalySample, values []float64, callstack func(*anomalySample) *schema.Callstack) {
+		if len(group) < minAnomalyPeers {
+			return
+		}
+		scores, m := modifiedZScores(values)
+		for i, score := range scores {
+			if math.Abs(score) > threshold {
+				result = append(result, &schema.Anomaly{
+					Session:     group[i].session,
+					Callstack:   callstack(group[i]),
+					Rate:        values[i],
+					PeersMedian: m,
+					Score:       score,
+					Peers:       uint32(len(group)),
+				})
+			}
+		}
+	}
+
+	totals := make([]float64, len(samples))
+	for i, sample := range samples {
+		totals[i] = sample.total()
+	}
+	collect(samples, totals, func(*anomalySample) *schema.Callstack { return nil })
+
+	ids := make(map[string]struct{})
+	for _, sample := range samples {
+		for id := range sample.rates {
+			ids[id] = struct{}{}
+		}
+	}
+	for id := range ids {
+		var (
+			group  []*anomalySample
+			values []float64
+		)
+		for _, sample := range samples {
+			if rate, exists := sample.rates[id]; exists {
+				group = append(group, sample)
+				values = append(values, rate)
+			}
+		}
+		id := id
+		collect(group, values, func(s *anomalySample) *schema.Callstack { return s.callstacks[id] })
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if a, b := math.Abs(result[i].Score), math.Abs(result[j].Score); a != b {
+			return a > b
+		}
+		return result[i].GetCallstack().GetId() < result[j].GetCallstack().GetId()
+	})
+	return result
+}
+
+// anomalySample extracts rates of a given memory indicator estimated for a given time span
+func (sd *sessionData) anomalySample(
+	session *schema.SessionDescription,
+	indicator schema.MemoryIndicator,
+	span time.Duration,
+) (*anomalySample, error) {
+
+	sample := &anomalySample{
+		session:    session,
+		rates:      make(map[string]float64),
+		callstacks: make(map[string]*schema.Callstack),
+	}
+
+	for _, location := range sd.getSessionMetrics(Aggregation{}).GetLocations() {
+		for _, rate := range location.GetRates() {
+			rateSpan, err := ptypes.Duration(rate.GetSpan())
+			if err != nil {
+				return nil, err
+			}
+			if rateSpan != span {
+				continue
+			}
+			if value := schema.Metrics[indicator].Rate(rate.GetValues()); !math.IsNaN(value) {
+				id := location.GetCallstack().GetId()
+				sample.rates[id] = value
+				sample.callstacks[id] = location.GetCallstack()
+			}
+		}
+	}
+	return sample, nil
+}
@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/memprofiler/memprofiler/schema"
+)
+
+func TestScoreAnomalies(t *testing.T) {
+	var (
+		cs1     = &schema.Callstack{Id: "1"}
+		cs2     = &schema.Callstack{Id: "2"}
+		samples []*anomalySample
+	)
+
+	// the last instance leaks in the first location, the second location is shared by two instances only
+	for i, rate := range []float64{10, 11, 9, 10, 100} {
+		sample := &anomalySample{
+			session:    &schema.SessionDescription{Id: int64(i)},
+			rates:      map[string]float64{cs1.Id: rate},
+			callstacks: map[string]*schema.Callstack{cs1.Id: cs1},
+		}
+		if i < 2 {
+			sample.rates[cs2.Id] = 0.5
+			sample.callstacks[cs2.Id] = cs2
+		}
+		samples = append(samples, sample)
+	}
+
+	anomalies := scoreAnomalies(samples, defaultAnomalyThreshold)
+	if !assert.Len(t, anomalies, 2) {
+		t.FailNow()
+	}
+	assert.Equal(t, int64(4), anomalies[0].Session.Id)
+	assert.Equal(t, cs1, anomalies[0].Callstack)
+	assert.Equal(t, float64(100), anomalies[0].Rate)
+	assert.Equal(t, float64(10), anomalies[0].PeersMedian)
+	assert.Equal(t, uint32(5), anomalies[0].Peers)
+	assert.True(t, anomalies[0].Score > defaultAnomalyThreshold)
+
+	// the whole instance is an outlier too
+	assert.Equal(t, int64(4), anomalies[1].Session.Id)
+	assert.Nil(t, anomalies[1].Callstack)
+	assert.Equal(t, 10.5, anomalies[1].PeersMedian)
+
+	// when most of rates are equal, outliers are still detected
+	scores, m := modifiedZScores([]float64{5, 5, 5, 5, 50})
+	assert.Equal(t, float64(5), m)
+	assert.Equal(t, float64(0), scores[0])
+	assert.True(t, scores[4] > defaultAnomalyThreshold)
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"time"
+)
+
+// rollingMinimum maintains the minimum of time series values over a sliding period,
+// which is a lower envelope of the sawtooth formed by heap usage between GC cycles;
+// monotonic queue is used, so every point is processed in O(1) amortized time
+type rollingMinimum struct {
+	period  time.Duration
+	indices []int // indices of points that may become the minimum, their values are increasing
+}
+
+// push registers the latest point of the series and returns the minimum over the period ending at this point
+func (m *rollingMinimum) push(values []float64, timestamps []time.Time) float64 {
+	last := len(values) - 1
+
+	for len(m.indices) > 0 && values[m.indices[len(m.indices)-1]] >= values[last] {
+		m.indices = m.indices[:len(m.indices)-1]
+	}
+	m.indices = append(m.indices, last)
+
+	// the latest point always stays within the period
+	threshold := timestamps[last].Add(-1 * m.period)
+	for timestamps[m.indices[0]].Before(threshold) {
+		m.indices = m.indices[1:]
+	}
+
+	return values[m.indices[0]]
+}
+
+// shift drops the points with indices less than edge; it must be called
+// when location time series are shifted by edge elements
+func (m *rollingMinimum) shift(edge int) {
+	i := 0
+	for i < len(m.indices) && m.indices[i] < edge {
+		i++
+	}
+	m.indices = m.indices[i:]
+	for i := range m.indices {
+		m.indices[i] -= edge
+	}
+}
+
+func (m *rollingMinimum) reset() { m.indices = nil }
+
+func newRollingMinimum(period time.Duration) *rollingMinimum {
+	return &rollingMinimum{period: period}
+}
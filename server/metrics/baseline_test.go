@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/memprofiler/memprofiler/schema"
+)
+
+// Memory leaks with a rate of 10 bytes per second, while the garbage is accumulated
+// with a rate of 1000 bytes per second and freed every 10 seconds;
+// the baseline growth must be close to the leak rate, unlike the raw in-use bytes growth
+func TestLocationData_Baseline(t *testing.T) {
+	var (
+		ld  = newLocationData(&schema.Callstack{}, time.Minute, 15*time.Second)
+		now = time.Now()
+	)
+	for i := 0; i < 100; i++ {
+		allocBytes := 10*i + 1000*(i%10)
+		ld.registerMeasurement(now.Add(time.Duration(i-99)*time.Second), &schema.MemoryUsage{AllocBytes: int64(allocBytes)})
+	}
+
+	// baseline is a rolling minimum
+	baseline := ld.Series[schema.MemoryIndicator_IN_USE_BYTES_BASELINE]
+	assert.Equal(t, len(ld.Timestamps), len(baseline))
+	assert.Equal(t, float64(900), baseline[len(baseline)-1])
+
+	rates := (&olsEstimator{}).estimate(ld, 25*time.Second, now)
+	assert.InDelta(t, 10, rates[schema.MemoryIndicator_IN_USE_BYTES_BASELINE].slope, 2)
+	assert.True(t, math.Abs(rates[schema.MemoryIndicator_IN_USE_BYTES].slope-10) > 10)
+}
@@ -0,0 +1,203 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/golang/protobuf/ptypes"
+
+	"github.com/memprofiler/memprofiler/schema"
+)
+
+const (
+	// minSegmentLength is a minimal number of points between two change points
+	minSegmentLength = 3
+	// changePointPenalty scales the penalty for adding new change point, in units of noise variance;
+	// the larger value is, the less change points are detected
+	changePointPenalty = 4
+)
+
+// linearSegments computes the cost of fitting any part of time series with a straight line in O(1)
+type linearSegments struct {
+	sumX, sumXX, sumY, sumYY, sumXY []float64 // prefix sums
+}
+
+// cost returns the residual sum of squares of the linear fit for the points [from, to)
+func (s *linearSegments) cost(from, to int) float64 {
+	var (
+		n    = float64(to - from)
+		sumX = s.sumX[to] - s.sumX[from]
+		sumY = s.sumY[to] - s.sumY[from]
+		sxx  = s.sumXX[to] - s.sumXX[from] - sumX*sumX/n
+		sxy  = s.sumXY[to] - s.sumXY[from] - sumX*sumY/n
+		syy  = s.sumYY[to] - s.sumYY[from] - sumY*sumY/n
+		sse  = syy
+	)
+	if sxx > 0 {
+		sse -= sxy * sxy / sxx
+	}
+	return math.Max(0, sse)
+}
+
+// slope returns the slope of the linear fit for the points [from, to)
+func (s *linearSegments) slope(from, to int) float64 {
+	var (
+		n    = float64(to - from)
+		sumX = s.sumX[to] - s.sumX[from]
+		sumY = s.sumY[to] - s.sumY[from]
+		sxx  = s.sumXX[to] - s.sumXX[from] - sumX*sumX/n
+		sxy  = s.sumXY[to] - s.sumXY[from] - sumX*sumY/n
+	)
+	return sxy / sxx
+}
+
+func newLinearSegments(x, y []float64) *linearSegments {
+	s := &linearSegments{
+		sumX:  make([]float64, len(x)+1),
+		sumXX: make([]float64, len(x)+1),
+		sumY:  make([]float64, len(x)+1),
+		sumYY: make([]float64, len(x)+1),
+		sumXY: make([]float64, len(x)+1),
+	}
+	// values are shifted to keep precision
+	for i := range x {
+		dx, dy := x[i]-x[0], y[i]-y[0]
+		s.sumX[i+1] = s.sumX[i] + dx
+		s.sumXX[i+1] = s.sumXX[i] + dx*dx
+		s.sumY[i+1] = s.sumY[i] + dy
+		s.sumYY[i+1] = s.sumYY[i] + dy*dy
+		s.sumXY[i+1] = s.sumXY[i] + dx*dy
+	}
+	return s
+}
+
+// noiseVariance robustly estimates the variance of the noise around piecewise linear trend
+// using the median absolute deviation of the second differences
+func noiseVariance(y []float64) float64 {
+	if len(y) < 3 {
+		return 0
+	}
+	diffs := make([]float64, 0, len(y)-2)
+	for i := 2; i < len(y); i++ {
+		diffs = append(diffs, math.Abs(y[i]-2*y[i-1]+y[i-2]))
+	}
+	sort.Float64s(diffs)
+	sigma := 1.4826 * diffs[len(diffs)/2] / math.Sqrt(6)
+	return sigma * sigma
+}
+
+// detectChangePoints finds the indices of points where the growth regime of time series changes;
+// series is split into linear segments with PELT (Pruned Exact Linear Time) algorithm
+func detectChangePoints(x, y []float64) []int {
+	n := len(x)
+	if n < 2*minSegmentLength {
+		return nil
+	}
+
+	var (
+		segments = newLinearSegments(x, y)
+		total    = segments.cost(0, n)
+		// noise variance may be zero for the perfectly piecewise linear series
+		penalty    = changePointPenalty * math.Max(noiseVariance(y), 1e-9*(1+total/float64(n))) * math.Log(float64(n))
+		optimal    = make([]float64, n+1) // optimal cost of the first i points
+		last       = make([]int, n+1)     // the last change point of the optimal segmentation of the first i points
+		candidates = []int{0}
+	)
+
+	optimal[0] = -penalty
+	for i := 1; i <= n; i++ {
+		optimal[i] = math.Inf(1)
+		for _, c := range candidates {
+			if i-c < minSegmentLength {
+				continue
+			}
+			if value := optimal[c] + segments.cost(c, i) + penalty; value < optimal[i] {
+				optimal[i], last[i] = value, c
+			}
+		}
+
+		// prune the candidates that can never be optimal
+		pruned := candidates[:0]
+		for _, c := range candidates {
+			if i-c < minSegmentLength || optimal[c]+segments.cost(c, i) <= optimal[i] {
+				pruned = append(pruned, c)
+			}
+		}
+		candidates = append(pruned, i)
+	}
+
+	var result []int
+	for i := last[n]; i > 0; i = last[i] {
+		result = append(result, i)
+	}
+	sort.Ints(result)
+	return result
+}
+
+// changePoints converts change points of in-use bytes series into session annotations
+func (ld *locationData) changePoints(callStackID string) ([]*schema.Annotation, error) {
+	var (
+		x        = timestampsToFloats(ld.Timestamps)
+		y        = ld.Series[schema.MemoryIndicator_IN_USE_BYTES]
+		segments = newLinearSegments(x, y)
+		indices  = detectChangePoints(x, y)
+		result   = make([]*schema.Annotation, 0, len(indices))
+	)
+
+	for i, ix := range indices {
+		from, to := 0, len(x)
+		if i > 0 {
+			from = indices[i-1]
+		}
+		if i < len(indices)-1 {
+			to = indices[i+1]
+		}
+
+		observedAt, err := ptypes.TimestampProto(ld.Timestamps[ix])
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, &schema.Annotation{
+			ObservedAt:  observedAt,
+			Kind:        schema.AnnotationKind_CHANGE_POINT,
+			CallstackId: callStackID,
+			Description: fmt.Sprintf(
+				"in-use bytes growth rate changed from %.2f to %.2f bytes/s",
+				segments.slope(from, ix), segments.slope(ix, to),
+			),
+		})
+	}
+	return result, nil
+}
+
+// changePoints detects change points for the total in-use bytes of the session and for every location
+func (sd *sessionData) changePoints() ([]*schema.Annotation, error) {
+	sd.mutex.RLock()
+	defer sd.mutex.RUnlock()
+
+	if len(sd.locations) == 0 {
+		return nil, nil
+	}
+
+	items := make([]*locationData, 0, len(sd.locations))
+	for _, ld := range sd.locations {
+		items = append(items, ld)
+	}
+
+	// the total in-use bytes change points have an empty callstack
+	result, err := sumLocationData(&schema.Callstack{}, sd.lifetime, items).changePoints("")
+	if err != nil {
+		return nil, err
+	}
+
+	for id, ld := range sd.locations {
+		annotations, err := ld.changePoints(id)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, annotations...)
+	}
+	return result, nil
+}
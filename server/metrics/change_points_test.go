@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectChangePoints(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	series := func(slopes []float64, length int, noise float64) ([]float64, []float64) {
+		var (
+			x     []float64
+			y     []float64
+			value float64
+		)
+		for _, slope := range slopes {
+			for i := 0; i < length; i++ {
+				value += slope
+				x = append(x, float64(len(x)))
+				y = append(y, value+noise*r.NormFloat64())
+			}
+		}
+		return x, y
+	}
+
+	t.Run("perfect", func(t *testing.T) {
+		// the change point is the vertex shared by two adjacent segments
+		x, y := series([]float64{10, 10, 50, 0}, 30, 0)
+		assert.Equal(t, []int{59, 89}, detectChangePoints(x, y))
+	})
+
+	t.Run("noisy", func(t *testing.T) {
+		x, y := series([]float64{10, 100}, 60, 20)
+		indices := detectChangePoints(x, y)
+		if assert.Len(t, indices, 1) {
+			assert.True(t, math.Abs(float64(indices[0]-60)) <= 2)
+		}
+	})
+
+	t.Run("single regime", func(t *testing.T) {
+		x, y := series([]float64{10}, 200, 20)
+		assert.Empty(t, detectChangePoints(x, y))
+	})
+
+	t.Run("short", func(t *testing.T) {
+		x, y := series([]float64{10, 100}, 2, 0)
+		assert.Empty(t, detectChangePoints(x, y))
+	})
+}
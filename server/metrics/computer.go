@@ -2,7 +2,9 @@ package metrics
 
 import (
 	"context"
+	"fmt"
 	"sync"
+	"time"
 
 	"github.com/rs/zerolog"
 
@@ -19,6 +21,15 @@ type defaultComputer struct {
 	// FIXME: it's necessary to implement session cleanup, otherwise memory will leak
 	sessions map[string]*sessionData
 
+	// throttles limit the frequency of session metrics recomputation (sessionID <-> throttle)
+	throttles map[string]*throttle
+
+	// pool is shared by all sessions to perform CPU-bound computations
+	pool *workerPool
+
+	// estimator is used to compute rates
+	estimator estimator
+
 	// dispatcher owns subscriptions
 	dispatcher dispatcher
 
@@ -39,9 +50,14 @@ func (r *defaultComputer) PutMeasurement(sd *schema.SessionDescription, mm *sche
 	sessionID := shortSessionIdentifier(sd)
 	data, exists := r.sessions[sessionID]
 	if !exists {
-		data = newSessionData(r.logger, r.cfg.AveragingWindows)
+		data = newSessionData(r.logger, r.cfg.AveragingWindows, r.pool, r.estimator, r.cfg.BaselinePeriod)
 		r.sessions[sessionID] = data
 	}
+	t, exists := r.throttles[sessionID]
+	if !exists {
+		t = newThrottle(r.cfg.RecomputeInterval)
+		r.throttles[sessionID] = t
+	}
 	r.mutex.Unlock()
 
 	// push measurement to time series
@@ -49,8 +65,17 @@ func (r *defaultComputer) PutMeasurement(sd *schema.SessionDescription, mm *sche
 		return err
 	}
 
-	// notify subscribers
-	r.dispatcher.broadcast(sd, data.getSessionMetrics())
+	// Notify subscribers, but not more often than configured. Session metrics are computed lazily,
+	// when subscribers are ready to receive them, so the session without subscribers is never recomputed.
+	if r.dispatcher.hasSubscribers(sd) {
+		t.run(func() {
+			select {
+			case <-r.ctx.Done():
+			default:
+				r.dispatcher.broadcast(sd, data)
+			}
+		})
+	}
 	return nil
 }
 
@@ -60,37 +85,202 @@ func (r *defaultComputer) SessionRecentMetrics(
 	sd *schema.SessionDescription,
 ) (*schema.SessionMetrics, error) {
 
-	sessionID := shortSessionIdentifier(sd)
+	data, err := r.getSessionData(ctx, sd)
+	if err != nil {
+		return nil, err
+	}
 
-	// get or create session data
-	r.mutex.Lock()
-	data, exists := r.sessions[sessionID]
-	if !exists {
-		data = newSessionData(r.logger, r.cfg.AveragingWindows)
-		r.sessions[sessionID] = data
+	return data.getSessionMetrics(Aggregation{}), nil
+}
+
+func (r *defaultComputer) SessionSubscribe(
+	ctx context.Context,
+	sd *schema.SessionDescription,
+	aggregation Aggregation,
+	interval time.Duration,
+) (Subscription, error) {
+
+	if err := aggregation.Verify(); err != nil {
+		return nil, err
+	}
+	if interval < 0 {
+		return nil, fmt.Errorf("invalid recompute interval %v", interval)
 	}
-	r.mutex.Unlock()
 
-	// if metrics of old session has been requested, that doesn't exist in cache yet,
-	// load data from storage and compute metrics from scratch
-	if !exists {
-		if err := r.populateSessionData(ctx, sd, data); err != nil {
+	// session metrics are never recomputed more often than configured
+	if interval < r.cfg.RecomputeInterval {
+		interval = r.cfg.RecomputeInterval
+	}
+
+	data, err := r.getSessionData(ctx, sd)
+	if err != nil {
+		return nil, err
+	}
+
+	subscription := r.dispatcher.createSubscription(ctx, sd, aggregation, interval)
+	r.dispatcher.broadcast(sd, data)
+	return subscription, nil
+}
+
+func (r *defaultComputer) ServiceSubscribe(
+	ctx context.Context,
+	sds []*schema.SessionDescription,
+	aggregation Aggregation,
+	interval time.Duration,
+) (ServiceSubscription, error) {
+
+	if len(sds) == 0 {
+		return nil, fmt.Errorf("no sessions to subscribe for")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	subscriptions := make([]Subscription, 0, len(sds))
+	for _, sd := range sds {
+		subscription, err := r.SessionSubscribe(ctx, sd, aggregation, interval)
+		if err != nil {
+			cancel()
+			for _, subscription := range subscriptions {
+				subscription.Unsubscribe()
+			}
 			return nil, err
 		}
+		subscriptions = append(subscriptions, subscription)
+	}
+	return newServiceSubscription(ctx, sds, subscriptions, cancel), nil
+}
+
+func (r *defaultComputer) SessionFlameGraph(
+	ctx context.Context,
+	sd *schema.SessionDescription,
+	observedAt time.Time,
+	span time.Duration,
+) (*schema.FlameGraph, error) {
+
+	if span == 0 {
+		span = r.cfg.AveragingWindows[0]
+	}
+
+	data, err := r.getSessionData(ctx, sd)
+	if err != nil {
+		return nil, err
+	}
+
+	if observedAt.IsZero() || data.covers(observedAt, span) {
+		return data.flameGraph(observedAt, span)
+	}
+
+	// requested moment is out of the data kept in memory, so load historical data
+	// into a temporary container with the retention period that covers required time span
+	historicalData := newSessionData(r.logger, []time.Duration{time.Since(observedAt) + span}, r.pool, r.estimator, r.cfg.BaselinePeriod)
+	if err := r.populateSessionData(ctx, sd, historicalData); err != nil {
+		return nil, err
+	}
+	return historicalData.flameGraph(observedAt, span)
+}
+
+func (r *defaultComputer) SessionForecast(
+	ctx context.Context,
+	sd *schema.SessionDescription,
+	memoryLimit uint64,
+	span time.Duration,
+	confidence float64,
+) (*schema.Forecast, error) {
+
+	if memoryLimit == 0 {
+		serviceName := sd.GetInstanceDescription().GetServiceName()
+		memoryLimit = r.cfg.MemoryLimits[serviceName]
+		if memoryLimit == 0 {
+			return nil, fmt.Errorf("memory limit is not configured for service '%s'", serviceName)
+		}
+	}
+
+	if span == 0 {
+		span = r.cfg.AveragingWindows[len(r.cfg.AveragingWindows)-1]
+	}
+
+	if confidence == 0 {
+		confidence = defaultConfidence
+	}
+	if confidence <= 0 || confidence >= 1 {
+		return nil, fmt.Errorf("invalid confidence level %v", confidence)
+	}
+
+	data, err := r.getSessionData(ctx, sd)
+	if err != nil {
+		return nil, err
+	}
+
+	return data.forecast(memoryLimit, span, confidence, time.Now())
+}
+
+func (r *defaultComputer) SessionChangePoints(
+	ctx context.Context,
+	sd *schema.SessionDescription,
+) ([]*schema.Annotation, error) {
+
+	data, err := r.getSessionData(ctx, sd)
+	if err != nil {
+		return nil, err
+	}
+	return data.changePoints()
+}
+
+func (r *defaultComputer) SessionsAnomalies(
+	ctx context.Context,
+	sds []*schema.SessionDescription,
+	indicator schema.MemoryIndicator,
+	span time.Duration,
+	threshold float64,
+) ([]*schema.Anomaly, error) {
+
+	if _, exists := schema.MemoryIndicator_name[int32(indicator)]; !exists {
+		return nil, fmt.Errorf("unknown memory indicator %d", indicator)
+	}
+
+	if span == 0 {
+		span = r.cfg.AveragingWindows[len(r.cfg.AveragingWindows)-1]
+	}
+	configured := false
+	for _, window := range r.cfg.AveragingWindows {
+		configured = configured || window == span
+	}
+	if !configured {
+		return nil, fmt.Errorf("averaging window %v is not configured on the server", span)
+	}
+
+	if threshold == 0 {
+		threshold = defaultAnomalyThreshold
+	}
+	if threshold < 0 {
+		return nil, fmt.Errorf("invalid threshold %v", threshold)
 	}
 
-	return data.getSessionMetrics(), nil
+	samples := make([]*anomalySample, 0, len(sds))
+	for _, sd := range sds {
+		data, err := r.getSessionData(ctx, sd)
+		if err != nil {
+			return nil, err
+		}
+		sample, err := data.anomalySample(sd, indicator, span)
+		if err != nil {
+			return nil, err
+		}
+		samples = append(samples, sample)
+	}
+	return scoreAnomalies(samples, threshold), nil
 }
 
-func (r *defaultComputer) SessionSubscribe(ctx context.Context, sd *schema.SessionDescription) (Subscription, error) {
+// getSessionData returns the most recent data of a particular session;
+// if the session is not in cache yet, the data is loaded from storage
+func (r *defaultComputer) getSessionData(ctx context.Context, sd *schema.SessionDescription) (*sessionData, error) {
 
 	sessionID := shortSessionIdentifier(sd)
 
-	// check session data, create if not exists
+	// get or create session data
 	r.mutex.Lock()
 	data, exists := r.sessions[sessionID]
 	if !exists {
-		data = newSessionData(r.logger, r.cfg.AveragingWindows)
+		data = newSessionData(r.logger, r.cfg.AveragingWindows, r.pool, r.estimator, r.cfg.BaselinePeriod)
 		r.sessions[sessionID] = data
 	}
 	r.mutex.Unlock()
@@ -103,9 +293,7 @@ func (r *defaultComputer) SessionSubscribe(ctx context.Context, sd *schema.Sessi
 		}
 	}
 
-	subscription := r.dispatcher.createSubscription(ctx, sd)
-	r.dispatcher.broadcast(sd, data.getSessionMetrics())
-	return subscription, nil
+	return data, nil
 }
 
 // populateSessionData takes data from persistent storage
@@ -140,14 +328,22 @@ func (r *defaultComputer) populateSessionData(
 func (r *defaultComputer) Quit() {
 	r.cancel()
 	r.wg.Wait()
+	r.pool.wait()
 }
 
 // NewComputer instantiates new runner
-func NewComputer(logger *zerolog.Logger, storage data.Storage, cfg *config.MetricsConfig) Computer {
+func NewComputer(logger *zerolog.Logger, storage data.Storage, cfg *config.MetricsConfig) (Computer, error) {
+	estimator, err := newEstimator(cfg.TrendEstimator())
+	if err != nil {
+		return nil, err
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	return &defaultComputer{
 		logger:     logger,
 		sessions:   make(map[string]*sessionData),
+		throttles:  make(map[string]*throttle),
+		pool:       newWorkerPool(ctx, cfg.Workers),
 		dispatcher: newDispatcher(),
 		storage:    storage,
 		mutex:      sync.RWMutex{},
@@ -155,5 +351,6 @@ func NewComputer(logger *zerolog.Logger, storage data.Storage, cfg *config.Metri
 		cfg:        cfg,
 		ctx:        ctx,
 		cancel:     cancel,
-	}
+		estimator:  estimator,
+	}, nil
 }
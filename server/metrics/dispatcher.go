@@ -3,6 +3,7 @@ package metrics
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/memprofiler/memprofiler/schema"
 )
@@ -20,7 +21,9 @@ type defaultDispatcher struct {
 
 func (d *defaultDispatcher) createSubscription(
 	ctx context.Context,
-	sd *schema.SessionDescription) Subscription {
+	sd *schema.SessionDescription,
+	aggregation Aggregation,
+	interval time.Duration) Subscription {
 
 	d.mutex.Lock()
 	defer d.mutex.Unlock()
@@ -34,7 +37,7 @@ func (d *defaultDispatcher) createSubscription(
 
 	// create and store new subscription
 	d.counter++
-	subscription := newSubscription(ctx, d.counter, sd, d)
+	subscription := newSubscription(ctx, d.counter, sd, aggregation, interval, d)
 	ss[d.counter] = subscription
 	return subscription
 }
@@ -58,7 +61,14 @@ func (d *defaultDispatcher) dropSubscription(
 	}
 }
 
-func (d *defaultDispatcher) broadcast(sd *schema.SessionDescription, msg *schema.SessionMetrics) {
+func (d *defaultDispatcher) hasSubscribers(sd *schema.SessionDescription) bool {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	return len(d.subscriptions[shortSessionIdentifier(sd)]) > 0
+}
+
+func (d *defaultDispatcher) broadcast(sd *schema.SessionDescription, data *sessionData) {
 	d.mutex.RLock()
 	defer d.mutex.RUnlock()
 
@@ -68,9 +78,9 @@ func (d *defaultDispatcher) broadcast(sd *schema.SessionDescription, msg *schema
 		return
 	}
 
-	// publish message to every subscriber
+	// publish metrics to every subscriber
 	for _, s := range ss {
-		s.publish(msg)
+		s.publish(data)
 	}
 }
 
@@ -0,0 +1,192 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/memprofiler/memprofiler/schema"
+)
+
+// estimator estimates trends (the first time derivative) of location time series
+type estimator interface {
+	// kind identifies estimator within API
+	kind() schema.TrendEstimator
+	// estimate computes rates [units per second] of every location time series
+	// within the averaging window counted back from the given moment of time;
+	// NaN means that there are not enough data
+	estimate(ld *locationData, span time.Duration, now time.Time) []rateEstimate
+}
+
+// rateEstimate contains the rate of a time series along with the goodness-of-fit values
+type rateEstimate struct {
+	slope         float64
+	rSquared      float64
+	standardError float64
+	samples       int
+}
+
+// toSchema converts goodness-of-fit values into API representation
+func (e rateEstimate) toSchema() *schema.FitQuality {
+	return &schema.FitQuality{RSquared: e.rSquared, StandardError: e.standardError, Samples: uint32(e.samples)}
+}
+
+// newRateEstimate evaluates how well the line with a given slope fits n points having
+// centered sums sxx = Σ(x-x̄)², sxy = Σ(x-x̄)(y-ȳ) and syy = Σ(y-ȳ)²; the line is assumed
+// to pass through the mean point, which minimizes residuals for any slope,
+// so for OLS slope the values are the same as for the classic regression
+func newRateEstimate(slope, n, sxx, sxy, syy float64) rateEstimate {
+	result := rateEstimate{
+		slope:         slope,
+		rSquared:      math.NaN(),
+		standardError: math.NaN(),
+		samples:       int(n),
+	}
+	if math.IsNaN(slope) || math.IsInf(slope, 0) || sxx <= 0 {
+		return result
+	}
+
+	// residual sum of squares; rounding errors may make it slightly negative
+	sse := math.Max(0, syy-2*slope*sxy+slope*slope*sxx)
+
+	// constant series is perfectly described by any line with zero slope
+	if syy > 0 {
+		result.rSquared = 1 - sse/syy
+	} else if slope == 0 {
+		result.rSquared = 1
+	}
+
+	if n > 2 {
+		result.standardError = math.Sqrt(sse / (n - 2) / sxx)
+	}
+	return result
+}
+
+// centeredSums computes sums of squared deviations and products of deviations from the means
+func centeredSums(x, y []float64) (sxx, sxy, syy float64) {
+	var meanX, meanY float64
+	for i := range x {
+		meanX += x[i]
+		meanY += y[i]
+	}
+	meanX /= float64(len(x))
+	meanY /= float64(len(y))
+
+	for i := range x {
+		dx, dy := x[i]-meanX, y[i]-meanY
+		sxx += dx * dx
+		sxy += dx * dy
+		syy += dy * dy
+	}
+	return sxx, sxy, syy
+}
+
+var _ estimator = (*olsEstimator)(nil)
+
+// olsEstimator computes the slope of ordinary least squares linear regression;
+// it's cheap, since regression state is maintained incrementally, but it's sensitive
+// to outliers, like sawtooth patterns caused by GC
+type olsEstimator struct{}
+
+func (e *olsEstimator) kind() schema.TrendEstimator { return schema.TrendEstimator_OLS }
+
+func (e *olsEstimator) estimate(ld *locationData, span time.Duration, now time.Time) []rateEstimate {
+	w, exists := ld.windows[span]
+	if !exists {
+		w = newRegressionWindow(span)
+		ld.windows[span] = w
+	}
+	w.update(ld, now.Add(-1*span))
+	return w.estimates()
+}
+
+var _ estimator = (*seriesEstimator)(nil)
+
+// seriesEstimator applies a stateless slope function to the points within averaging window
+type seriesEstimator struct {
+	estimatorKind schema.TrendEstimator
+	slope         func(timestamps, values []float64) float64
+}
+
+func (e *seriesEstimator) kind() schema.TrendEstimator { return e.estimatorKind }
+
+func (e *seriesEstimator) estimate(ld *locationData, span time.Duration, now time.Time) []rateEstimate {
+	threshold := now.Add(-1 * span)
+	ix := sort.Search(len(ld.Timestamps), func(i int) bool { return !ld.Timestamps[i].Before(threshold) })
+	timestampFloats := timestampsToFloats(ld.Timestamps[ix:])
+
+	result := make([]rateEstimate, len(ld.Series))
+	for i, values := range ld.Series {
+		sxx, sxy, syy := centeredSums(timestampFloats, values[ix:])
+		result[i] = newRateEstimate(e.slope(timestampFloats, values[ix:]), float64(len(timestampFloats)), sxx, sxy, syy)
+	}
+	return result
+}
+
+// theilSenSlope computes the median of slopes between all pairs of points;
+// it tolerates up to 29% of outliers, but costs O(N^2) for N points
+func theilSenSlope(timestamps, values []float64) float64 {
+	slopes := make([]float64, 0, len(timestamps)*(len(timestamps)-1)/2)
+	for i := range timestamps {
+		for j := i + 1; j < len(timestamps); j++ {
+			if dx := timestamps[j] - timestamps[i]; dx != 0 {
+				slopes = append(slopes, (values[j]-values[i])/dx)
+			}
+		}
+	}
+
+	if len(slopes) == 0 {
+		return math.NaN()
+	}
+
+	sort.Float64s(slopes)
+	middle := len(slopes) / 2
+	if len(slopes)%2 == 0 {
+		return (slopes[middle-1] + slopes[middle]) / 2
+	}
+	return slopes[middle]
+}
+
+// ewmaHalfLives is a number of half-lives fitting into the time span covered by the series
+const ewmaHalfLives = 4
+
+// ewmaSlope computes exponentially weighted moving average of the first differences;
+// weights decay with time, so the irregular sampling is taken into account
+func ewmaSlope(timestamps, values []float64) float64 {
+	if len(timestamps) < 2 {
+		return math.NaN()
+	}
+
+	halfLife := (timestamps[len(timestamps)-1] - timestamps[0]) / ewmaHalfLives
+
+	result := math.NaN()
+	for i := 1; i < len(timestamps); i++ {
+		dx := timestamps[i] - timestamps[i-1]
+		if dx == 0 {
+			continue
+		}
+		derivative := (values[i] - values[i-1]) / dx
+		if math.IsNaN(result) {
+			result = derivative
+			continue
+		}
+		alpha := 1 - math.Exp(-math.Ln2*dx/halfLife)
+		result += alpha * (derivative - result)
+	}
+	return result
+}
+
+// newEstimator instantiates estimator of a given kind
+func newEstimator(kind schema.TrendEstimator) (estimator, error) {
+	switch kind {
+	case schema.TrendEstimator_OLS:
+		return &olsEstimator{}, nil
+	case schema.TrendEstimator_THEIL_SEN:
+		return &seriesEstimator{estimatorKind: kind, slope: theilSenSlope}, nil
+	case schema.TrendEstimator_EWMA:
+		return &seriesEstimator{estimatorKind: kind, slope: ewmaSlope}, nil
+	default:
+		return nil, fmt.Errorf("unknown trend estimator %d", kind)
+	}
+}
@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/stat"
+
+	"github.com/memprofiler/memprofiler/schema"
+	"github.com/memprofiler/memprofiler/utils"
+)
+
+// Every estimator finds the exact slope of a straight line
+func TestEstimator_LinearGrowth(t *testing.T) {
+	var (
+		ld  = newLocationData(&schema.Callstack{}, time.Minute, time.Minute)
+		now = time.Now()
+	)
+	for i := 0; i < 10; i++ {
+		ld.registerMeasurement(now.Add(time.Duration(i-9)*time.Second), &schema.MemoryUsage{AllocBytes: int64(2 * i)})
+	}
+
+	for kind := range schema.TrendEstimator_name {
+		est, err := newEstimator(schema.TrendEstimator(kind))
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, schema.TrendEstimator(kind), est.kind())
+
+		rates := est.estimate(ld, time.Minute, now)
+		allocBytes := rates[schema.MemoryIndicator_ALLOC_BYTES]
+		assert.InDelta(t, 2, allocBytes.slope, 1e-9, est.kind().String())
+		assert.InDelta(t, 1, allocBytes.rSquared, 1e-9, est.kind().String())
+		assert.InDelta(t, 0, allocBytes.standardError, 1e-6, est.kind().String())
+		assert.Equal(t, 10, allocBytes.samples)
+		freeBytes := rates[schema.MemoryIndicator_FREE_BYTES]
+		assert.InDelta(t, 0, freeBytes.slope, 1e-9, est.kind().String())
+		assert.Equal(t, float64(1), freeBytes.rSquared, est.kind().String())
+
+		// not enough data within the window
+		rates = est.estimate(ld, time.Millisecond, now)
+		assert.True(t, math.IsNaN(rates[schema.MemoryIndicator_ALLOC_BYTES].slope), est.kind().String())
+		assert.True(t, math.IsNaN(rates[schema.MemoryIndicator_ALLOC_BYTES].rSquared), est.kind().String())
+	}
+}
+
+// Theil-Sen estimator ignores a single spike caused by delayed garbage collection
+func TestEstimator_TheilSenOutlier(t *testing.T) {
+	timestamps := []float64{0, 1, 2, 3, 4, 5, 6, 7, 8}
+	values := []float64{0, 1, 2, 3, 100, 5, 6, 7, 8}
+
+	assert.Equal(t, float64(1), theilSenSlope(timestamps, values))
+	assert.NotEqual(t, float64(1), computeSlope(timestamps, values))
+}
+
+// Goodness-of-fit values of OLS estimator are the same as the classic ones
+func TestEstimator_FitQuality(t *testing.T) {
+	var (
+		r      = rand.New(rand.NewSource(1))
+		ld     = newLocationData(&schema.Callstack{}, time.Minute, time.Minute)
+		now    = time.Now()
+		x, y   []float64
+		points = 30
+	)
+	for i := 0; i < points; i++ {
+		timestamp := now.Add(time.Duration(i-points+1) * time.Second)
+		allocBytes := 1e9 + 100*float64(i) + 1000*r.Float64()
+		ld.registerMeasurement(timestamp, &schema.MemoryUsage{AllocBytes: int64(allocBytes)})
+		x = append(x, utils.TimeToFloat64(timestamp))
+		y = append(y, float64(int64(allocBytes)))
+	}
+
+	alpha, beta := stat.LinearRegression(x, y, nil, false)
+	rSquared := stat.RSquared(x, y, nil, alpha, beta)
+	var sse float64
+	for i := range x {
+		residual := y[i] - alpha - beta*x[i]
+		sse += residual * residual
+	}
+	standardError := math.Sqrt(sse/float64(points-2)) / math.Sqrt(stat.Variance(x, nil)*float64(points-1))
+
+	estimate := (&olsEstimator{}).estimate(ld, time.Minute, now)[schema.MemoryIndicator_ALLOC_BYTES]
+	assert.InEpsilon(t, beta, estimate.slope, 1e-6)
+	assert.InEpsilon(t, rSquared, estimate.rSquared, 1e-6)
+	assert.InEpsilon(t, standardError, estimate.standardError, 1e-6)
+	assert.Equal(t, points, estimate.samples)
+}
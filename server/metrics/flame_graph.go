@@ -0,0 +1,201 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+
+	"github.com/memprofiler/memprofiler/schema"
+)
+
+// flameGraphNode is a mutable call tree node used while the tree is being built
+type flameGraphNode struct {
+	name             string
+	file             string
+	inUseBytes       float64 // subtree total
+	inUseObjects     float64 // subtree total
+	inUseBytesRate   float64 // subtree total
+	inUseObjectsRate float64 // subtree total
+	selfInUseBytes   float64 // memory allocated by the function itself
+	children         map[string]*flameGraphNode
+}
+
+func (n *flameGraphNode) child(frame *schema.StackFrame) *flameGraphNode {
+	child, exists := n.children[frame.GetName()]
+	if !exists {
+		child = newFlameGraphNode(frame.GetName(), frame.GetFile())
+		n.children[frame.GetName()] = child
+	}
+	return child
+}
+
+func (n *flameGraphNode) add(point *flameGraphPoint) {
+	n.inUseBytes += point.inUseBytes
+	n.inUseObjects += point.inUseObjects
+	n.inUseBytesRate += point.inUseBytesRate
+	n.inUseObjectsRate += point.inUseObjectsRate
+}
+
+// sortedChildren returns children sorted by function name (like flame graph tools do)
+func (n *flameGraphNode) sortedChildren() []*flameGraphNode {
+	result := make([]*flameGraphNode, 0, len(n.children))
+	for _, child := range n.children {
+		result = append(result, child)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].name < result[j].name })
+	return result
+}
+
+func (n *flameGraphNode) toSchema() *schema.FlameGraphNode {
+	result := &schema.FlameGraphNode{
+		Frame:            &schema.StackFrame{Name: n.name, File: n.file},
+		InUseBytes:       int64(n.inUseBytes),
+		InUseObjects:     int64(n.inUseObjects),
+		InUseBytesRate:   n.inUseBytesRate,
+		InUseObjectsRate: n.inUseObjectsRate,
+		Children:         make([]*schema.FlameGraphNode, 0, len(n.children)),
+	}
+	for _, child := range n.sortedChildren() {
+		result.Children = append(result.Children, child.toSchema())
+	}
+	return result
+}
+
+// fold dumps tree in a folded stacks format: every line contains semicolon separated
+// path from the child of the root and the self value of a node; the root itself is omitted
+func (n *flameGraphNode) fold(path []string, sb *strings.Builder) {
+	if len(path) > 0 && n.selfInUseBytes > 0 {
+		_, _ = fmt.Fprintf(sb, "%s %d\n", strings.Join(path, ";"), int64(n.selfInUseBytes))
+	}
+	for _, child := range n.sortedChildren() {
+		child.fold(append(path, child.name), sb)
+	}
+}
+
+func newFlameGraphNode(name, file string) *flameGraphNode {
+	return &flameGraphNode{
+		name:     name,
+		file:     file,
+		children: make(map[string]*flameGraphNode),
+	}
+}
+
+// flameGraphPoint contains location values for a particular moment of time
+type flameGraphPoint struct {
+	inUseBytes       float64
+	inUseObjects     float64
+	inUseBytesRate   float64
+	inUseObjectsRate float64
+}
+
+// flameGraphPoint takes the latest location values observed not later than the given moment;
+// rates are estimated for a time span preceding this moment, and are zero if there are not enough data
+func (ld *locationData) flameGraphPoint(observedAt time.Time, span time.Duration) (*flameGraphPoint, time.Time, bool) {
+	last := sort.Search(len(ld.Timestamps), func(i int) bool { return ld.Timestamps[i].After(observedAt) }) - 1
+	if last < 0 {
+		return nil, time.Time{}, false
+	}
+
+	threshold := observedAt.Add(-1 * span)
+	first := sort.Search(len(ld.Timestamps), func(i int) bool { return !ld.Timestamps[i].Before(threshold) })
+	timestampFloats := timestampsToFloats(ld.Timestamps[first : last+1])
+
+	var (
+		inUseBytes   = ld.Series[schema.MemoryIndicator_IN_USE_BYTES]
+		inUseObjects = ld.Series[schema.MemoryIndicator_IN_USE_OBJECTS]
+	)
+	point := &flameGraphPoint{
+		inUseBytes:       inUseBytes[last],
+		inUseObjects:     inUseObjects[last],
+		inUseBytesRate:   finiteOrZero(computeSlope(timestampFloats, inUseBytes[first:last+1])),
+		inUseObjectsRate: finiteOrZero(computeSlope(timestampFloats, inUseObjects[first:last+1])),
+	}
+	return point, ld.Timestamps[last], true
+}
+
+func finiteOrZero(value float64) float64 {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0
+	}
+	return value
+}
+
+// buildFlameGraph merges location callstacks into a single call tree;
+// zero observedAt value means the latest available data
+func buildFlameGraph(
+	locations map[string]*locationData,
+	observedAt time.Time,
+	span time.Duration,
+) (*schema.FlameGraph, error) {
+
+	if observedAt.IsZero() {
+		for _, ld := range locations {
+			if n := len(ld.Timestamps); n > 0 && ld.Timestamps[n-1].After(observedAt) {
+				observedAt = ld.Timestamps[n-1]
+			}
+		}
+	}
+
+	var (
+		root       = newFlameGraphNode("root", "")
+		actualTime time.Time
+	)
+	for _, ld := range locations {
+		point, pointTime, ok := ld.flameGraphPoint(observedAt, span)
+		if !ok || *point == (flameGraphPoint{}) {
+			continue
+		}
+		if pointTime.After(actualTime) {
+			actualTime = pointTime
+		}
+
+		// frames are stored starting from the allocation site, so walk them backwards
+		node := root
+		node.add(point)
+		frames := ld.callStack.GetFrames()
+		for i := len(frames) - 1; i >= 0; i-- {
+			node = node.child(frames[i])
+			node.add(point)
+		}
+		node.selfInUseBytes += point.inUseBytes
+	}
+
+	var sb strings.Builder
+	root.fold(nil, &sb)
+
+	result := &schema.FlameGraph{Root: root.toSchema(), Folded: sb.String()}
+	if !actualTime.IsZero() {
+		var err error
+		if result.ObservedAt, err = ptypes.TimestampProto(actualTime); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// flameGraph builds session call tree for a given moment of time
+func (sd *sessionData) flameGraph(observedAt time.Time, span time.Duration) (*schema.FlameGraph, error) {
+	sd.mutex.RLock()
+	defer sd.mutex.RUnlock()
+
+	return buildFlameGraph(sd.locations, observedAt, span)
+}
+
+// covers checks if session data retained in memory contains data
+// for the given moment of time and the time span preceding it
+func (sd *sessionData) covers(observedAt time.Time, span time.Duration) bool {
+	sd.mutex.RLock()
+	defer sd.mutex.RUnlock()
+
+	threshold := observedAt.Add(-1 * span)
+	for _, ld := range sd.locations {
+		if len(ld.Timestamps) > 0 && !ld.Timestamps[0].After(threshold) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,194 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/duration"
+	"gonum.org/v1/gonum/stat"
+	"gonum.org/v1/gonum/stat/distuv"
+
+	"github.com/memprofiler/memprofiler/schema"
+	"github.com/memprofiler/memprofiler/utils"
+)
+
+// defaultConfidence is a confidence level of the prediction interval used by default
+const defaultConfidence = 0.95
+
+// predictionBand describes linear trend y(d) = meanY + slope*d with the prediction interval
+// ±k*sqrt(g + d²/sxx) around it, where d is a distance from the mean x value
+type predictionBand struct {
+	meanY float64
+	slope float64
+	k     float64
+	g     float64
+	sxx   float64
+}
+
+// bound returns the upper (sign = 1) or the lower (sign = -1) bound of the prediction interval
+func (b *predictionBand) bound(d, sign float64) float64 {
+	return b.meanY + b.slope*d + sign*b.k*math.Sqrt(b.g+d*d/b.sxx)
+}
+
+// crossing returns the first point after d0 where the upper (sign = 1) or the lower (sign = -1)
+// bound of the prediction interval reaches the limit
+func (b *predictionBand) crossing(d0, limit, sign float64) (float64, bool) {
+	if b.bound(d0, sign) >= limit {
+		return d0, true
+	}
+
+	c := limit - b.meanY
+
+	// perfect fit, there is no interval around the trend
+	if b.k == 0 {
+		if b.slope <= 0 {
+			return 0, false
+		}
+		return c / b.slope, true
+	}
+
+	// (c - slope*d)² = k²(g + d²/sxx) is a quadratic equation
+	var (
+		qa    = b.slope*b.slope - b.k*b.k/b.sxx
+		qb    = -2 * b.slope * c
+		qc    = c*c - b.k*b.k*b.g
+		roots []float64
+	)
+	if qa == 0 {
+		roots = append(roots, -qc/qb)
+	} else {
+		discriminant := qb*qb - 4*qa*qc
+		if discriminant < 0 {
+			return 0, false
+		}
+		sqrt := math.Sqrt(discriminant)
+		roots = append(roots, (-qb-sqrt)/(2*qa), (-qb+sqrt)/(2*qa))
+	}
+
+	// squaring brings the roots belonging to the opposite bound, they're filtered out
+	result, found := math.Inf(1), false
+	for _, d := range roots {
+		if d > d0 && sign*(c-b.slope*d) >= 0 && d < result {
+			result, found = d, true
+		}
+	}
+	return result, found
+}
+
+// secondsToDurationProto converts the number of seconds into duration;
+// if the value is too large to be represented, nil is returned
+func secondsToDurationProto(seconds float64) *duration.Duration {
+	if math.IsNaN(seconds) || seconds >= float64(math.MaxInt64)/float64(time.Second) {
+		return nil
+	}
+	return ptypes.DurationProto(time.Duration(math.Max(0, seconds) * float64(time.Second)))
+}
+
+// windowStart returns the index of the first point within the time span preceding the given moment
+func windowStart(timestamps []time.Time, span time.Duration, now time.Time) int {
+	threshold := now.Add(-1 * span)
+	return sort.Search(len(timestamps), func(i int) bool { return !timestamps[i].Before(threshold) })
+}
+
+// forecast predicts when the total in-use bytes of the session reach the limit;
+// the linear trend is estimated for the time span preceding the given moment
+func (sd *sessionData) forecast(
+	limit uint64,
+	span time.Duration,
+	confidence float64,
+	now time.Time,
+) (*schema.Forecast, error) {
+	sd.mutex.RLock()
+	defer sd.mutex.RUnlock()
+
+	items := make([]*locationData, 0, len(sd.locations))
+	for _, ld := range sd.locations {
+		items = append(items, ld)
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no session data")
+	}
+
+	// fit the total in-use bytes series
+	total := sumLocationData(&schema.Callstack{}, sd.lifetime, items)
+	ix := windowStart(total.Timestamps, span, now)
+	x := timestampsToFloats(total.Timestamps[ix:])
+	y := total.Series[schema.MemoryIndicator_IN_USE_BYTES][ix:]
+	n := float64(len(x))
+	if n < 3 {
+		return nil, fmt.Errorf("not enough data to make a forecast: %d points within %v", len(x), span)
+	}
+
+	sxx, sxy, syy := centeredSums(x, y)
+	estimate := newRateEstimate(sxy/sxx, n, sxx, sxy, syy)
+	t := distuv.StudentsT{Mu: 0, Sigma: 1, Nu: n - 2}.Quantile((1 + confidence) / 2)
+	band := &predictionBand{
+		meanY: stat.Mean(y, nil),
+		slope: estimate.slope,
+		k:     t * estimate.standardError * math.Sqrt(sxx),
+		g:     1 + 1/n,
+		sxx:   sxx,
+	}
+
+	observedAt, err := ptypes.TimestampProto(now)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &schema.Forecast{
+		ObservedAt:     observedAt,
+		MemoryLimit:    limit,
+		InUseBytes:     y[len(y)-1],
+		InUseBytesRate: estimate.slope,
+		Quality:        estimate.toSchema(),
+		Confidence:     confidence,
+	}
+
+	// time is counted from now
+	d0 := utils.TimeToFloat64(now) - stat.Mean(x, nil)
+	trend := *band
+	trend.k = 0
+	if d, ok := trend.crossing(d0, float64(limit), 1); ok {
+		result.TimeToExhaustion = secondsToDurationProto(d - d0)
+	}
+	if d, ok := band.crossing(d0, float64(limit), 1); ok {
+		result.EarliestExhaustion = secondsToDurationProto(d - d0)
+	}
+	if d, ok := band.crossing(d0, float64(limit), -1); ok {
+		result.LatestExhaustion = secondsToDurationProto(d - d0)
+	}
+
+	result.Contributions = locationContributions(sd.locations, estimate.slope, span, now)
+	return result, nil
+}
+
+// locationContributions estimates in-use bytes growth rate of every location
+func locationContributions(
+	locations map[string]*locationData,
+	totalRate float64,
+	span time.Duration,
+	now time.Time,
+) []*schema.LocationContribution {
+
+	result := make([]*schema.LocationContribution, 0, len(locations))
+	for _, ld := range locations {
+		ix := windowStart(ld.Timestamps, span, now)
+		inUseBytes := ld.Series[schema.MemoryIndicator_IN_USE_BYTES]
+		if ix == len(inUseBytes) {
+			continue
+		}
+		rate := finiteOrZero(computeSlope(timestampsToFloats(ld.Timestamps[ix:]), inUseBytes[ix:]))
+		result = append(result, &schema.LocationContribution{
+			Callstack:      ld.callStack,
+			InUseBytes:     inUseBytes[len(inUseBytes)-1],
+			InUseBytesRate: rate,
+			Share:          finiteOrZero(rate / totalRate),
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].InUseBytesRate > result[j].InUseBytesRate })
+	return result
+}
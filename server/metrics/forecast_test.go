@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"context"
+	"math/rand"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/memprofiler/memprofiler/schema"
+)
+
+// Two locations grow with rates of 30 and 10 bytes per second,
+// so 1000 bytes limit is reached in (1000 - 460) / 40 = 13.5 seconds
+func TestSessionData_Forecast(t *testing.T) {
+	stubLogger := zerolog.New(os.Stderr).With().Timestamp().Logger()
+
+	var (
+		r         = rand.New(rand.NewSource(1))
+		now       = time.Now()
+		cs1       = &schema.Callstack{Id: "1"}
+		cs2       = &schema.Callstack{Id: "2"}
+		container = newSessionData(
+			&stubLogger, []time.Duration{time.Minute}, newWorkerPool(context.Background(), 1), &olsEstimator{}, time.Minute)
+	)
+
+	put := func(noise float64) {
+		for i := 0; i < 10; i++ {
+			tstamp, err := ptypes.TimestampProto(now.Add(time.Duration(i-9) * time.Second))
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			mm := &schema.Measurement{
+				ObservedAt: tstamp,
+				Locations: []*schema.Location{
+					{Callstack: cs1, MemoryUsage: &schema.MemoryUsage{AllocBytes: int64(30*i + int(noise*r.NormFloat64()))}},
+					{Callstack: cs2, MemoryUsage: &schema.MemoryUsage{AllocBytes: int64(100 + 10*i)}},
+				},
+			}
+			if !assert.NoError(t, container.appendMeasurement(mm)) {
+				t.FailNow()
+			}
+		}
+	}
+
+	// perfect linear growth
+	put(0)
+	forecast, err := container.forecast(1000, time.Minute, defaultConfidence, now)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, float64(460), forecast.InUseBytes)
+	assert.InDelta(t, 40, forecast.InUseBytesRate, 1e-6)
+	assert.InDelta(t, 1, forecast.Quality.RSquared, 1e-9)
+	tte, err := ptypes.Duration(forecast.TimeToExhaustion)
+	assert.NoError(t, err)
+	assert.InDelta(t, 13.5, tte.Seconds(), 1e-3)
+	earliest, err := ptypes.Duration(forecast.EarliestExhaustion)
+	assert.NoError(t, err)
+	assert.InDelta(t, tte.Seconds(), earliest.Seconds(), 1e-3)
+	latest, err := ptypes.Duration(forecast.LatestExhaustion)
+	assert.NoError(t, err)
+	assert.InDelta(t, tte.Seconds(), latest.Seconds(), 1e-3)
+	if assert.Len(t, forecast.Contributions, 2) {
+		assert.Equal(t, cs1, forecast.Contributions[0].Callstack)
+		assert.InDelta(t, 0.75, forecast.Contributions[0].Share, 1e-6)
+		assert.InDelta(t, 0.25, forecast.Contributions[1].Share, 1e-6)
+	}
+
+	// noisy growth makes prediction interval wider
+	container = newSessionData(
+		&stubLogger, []time.Duration{time.Minute}, newWorkerPool(context.Background(), 1), &olsEstimator{}, time.Minute)
+	put(20)
+	forecast, err = container.forecast(1000, time.Minute, defaultConfidence, now)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	tte, err = ptypes.Duration(forecast.TimeToExhaustion)
+	assert.NoError(t, err)
+	earliest, err = ptypes.Duration(forecast.EarliestExhaustion)
+	assert.NoError(t, err)
+	assert.True(t, earliest < tte)
+	if forecast.LatestExhaustion != nil {
+		latest, err = ptypes.Duration(forecast.LatestExhaustion)
+		assert.NoError(t, err)
+		assert.True(t, tte < latest)
+	}
+
+	// there are not enough data within the time span
+	_, err = container.forecast(1000, time.Millisecond, defaultConfidence, now)
+	assert.Error(t, err)
+}
@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"context"
+	"time"
 
 	"github.com/memprofiler/memprofiler/schema"
 	"github.com/memprofiler/memprofiler/server/common"
@@ -13,8 +14,53 @@ type Computer interface {
 	PutMeasurement(sd *schema.SessionDescription, mm *schema.Measurement) error
 	// TODO: remove?
 	SessionRecentMetrics(ctx context.Context, sd *schema.SessionDescription) (*schema.SessionMetrics, error)
-	// SessionSubscribe returns new subscription for session updates
-	SessionSubscribe(ctx context.Context, sd *schema.SessionDescription) (Subscription, error)
+	// SessionFlameGraph builds merged call tree of the session in-use memory for a given moment of time
+	// (zero value means the latest measurement); rates are estimated for a time span preceding this moment
+	SessionFlameGraph(
+		ctx context.Context,
+		sd *schema.SessionDescription,
+		observedAt time.Time,
+		span time.Duration,
+	) (*schema.FlameGraph, error)
+	// SessionForecast predicts when the session in-use memory reaches the memory limit
+	// (zero value means the limit configured for the service); the trend is estimated
+	// for a given time span, the prediction interval has a given confidence level
+	SessionForecast(
+		ctx context.Context,
+		sd *schema.SessionDescription,
+		memoryLimit uint64,
+		span time.Duration,
+		confidence float64,
+	) (*schema.Forecast, error)
+	// SessionChangePoints detects moments when the growth regime of in-use bytes has changed,
+	// both for the whole session and for every location
+	SessionChangePoints(ctx context.Context, sd *schema.SessionDescription) ([]*schema.Annotation, error)
+	// SessionsAnomalies compares rates of a given memory indicator estimated for a given time span
+	// (zero value means the longest averaging window) across the sessions of different instances;
+	// rates with modified z-score exceeding the threshold (zero value means default threshold) are returned
+	SessionsAnomalies(
+		ctx context.Context,
+		sds []*schema.SessionDescription,
+		indicator schema.MemoryIndicator,
+		span time.Duration,
+		threshold float64,
+	) ([]*schema.Anomaly, error)
+	// SessionSubscribe returns new subscription for session updates; updates are sent not more often
+	// than once per interval, which can't be shorter than the configured recompute interval
+	SessionSubscribe(
+		ctx context.Context,
+		sd *schema.SessionDescription,
+		aggregation Aggregation,
+		interval time.Duration,
+	) (Subscription, error)
+	// ServiceSubscribe returns new subscription for the merged updates of several sessions
+	// (usually the live sessions of all service instances); the set of sessions is fixed
+	ServiceSubscribe(
+		ctx context.Context,
+		sds []*schema.SessionDescription,
+		aggregation Aggregation,
+		interval time.Duration,
+	) (ServiceSubscription, error)
 	// TODO: method to close session and free resources
 	common.Subsystem
 }
@@ -22,22 +68,46 @@ type Computer interface {
 // Subscription provides push interface to receive actual session metrics
 type Subscription interface {
 	// Updates returns read-only channel with actual session metrics;
-	// if the channel is closed, the session is terminated
+	// if the channel is closed, the session is terminated.
+	// Updates are never queued: if subscriber reads slower than session data changes,
+	// it receives only the most recent version of session metrics
 	Updates() <-chan *schema.SessionMetrics
+	// Dropped returns the number of updates that were coalesced because of slow reading
+	Dropped() uint64
 	// Unsubscribe frees resources occupied by subscription
 	Unsubscribe()
-	// publish sends message to the subscriber
-	publish(*schema.SessionMetrics)
+	// publish notifies subscriber about session data update; it never blocks,
+	// session metrics are computed (according to the subscription aggregation mode)
+	// asynchronously, when subscriber is ready to receive them
+	publish(*sessionData)
 	// close terminates subscription
 	close()
 }
 
+// ServiceSubscription provides push interface to receive metrics merged across several sessions
+type ServiceSubscription interface {
+	// Updates returns read-only channel with merged metrics;
+	// if the channel is closed, all sessions are terminated
+	Updates() <-chan *schema.ServiceMetrics
+	// Dropped returns the number of session updates that were coalesced because of slow reading
+	Dropped() uint64
+	// Unsubscribe frees resources occupied by subscription
+	Unsubscribe()
+}
+
 // dispatcher is a subscription manager
 type dispatcher interface {
 	// createSubscription creates new subscription for a session
-	createSubscription(ctx context.Context, description *schema.SessionDescription) Subscription
+	createSubscription(
+		ctx context.Context,
+		description *schema.SessionDescription,
+		aggregation Aggregation,
+		interval time.Duration,
+	) Subscription
 	// dropSubscription deletes existing subscription
 	dropSubscription(description *schema.SessionDescription, id subscriptionID)
-	// broadcast sends message to all existing subscriptions
-	broadcast(description *schema.SessionDescription, msg *schema.SessionMetrics)
+	// hasSubscribers checks if there is anybody interested in session updates
+	hasSubscribers(description *schema.SessionDescription) bool
+	// broadcast notifies all existing subscriptions about session data update
+	broadcast(description *schema.SessionDescription, data *sessionData)
 }
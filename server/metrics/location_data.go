@@ -1,8 +1,6 @@
 package metrics
 
 import (
-	"reflect"
-	"sort"
 	"time"
 
 	"github.com/golang/protobuf/ptypes"
@@ -14,26 +12,23 @@ import (
 	"github.com/memprofiler/memprofiler/schema"
 )
 
-// locationData contains collection of time series with different heap metrics;
-// some of fields are public to make the work with reflection little bit easy
+// locationData contains collection of time series with different heap metrics
 type locationData struct {
-	AllocBytes   []float64
-	AllocObjects []float64
-	FreeBytes    []float64
-	FreeObjects  []float64
-	InUseBytes   []float64
-	InUseObjects []float64
-	Timestamps   []time.Time
-	lifetime     time.Duration // equals to the longest averaging window available
-	callStack    *schema.Callstack
+	Series     [][]float64 // values of every metric, indexed by schema.MemoryIndicator
+	Timestamps []time.Time
+	lifetime   time.Duration // equals to the longest averaging window available
+	callStack  *schema.Callstack
+	windows    map[time.Duration]*regressionWindow // incremental regression state for every averaging window
+	baselines  []*rollingMinimum                   // baseline state for every baseline metric (nil for others)
+	baseline   time.Duration                       // the period of baseline rolling minimum
 }
 
 // registerMeasurement appends new measurement to the process
 func (ld *locationData) registerMeasurement(timestamp time.Time, mu *schema.MemoryUsage) {
 
 	// check if there are outdated records and compute index
-	// that
-	threshold := time.Now().Add(-1 * ld.lifetime)
+	// that; the retention period is counted from the latest measurement
+	threshold := timestamp.Add(-1 * ld.lifetime)
 	edge := 0
 	for i, timestamp := range ld.Timestamps {
 		if timestamp.Before(threshold) {
@@ -45,47 +40,87 @@ func (ld *locationData) registerMeasurement(timestamp time.Time, mu *schema.Memo
 
 	// shift series if data TTL is reached
 	if edge != 0 {
-		ld.AllocObjects = ld.AllocObjects[edge:]
-		ld.AllocBytes = ld.AllocBytes[edge:]
-		ld.FreeObjects = ld.FreeObjects[edge:]
-		ld.FreeBytes = ld.FreeBytes[edge:]
-		ld.InUseObjects = ld.InUseObjects[edge:]
-		ld.InUseBytes = ld.InUseBytes[edge:]
+		for _, w := range ld.windows {
+			w.discard(ld, edge)
+		}
+		for _, b := range ld.baselines {
+			if b != nil {
+				b.shift(edge)
+			}
+		}
+		for i := range ld.Series {
+			ld.Series[i] = ld.Series[i][edge:]
+		}
 		ld.Timestamps = ld.Timestamps[edge:]
 	}
 
-	if mu != nil {
-		// append measurement data to the slices
-		ld.AllocObjects = append(ld.AllocObjects, float64(mu.AllocObjects))
-		ld.AllocBytes = append(ld.AllocBytes, float64(mu.AllocBytes))
-		ld.FreeObjects = append(ld.FreeObjects, float64(mu.FreeObjects))
-		ld.FreeBytes = append(ld.FreeBytes, float64(mu.FreeBytes))
-		ld.InUseObjects = append(ld.InUseObjects, float64(mu.AllocObjects)-float64(mu.FreeObjects))
-		ld.InUseBytes = append(ld.InUseBytes, float64(mu.AllocBytes)-float64(mu.FreeBytes))
-	} else {
-		// special case, see comments for the caller
-		ld.AllocObjects = append(ld.AllocObjects, 0)
-		ld.AllocBytes = append(ld.AllocBytes, 0)
-		ld.FreeObjects = append(ld.FreeObjects, 0)
-		ld.FreeBytes = append(ld.FreeBytes, 0)
-		ld.InUseObjects = append(ld.InUseObjects, 0)
-		ld.InUseBytes = append(ld.InUseBytes, 0)
+	// append measurement data to the slices; nil memory usage is
+	// a special case (see comments for the caller) that results in zero values
+	for i, metric := range schema.Metrics {
+		if !metric.Baseline {
+			ld.Series[i] = append(ld.Series[i], metric.Value(mu))
+		}
 	}
 	ld.Timestamps = append(ld.Timestamps, timestamp)
+	ld.pushBaselines()
 }
 
-// computeMetrics performs stats computations for every stored time series;
-// the timestamps are shared between all session members and stored out there
-func (ld *locationData) computeMetrics(spans []time.Duration) *schema.LocationMetrics {
+// pushBaselines computes baseline metrics for the latest point of source metrics
+func (ld *locationData) pushBaselines() {
+	for i, metric := range schema.Metrics {
+		if metric.Baseline {
+			value := ld.baselines[i].push(ld.Series[metric.Source], ld.Timestamps)
+			ld.Series[i] = append(ld.Series[i], value)
+		}
+	}
+}
+
+// fillBaselines computes baseline metrics from scratch
+func (ld *locationData) fillBaselines() {
+	for i, metric := range schema.Metrics {
+		if metric.Baseline {
+			ld.Series[i] = make([]float64, 0, len(ld.Timestamps))
+			ld.baselines[i].reset()
+		}
+	}
 
-	// x axis values
-	timestampFloats := timestampsToFloats(ld.Timestamps)
+	for j := range ld.Timestamps {
+		for i, metric := range schema.Metrics {
+			if metric.Baseline {
+				value := ld.baselines[i].push(ld.Series[metric.Source][:j+1], ld.Timestamps[:j+1])
+				ld.Series[i] = append(ld.Series[i], value)
+			}
+		}
+	}
+}
+
+// snapshot returns a copy of location data that can be used for computations without session lock;
+// series are only appended or resliced, so their contents are shared, while regression state is copied
+func (ld *locationData) snapshot() *locationData {
+	result := &locationData{
+		Series:     make([][]float64, len(ld.Series)),
+		Timestamps: ld.Timestamps,
+		lifetime:   ld.lifetime,
+		callStack:  ld.callStack,
+		windows:    make(map[time.Duration]*regressionWindow, len(ld.windows)),
+		baseline:   ld.baseline,
+	}
+	copy(result.Series, ld.Series)
+	for span, w := range ld.windows {
+		result.windows[span] = w.clone()
+	}
+	return result
+}
+
+// computeMetrics performs stats computations for every stored time series;
+// averaging windows are counted back from the given moment of time
+func (ld *locationData) computeMetrics(spans []time.Duration, now time.Time, est estimator) *schema.LocationMetrics {
 
 	rates := make([]*schema.MemoryUtilizationRate, 0, len(spans))
 
 	// compute trends for every span (or averaging window)
 	for _, span := range spans {
-		rates = append(rates, ld.computeMetricsForSpan(span, timestampFloats))
+		rates = append(rates, ld.computeMetricsForSpan(span, now, est))
 	}
 	return &schema.LocationMetrics{Callstack: ld.callStack, Rates: rates}
 }
@@ -93,37 +128,20 @@ func (ld *locationData) computeMetrics(spans []time.Duration) *schema.LocationMe
 // computeMetricsForSpan performs stats computation for a particular time span
 func (ld *locationData) computeMetricsForSpan(
 	span time.Duration,
-	timestampFloats []float64,
+	now time.Time,
+	est estimator,
 ) *schema.MemoryUtilizationRate {
 
-	threshold := utils.TimeToFloat64(time.Now().Add(-1 * span))
-	ix := sort.SearchFloat64s(timestampFloats, threshold)
-
 	result := &schema.MemoryUtilizationRate{
-		Values: &schema.MemoryUtilizationRate_Values{},
-		Span:   ptypes.DurationProto(span),
+		Values:    &schema.MemoryUtilizationRate_Values{},
+		Span:      ptypes.DurationProto(span),
+		Estimator: est.kind(),
+		Quality:   &schema.MemoryUtilizationRate_Quality{},
 	}
-
-	// The source time series are locationData's []float64 slices (called AllocBytes, AllocObjects, ...).
-	// The destination trends are MemoryUtilizationRate.Values (also called AllocBytes, AllocObjects, ...).
-	// Using the power of reflection, we walk through these two structs and compute trends one by one
-	src := reflect.Indirect(reflect.ValueOf(ld))
-	dst := reflect.Indirect(reflect.ValueOf(result.Values))
-	for i := 0; i < src.NumField(); i++ {
-
-		dataField := src.Field(i)
-		fieldName := src.Type().Field(i).Name
-
-		// estimate regression parameters for every time series
-		if fieldName != "Timestamps" &&
-			dataField.Kind() == reflect.Slice &&
-			dataField.Type().Elem().Kind() == reflect.Float64 {
-			slope := computeSlope(timestampFloats[ix:], dataField.Interface().([]float64)[ix:])
-			ratesField := dst.FieldByName(fieldName)
-			ratesField.SetFloat(slope)
-		}
+	for i, estimate := range est.estimate(ld, span, now) {
+		schema.Metrics[i].SetRate(result.Values, estimate.slope)
+		schema.Metrics[i].SetQuality(result.Quality, estimate.toSchema())
 	}
-
 	return result
 }
 
@@ -147,9 +165,19 @@ func computeSlope(timestamps, values []float64) float64 {
 	return slope
 }
 
-func newLocationData(callStack *schema.Callstack, lifetime time.Duration) *locationData {
-	return &locationData{
+func newLocationData(callStack *schema.Callstack, lifetime, baselinePeriod time.Duration) *locationData {
+	ld := &locationData{
+		Series:    make([][]float64, len(schema.Metrics)),
 		callStack: callStack,
 		lifetime:  lifetime,
+		windows:   make(map[time.Duration]*regressionWindow),
+		baselines: make([]*rollingMinimum, len(schema.Metrics)),
+		baseline:  baselinePeriod,
+	}
+	for i, metric := range schema.Metrics {
+		if metric.Baseline {
+			ld.baselines[i] = newRollingMinimum(baselinePeriod)
+		}
 	}
+	return ld
 }
@@ -0,0 +1,266 @@
+package metrics
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/memprofiler/memprofiler/schema"
+	"github.com/memprofiler/memprofiler/utils"
+)
+
+// fullRecomputeSlopes estimates rates over the whole window from scratch, like it was done before
+// the incremental regression was implemented; it's used as a reference implementation
+func fullRecomputeSlopes(ld *locationData, span time.Duration, now time.Time) []float64 {
+	timestampFloats := timestampsToFloats(ld.Timestamps)
+	ix := sort.SearchFloat64s(timestampFloats, utils.TimeToFloat64(now.Add(-1*span)))
+
+	result := make([]float64, len(ld.Series))
+	for i, values := range ld.Series {
+		result[i] = computeSlope(timestampFloats[ix:], values[ix:])
+	}
+	return result
+}
+
+func randomMemoryUsage(r *rand.Rand) *schema.MemoryUsage {
+	return &schema.MemoryUsage{
+		AllocObjects: r.Int63n(1 << 20),
+		AllocBytes:   r.Int63n(1 << 30),
+		FreeObjects:  r.Int63n(1 << 20),
+		FreeBytes:    r.Int63n(1 << 30),
+	}
+}
+
+// Incremental regression must give the same results as the full recomputation
+// while the points come in, leave the windows and get discarded by retention policy
+func TestLocationData_IncrementalRegression(t *testing.T) {
+	var (
+		r     = rand.New(rand.NewSource(1))
+		spans = []time.Duration{10 * time.Second, time.Minute}
+		ld    = newLocationData(&schema.Callstack{}, time.Minute, time.Minute)
+		now   = time.Now()
+	)
+
+	// the session lasts much longer than rebaseSpans windows
+	for i := 0; i < 1000; i++ {
+		now = now.Add(time.Duration(1+r.Intn(3)) * time.Second)
+
+		var mu *schema.MemoryUsage
+		if r.Intn(10) > 0 {
+			mu = randomMemoryUsage(r)
+		}
+		ld.registerMeasurement(now, mu)
+
+		// rates are not requested on every measurement
+		if r.Intn(3) > 0 {
+			continue
+		}
+
+		lm := ld.computeMetrics(spans, now, &olsEstimator{})
+		for j, span := range spans {
+			expected := fullRecomputeSlopes(ld, span, now)
+			for k, metric := range schema.Metrics {
+				actual := metric.Rate(lm.Rates[j].Values)
+				if math.IsNaN(expected[k]) {
+					assert.True(t, math.IsNaN(actual))
+				} else {
+					assert.InDelta(t, expected[k], actual, 1e-6*math.Max(1, math.Abs(expected[k])))
+				}
+			}
+		}
+	}
+}
+
+const (
+	benchmarkLocations = 10000
+	benchmarkWindow    = time.Hour
+	benchmarkStep      = 30 * time.Second
+)
+
+// benchmarkLocationData measures the cost of rate computation after every new measurement
+// for a session with a lot of locations and a long averaging window
+func benchmarkLocationData(b *testing.B, compute func(ld *locationData, now time.Time)) {
+	var (
+		r   = rand.New(rand.NewSource(1))
+		lds = make([]*locationData, benchmarkLocations)
+		now = time.Now()
+	)
+
+	register := func() {
+		now = now.Add(benchmarkStep)
+		for _, ld := range lds {
+			ld.registerMeasurement(now, randomMemoryUsage(r))
+		}
+	}
+
+	// fill the window
+	for i := range lds {
+		lds[i] = newLocationData(&schema.Callstack{}, benchmarkWindow, time.Minute)
+	}
+	for i := 0; i < int(benchmarkWindow/benchmarkStep); i++ {
+		register()
+	}
+	for _, ld := range lds {
+		compute(ld, now)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		register()
+		b.StartTimer()
+
+		for _, ld := range lds {
+			compute(ld, now)
+		}
+	}
+}
+
+func BenchmarkLocationData_Incremental(b *testing.B) {
+	spans := []time.Duration{benchmarkWindow}
+	benchmarkLocationData(b, func(ld *locationData, now time.Time) { ld.computeMetrics(spans, now, &olsEstimator{}) })
+}
+
+func BenchmarkLocationData_FullRecompute(b *testing.B) {
+	benchmarkLocationData(b, func(ld *locationData, now time.Time) { fullRecomputeSlopes(ld, benchmarkWindow, now) })
+}
+
+// reflectiveLocationData has the same series fields as locationData had before the metrics table was introduced
+type reflectiveLocationData struct {
+	AllocBytes   []float64
+	AllocObjects []float64
+	FreeBytes    []float64
+	FreeObjects  []float64
+	InUseBytes   []float64
+	InUseObjects []float64
+	Timestamps   []time.Time
+}
+
+func newReflectiveLocationData(ld *locationData) *reflectiveLocationData {
+	return &reflectiveLocationData{
+		AllocBytes:   ld.Series[schema.MemoryIndicator_ALLOC_BYTES],
+		AllocObjects: ld.Series[schema.MemoryIndicator_ALLOC_OBJECTS],
+		FreeBytes:    ld.Series[schema.MemoryIndicator_FREE_BYTES],
+		FreeObjects:  ld.Series[schema.MemoryIndicator_FREE_OBJECTS],
+		InUseBytes:   ld.Series[schema.MemoryIndicator_IN_USE_BYTES],
+		InUseObjects: ld.Series[schema.MemoryIndicator_IN_USE_OBJECTS],
+		Timestamps:   ld.Timestamps,
+	}
+}
+
+// computeRatesWithReflection reproduces computeMetricsForSpan as it was before the metrics table was introduced:
+// series fields are walked via reflection, and every slope is put into the rate field having the same name
+func computeRatesWithReflection(ld *reflectiveLocationData, timestampFloats []float64, ix int) *schema.MemoryUtilizationRate_Values {
+	result := &schema.MemoryUtilizationRate_Values{}
+	src := reflect.Indirect(reflect.ValueOf(ld))
+	dst := reflect.Indirect(reflect.ValueOf(result))
+	for i := 0; i < src.NumField(); i++ {
+		dataField := src.Field(i)
+		fieldName := src.Type().Field(i).Name
+		if fieldName != "Timestamps" &&
+			dataField.Kind() == reflect.Slice &&
+			dataField.Type().Elem().Kind() == reflect.Float64 {
+			slope := computeSlope(timestampFloats[ix:], dataField.Interface().([]float64)[ix:])
+			dst.FieldByName(fieldName).SetFloat(slope)
+		}
+	}
+	return result
+}
+
+// computeRatesWithTable computes the same rates, but takes series and rate fields from the metrics table
+func computeRatesWithTable(ld *locationData, timestampFloats []float64, ix int) *schema.MemoryUtilizationRate_Values {
+	result := &schema.MemoryUtilizationRate_Values{}
+	for i, metric := range schema.Metrics {
+		if metric.Baseline {
+			continue
+		}
+		metric.SetRate(result, computeSlope(timestampFloats[ix:], ld.Series[i][ix:]))
+	}
+	return result
+}
+
+// ratesBenchmarkData fills the averaging window of a location used by rate computation benchmarks
+func ratesBenchmarkData() (*locationData, []float64) {
+	var (
+		r   = rand.New(rand.NewSource(1))
+		ld  = newLocationData(&schema.Callstack{}, benchmarkWindow, time.Minute)
+		now = time.Now()
+	)
+	for i := 0; i < int(benchmarkWindow/benchmarkStep); i++ {
+		now = now.Add(benchmarkStep)
+		ld.registerMeasurement(now, randomMemoryUsage(r))
+	}
+	return ld, timestampsToFloats(ld.Timestamps)
+}
+
+func BenchmarkComputeRates_Reflection(b *testing.B) {
+	ld, timestampFloats := ratesBenchmarkData()
+	rld := newReflectiveLocationData(ld)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		computeRatesWithReflection(rld, timestampFloats, 0)
+	}
+}
+
+func BenchmarkComputeRates_Table(b *testing.B) {
+	ld, timestampFloats := ratesBenchmarkData()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		computeRatesWithTable(ld, timestampFloats, 0)
+	}
+}
+
+// The metrics table gives the same rates as the reflective field walk
+func TestComputeRates_TableMatchesReflection(t *testing.T) {
+	var (
+		r   = rand.New(rand.NewSource(1))
+		ld  = newLocationData(&schema.Callstack{}, time.Hour, time.Minute)
+		now = time.Now()
+	)
+	for i := 0; i < 100; i++ {
+		now = now.Add(time.Second)
+		ld.registerMeasurement(now, randomMemoryUsage(r))
+	}
+	timestampFloats := timestampsToFloats(ld.Timestamps)
+
+	for _, ix := range []int{0, 50} {
+		expected := computeRatesWithReflection(newReflectiveLocationData(ld), timestampFloats, ix)
+		assert.Equal(t, expected, computeRatesWithTable(ld, timestampFloats, ix))
+	}
+}
+
+// The sum of a single location doesn't share state with the original location
+func TestSumLocationData_SingleItem(t *testing.T) {
+	var (
+		start = time.Now()
+		cs    = &schema.Callstack{Id: "cs"}
+		ld    = newLocationData(cs, time.Minute, time.Minute)
+		est   = &olsEstimator{}
+	)
+	for i := 0; i < 10; i++ {
+		ld.registerMeasurement(start.Add(time.Duration(i)*time.Second), &schema.MemoryUsage{AllocBytes: int64(i)})
+	}
+	ld.computeMetrics([]time.Duration{time.Minute}, start.Add(10*time.Second), est)
+
+	group := &schema.Callstack{Id: "group"}
+	sum := sumLocationData(group, time.Minute, []*locationData{ld})
+	assert.Equal(t, group, sum.callStack)
+	assert.Equal(t, cs, ld.callStack)
+	assert.Equal(t, ld.Timestamps, sum.Timestamps)
+	assert.Equal(t, ld.Series, sum.Series)
+
+	// changes of the sum don't affect the original location
+	sum.registerMeasurement(start.Add(2*time.Minute), &schema.MemoryUsage{AllocBytes: 100})
+	sum.computeMetrics([]time.Duration{time.Minute}, start.Add(2*time.Minute), est)
+	assert.Len(t, ld.Timestamps, 10)
+	assert.Equal(t, float64(9), ld.Series[schema.MemoryIndicator_ALLOC_BYTES][9])
+	assert.Equal(t, 10, ld.windows[time.Minute].next)
+	assert.NotEqual(t, ld.windows[time.Minute], sum.windows[time.Minute])
+}
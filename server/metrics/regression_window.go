@@ -0,0 +1,145 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/memprofiler/memprofiler/schema"
+	"github.com/memprofiler/memprofiler/utils"
+)
+
+// rebaseSpans limits how far (in window spans) x values may move away from the origin
+// before the sums are recomputed from scratch; this bounds both the loss of precision
+// and the accumulation of rounding errors caused by subtractions
+const rebaseSpans = 4
+
+// regressionWindow incrementally maintains the sums required to estimate linear regression
+// slopes of location time series over a sliding time window: every point is added
+// and evicted exactly once, so the cost of rate computation doesn't depend on the window length
+type regressionWindow struct {
+	span    time.Duration
+	first   int       // index of the oldest point that belongs to the window
+	next    int       // index of the point that will be added next
+	origin  float64   // x values are shifted by origin to keep precision
+	origins []float64 // the same for y values of every series
+	n       float64
+	sumX    float64
+	sumXX   float64
+	sumY    []float64
+	sumYY   []float64
+	sumXY   []float64
+}
+
+// update adds the latest points of location time series to the window
+// and evicts the points that are older than the threshold
+func (w *regressionWindow) update(ld *locationData, threshold time.Time) {
+	for ; w.next < len(ld.Timestamps); w.next++ {
+		w.add(ld.Series, w.next, utils.TimeToFloat64(ld.Timestamps[w.next]))
+	}
+
+	for ; w.first < w.next && ld.Timestamps[w.first].Before(threshold); w.first++ {
+		w.remove(ld.Series, w.first, utils.TimeToFloat64(ld.Timestamps[w.first]))
+	}
+
+	if w.n > 0 && utils.TimeToFloat64(ld.Timestamps[w.next-1])-w.origin > rebaseSpans*w.span.Seconds() {
+		w.rebase(ld.Series, ld.Timestamps)
+	}
+}
+
+// discard evicts the points with indices less than edge; it must be called
+// right before location time series are shifted by edge elements
+func (w *regressionWindow) discard(ld *locationData, edge int) {
+	for ; w.first < edge && w.first < w.next; w.first++ {
+		w.remove(ld.Series, w.first, utils.TimeToFloat64(ld.Timestamps[w.first]))
+	}
+
+	// the points that have never been added to the window are just skipped
+	if w.next < edge {
+		w.first, w.next = edge, edge
+	}
+
+	w.first -= edge
+	w.next -= edge
+}
+
+// estimates computes the slope of linear regression equation for every location time series;
+// NaN values are returned if there are less than two points within the window
+func (w *regressionWindow) estimates() []rateEstimate {
+	result := make([]rateEstimate, len(w.sumY))
+	sxx := w.sumXX - w.sumX*w.sumX/w.n
+	for i := range result {
+		sxy := w.sumXY[i] - w.sumX*w.sumY[i]/w.n
+		syy := w.sumYY[i] - w.sumY[i]*w.sumY[i]/w.n
+		result[i] = newRateEstimate(sxy/sxx, w.n, sxx, sxy, syy)
+	}
+	return result
+}
+
+func (w *regressionWindow) add(series [][]float64, ix int, x float64) {
+	if w.n == 0 {
+		w.origin = x
+		for i, values := range series {
+			w.origins[i] = values[ix]
+		}
+	}
+	dx := x - w.origin
+	w.n++
+	w.sumX += dx
+	w.sumXX += dx * dx
+	for i, values := range series {
+		dy := values[ix] - w.origins[i]
+		w.sumY[i] += dy
+		w.sumYY[i] += dy * dy
+		w.sumXY[i] += dx * dy
+	}
+}
+
+func (w *regressionWindow) remove(series [][]float64, ix int, x float64) {
+	w.n--
+	if w.n == 0 {
+		w.reset()
+		return
+	}
+	dx := x - w.origin
+	w.sumX -= dx
+	w.sumXX -= dx * dx
+	for i, values := range series {
+		dy := values[ix] - w.origins[i]
+		w.sumY[i] -= dy
+		w.sumYY[i] -= dy * dy
+		w.sumXY[i] -= dx * dy
+	}
+}
+
+// rebase recomputes the sums from scratch with the origins moved to the oldest point of the window
+func (w *regressionWindow) rebase(series [][]float64, timestamps []time.Time) {
+	w.reset()
+	for ix := w.first; ix < w.next; ix++ {
+		w.add(series, ix, utils.TimeToFloat64(timestamps[ix]))
+	}
+}
+
+func (w *regressionWindow) clone() *regressionWindow {
+	result := *w
+	result.origins = append([]float64(nil), w.origins...)
+	result.sumY = append([]float64(nil), w.sumY...)
+	result.sumYY = append([]float64(nil), w.sumYY...)
+	result.sumXY = append([]float64(nil), w.sumXY...)
+	return &result
+}
+
+func (w *regressionWindow) reset() {
+	w.n, w.sumX, w.sumXX = 0, 0, 0
+	for i := range w.sumY {
+		w.sumY[i], w.sumYY[i], w.sumXY[i] = 0, 0, 0
+	}
+}
+
+func newRegressionWindow(span time.Duration) *regressionWindow {
+	return &regressionWindow{
+		span:    span,
+		origins: make([]float64, len(schema.Metrics)),
+		sumY:    make([]float64, len(schema.Metrics)),
+		sumYY:   make([]float64, len(schema.Metrics)),
+		sumXY:   make([]float64, len(schema.Metrics)),
+	}
+}
@@ -0,0 +1,181 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+
+	"github.com/memprofiler/memprofiler/schema"
+)
+
+var _ ServiceSubscription = (*defaultServiceSubscription)(nil)
+
+// defaultServiceSubscription merges updates of the session subscriptions;
+// every session update results in a new version of the merged view
+type defaultServiceSubscription struct {
+	sessions      []*schema.SessionDescription
+	subscriptions []Subscription
+	updates       chan *schema.ServiceMetrics
+	ctx           context.Context
+	cancel        context.CancelFunc
+}
+
+func (s *defaultServiceSubscription) Updates() <-chan *schema.ServiceMetrics { return s.updates }
+
+func (s *defaultServiceSubscription) Dropped() uint64 {
+	var result uint64
+	for _, subscription := range s.subscriptions {
+		result += subscription.Dropped()
+	}
+	return result
+}
+
+func (s *defaultServiceSubscription) Unsubscribe() {
+	s.cancel()
+	for _, subscription := range s.subscriptions {
+		subscription.Unsubscribe()
+	}
+}
+
+// sessionUpdate is an update of the i-th session
+type sessionUpdate struct {
+	index int
+	msg   *schema.SessionMetrics
+}
+
+func (s *defaultServiceSubscription) loop() {
+	defer close(s.updates)
+
+	var (
+		latest   = make([]*schema.SessionMetrics, len(s.subscriptions))
+		incoming = make(chan sessionUpdate)
+		wg       sync.WaitGroup
+	)
+
+	// fan in session updates
+	for i, subscription := range s.subscriptions {
+		wg.Add(1)
+		go func(i int, subscription Subscription) {
+			defer wg.Done()
+			for msg := range subscription.Updates() {
+				select {
+				case incoming <- sessionUpdate{index: i, msg: msg}:
+				case <-s.ctx.Done():
+					return
+				}
+			}
+		}(i, subscription)
+	}
+	go func() {
+		wg.Wait()
+		close(incoming)
+	}()
+
+	for {
+		select {
+		case update, ok := <-incoming:
+			if !ok {
+				// all sessions are terminated
+				return
+			}
+			latest[update.index] = update.msg
+			msg, err := mergeServiceMetrics(s.sessions, latest)
+			if err != nil {
+				return
+			}
+			select {
+			case s.updates <- msg:
+			case <-s.ctx.Done():
+				return
+			}
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// mergeServiceMetrics sums rates of the same locations of different sessions;
+// sessions that haven't published metrics yet are skipped
+func mergeServiceMetrics(
+	sessions []*schema.SessionDescription,
+	items []*schema.SessionMetrics,
+) (*schema.ServiceMetrics, error) {
+
+	type mergedLocation struct {
+		location *schema.LocationMetrics
+		rates    map[time.Duration]*schema.MemoryUtilizationRate
+	}
+
+	var (
+		result  = &schema.ServiceMetrics{Total: &schema.SessionMetrics{}}
+		merged  = make(map[string]*mergedLocation)
+		ordered []*mergedLocation
+	)
+
+	for i, item := range items {
+		if item == nil {
+			continue
+		}
+		result.Instances = append(result.Instances, &schema.InstanceMetrics{Session: sessions[i], Metrics: item})
+
+		for _, location := range item.GetLocations() {
+			id := location.GetCallstack().GetId()
+			ml, exists := merged[id]
+			if !exists {
+				ml = &mergedLocation{
+					location: &schema.LocationMetrics{Callstack: location.GetCallstack()},
+					rates:    make(map[time.Duration]*schema.MemoryUtilizationRate),
+				}
+				merged[id] = ml
+				ordered = append(ordered, ml)
+			}
+
+			for _, rate := range location.GetRates() {
+				span, err := ptypes.Duration(rate.GetSpan())
+				if err != nil {
+					return nil, err
+				}
+				// fit quality cannot be summed, so it's omitted
+				mr, exists := ml.rates[span]
+				if !exists {
+					mr = &schema.MemoryUtilizationRate{
+						Span:      rate.GetSpan(),
+						Values:    &schema.MemoryUtilizationRate_Values{},
+						Estimator: rate.GetEstimator(),
+					}
+					ml.rates[span] = mr
+					ml.location.Rates = append(ml.location.Rates, mr)
+				}
+				for _, metric := range schema.Metrics {
+					metric.SetRate(mr.Values, metric.Rate(mr.Values)+metric.Rate(rate.GetValues()))
+				}
+			}
+		}
+	}
+
+	result.Total.Locations = make([]*schema.LocationMetrics, 0, len(ordered))
+	for _, ml := range ordered {
+		result.Total.Locations = append(result.Total.Locations, ml.location)
+	}
+	result.Total.TotalLocations = uint32(len(result.Total.Locations))
+	return result, nil
+}
+
+func newServiceSubscription(
+	ctx context.Context,
+	sessions []*schema.SessionDescription,
+	subscriptions []Subscription,
+	cancel context.CancelFunc,
+) ServiceSubscription {
+	s := &defaultServiceSubscription{
+		ctx:           ctx,
+		cancel:        cancel,
+		sessions:      sessions,
+		subscriptions: subscriptions,
+		updates:       make(chan *schema.ServiceMetrics),
+	}
+	go s.loop()
+	return s
+}
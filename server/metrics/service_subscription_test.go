@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/memprofiler/memprofiler/schema"
+)
+
+func TestMergeServiceMetrics(t *testing.T) {
+	var (
+		span     = ptypes.DurationProto(time.Minute)
+		cs1      = &schema.Callstack{Id: "1"}
+		cs2      = &schema.Callstack{Id: "2"}
+		sessions = []*schema.SessionDescription{{Id: 1}, {Id: 2}, {Id: 3}}
+	)
+
+	location := func(cs *schema.Callstack, inUseBytes float64) *schema.LocationMetrics {
+		return &schema.LocationMetrics{
+			Callstack: cs,
+			Rates: []*schema.MemoryUtilizationRate{
+				{Span: span, Values: &schema.MemoryUtilizationRate_Values{InUseBytes: inUseBytes}},
+			},
+		}
+	}
+
+	// the third session hasn't published its metrics yet
+	items := []*schema.SessionMetrics{
+		{Locations: []*schema.LocationMetrics{location(cs1, 10), location(cs2, 1)}},
+		{Locations: []*schema.LocationMetrics{location(cs1, 20)}},
+		nil,
+	}
+
+	merged, err := mergeServiceMetrics(sessions, items)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	if assert.Len(t, merged.Instances, 2) {
+		assert.Equal(t, sessions[0], merged.Instances[0].Session)
+		assert.Equal(t, items[1], merged.Instances[1].Metrics)
+	}
+
+	assert.Equal(t, uint32(2), merged.Total.TotalLocations)
+	if assert.Len(t, merged.Total.Locations, 2) {
+		assert.Equal(t, cs1, merged.Total.Locations[0].Callstack)
+		assert.Equal(t, float64(30), merged.Total.Locations[0].Rates[0].Values.InUseBytes)
+		assert.Equal(t, cs2, merged.Total.Locations[1].Callstack)
+		assert.Equal(t, float64(1), merged.Total.Locations[1].Rates[0].Values.InUseBytes)
+	}
+
+	// source messages are shared between subscribers and must not be modified
+	assert.Equal(t, float64(10), items[0].Locations[0].Rates[0].Values.InUseBytes)
+}
@@ -2,7 +2,6 @@ package metrics
 
 import (
 	"context"
-	"runtime"
 	"sync"
 	"time"
 
@@ -17,12 +16,17 @@ import (
 // sessionData contains the most recent data of the particular session;
 // it's responsible for session metrics computation
 type sessionData struct {
-	mutex            sync.RWMutex             // synchronizes access to internal structs
-	locations        map[string]*locationData // per-location stats (stackID <-> locationData)
-	lifetime         time.Duration            // the retention period for time series data
-	sessionMetrics   *schema.SessionMetrics   // latest available session metrics (potentially outdated)
-	averagingWindows []time.Duration          // list of time spans used to compute trends
-	outdated         bool                     // if metrics should be recomputed by demand
+	mutex            sync.RWMutex                           // synchronizes access to internal structs
+	locations        map[string]*locationData               // per-location stats (stackID <-> locationData)
+	version          uint64                                 // number of measurements appended so far
+	lifetime         time.Duration                          // the retention period for time series data
+	cacheMutex       sync.Mutex                             // synchronizes access to metrics cache
+	sessionMetrics   map[Aggregation]*schema.SessionMetrics // latest available session metrics
+	cacheVersion     uint64                                 // version of session data the cached metrics belong to
+	averagingWindows []time.Duration                        // list of time spans used to compute trends
+	pool             *workerPool                            // performs rate computations
+	estimator        estimator                              // estimates rates
+	baselinePeriod   time.Duration                          // the period of baseline series rolling minimum
 	logger           *zerolog.Logger
 }
 
@@ -31,9 +35,6 @@ func (sd *sessionData) populate(
 	loadChan <-chan *data.LoadResult,
 ) error {
 
-	sd.mutex.Lock()
-	defer sd.mutex.Unlock()
-
 	// populate session data with historical measurements coming from loader
 LOOP:
 	for {
@@ -82,7 +83,7 @@ func (sd *sessionData) appendMeasurement(mm *schema.Measurement) error {
 	for _, l := range mm.Locations {
 		sdl, exists := sd.locations[l.Callstack.Id]
 		if !exists {
-			sdl = newLocationData(l.Callstack, sd.lifetime)
+			sdl = newLocationData(l.Callstack, sd.lifetime, sd.baselinePeriod)
 			sd.locations[l.Callstack.Id] = sdl
 		}
 		sdl.registerMeasurement(timestamp, l.MemoryUsage)
@@ -98,73 +99,111 @@ func (sd *sessionData) appendMeasurement(mm *schema.Measurement) error {
 	}
 
 	// mark existing sessionMetrics as outdated
-	sd.outdated = true
+	sd.version++
 	return nil
 }
 
-// getSessionMetrics returns trend values in a lazy manner
-func (sd *sessionData) getSessionMetrics() *schema.SessionMetrics {
+// getSessionMetrics returns trend values in a lazy manner;
+// metrics are cached separately for every aggregation mode
+func (sd *sessionData) getSessionMetrics(aggregation Aggregation) *schema.SessionMetrics {
+	// computations are performed on a snapshot of session data,
+	// so they don't block appending of new measurements
 	sd.mutex.RLock()
-	defer sd.mutex.RUnlock()
+	version := sd.version
+	if sessionMetrics := sd.getCachedSessionMetrics(aggregation, version); sessionMetrics != nil {
+		sd.mutex.RUnlock()
+		return sessionMetrics
+	}
+	snapshot := make(map[string]*locationData, len(sd.locations))
+	for stackID, ld := range sd.locations {
+		snapshot[stackID] = ld.snapshot()
+	}
+	sd.mutex.RUnlock()
 
-	// return existing sessionMetrics if it's not outdated
-	if !sd.outdated && sd.sessionMetrics != nil {
-		return sd.sessionMetrics
+	locations := aggregateLocations(aggregation, snapshot, sd.lifetime)
+	sessionMetrics := sd.computeSessionMetrics(locations)
+
+	// incremental regression state is kept unless session data has been changed in the meantime
+	if aggregation.Mode == schema.AggregationMode_CALLSTACK {
+		sd.mutex.Lock()
+		if sd.version == version {
+			for stackID, ld := range snapshot {
+				sd.locations[stackID].windows = ld.windows
+			}
+		}
+		sd.mutex.Unlock()
 	}
 
-	// otherwise perform computation from scratch
-	sd.sessionMetrics = sd.computeSessionMetrics()
-	sd.outdated = false
-	return sd.sessionMetrics
+	sd.putCachedSessionMetrics(aggregation, version, sessionMetrics)
+	return sessionMetrics
 }
 
-// computeSessionMetrics performs rate computation for all known locations
-func (sd *sessionData) computeSessionMetrics() *schema.SessionMetrics {
-	sd.mutex.RLock()
-	defer sd.mutex.RUnlock()
+func (sd *sessionData) getCachedSessionMetrics(aggregation Aggregation, version uint64) *schema.SessionMetrics {
+	sd.cacheMutex.Lock()
+	defer sd.cacheMutex.Unlock()
 
-	var (
-		requestChan  = make(chan *locationData, runtime.NumCPU())
-		responseChan = make(chan *schema.LocationMetrics, runtime.NumCPU())
-	)
+	if sd.cacheVersion != version {
+		return nil
+	}
+	return sd.sessionMetrics[aggregation]
+}
 
-	// rate computation is a CPU-bound operation, so spread it across cores
-	for i := 0; i < runtime.NumCPU(); i++ {
-		go func() {
-			for {
-				ld, ok := <-requestChan
-				if !ok {
-					return
-				}
-				responseChan <- ld.computeMetrics(sd.averagingWindows)
-			}
-		}()
+func (sd *sessionData) putCachedSessionMetrics(
+	aggregation Aggregation,
+	version uint64,
+	sessionMetrics *schema.SessionMetrics,
+) {
+	sd.cacheMutex.Lock()
+	defer sd.cacheMutex.Unlock()
+
+	// metrics computed concurrently for more recent data shouldn't be replaced
+	if version < sd.cacheVersion {
+		return
+	}
+	// drop existing sessionMetrics if they are outdated
+	if version > sd.cacheVersion {
+		sd.sessionMetrics = make(map[Aggregation]*schema.SessionMetrics)
+		sd.cacheVersion = version
 	}
+	sd.sessionMetrics[aggregation] = sessionMetrics
+}
 
-	// enqueue jobs
-	go func() {
-		for _, ld := range sd.locations {
-			requestChan <- ld
-		}
-		close(requestChan) // notify consumers
-	}()
+// computeSessionMetrics performs rate computation for all given locations
+func (sd *sessionData) computeSessionMetrics(locations map[string]*locationData) *schema.SessionMetrics {
+	var (
+		results = make([]*schema.LocationMetrics, len(locations))
+		tasks   = make([]func(), 0, len(locations))
+	)
 
-	// await results
-	results := make([]*schema.LocationMetrics, len(sd.locations))
-	for i := 0; i < len(sd.locations); i++ {
-		results[i] = <-responseChan
+	// rate computation is a CPU-bound operation, so spread it across
+	// the worker pool shared by all sessions
+	now := time.Now()
+	for _, ld := range locations {
+		ld, i := ld, len(tasks)
+		tasks = append(tasks, func() { results[i] = ld.computeMetrics(sd.averagingWindows, now, sd.estimator) })
 	}
+	sd.pool.execute(tasks)
 
-	return &schema.SessionMetrics{Locations: results}
+	return &schema.SessionMetrics{Locations: results, TotalLocations: uint32(len(results))}
 }
 
 // newSessionData instantiates new
-func newSessionData(logger *zerolog.Logger, averagingWindows []time.Duration) *sessionData {
+func newSessionData(
+	logger *zerolog.Logger,
+	averagingWindows []time.Duration,
+	pool *workerPool,
+	estimator estimator,
+	baselinePeriod time.Duration,
+) *sessionData {
 	return &sessionData{
 		locations:        make(map[string]*locationData),
+		sessionMetrics:   make(map[Aggregation]*schema.SessionMetrics),
 		lifetime:         averagingWindows[len(averagingWindows)-1],
 		averagingWindows: averagingWindows,
 		logger:           logger,
+		pool:             pool,
+		estimator:        estimator,
+		baselinePeriod:   baselinePeriod,
 		mutex:            sync.RWMutex{},
 	}
 }
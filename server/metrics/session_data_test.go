@@ -1,8 +1,10 @@
 package metrics
 
 import (
+	"context"
 	"math"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -84,7 +86,7 @@ func TestSessionData_LinearGrowth(t *testing.T) {
 	sixtySeconds := time.Minute
 	averagingWindows := []time.Duration{fiveSeconds, twentySeconds, sixtySeconds}
 
-	container := newSessionData(&stubLogger, averagingWindows)
+	container := newSessionData(&stubLogger, averagingWindows, newWorkerPool(context.Background(), 1), &olsEstimator{}, time.Minute)
 	for _, mm := range mms {
 		err := container.appendMeasurement(mm)
 		if !assert.NoError(t, err) {
@@ -93,7 +95,7 @@ func TestSessionData_LinearGrowth(t *testing.T) {
 	}
 
 	// obtain result
-	sessionMetrics := container.getSessionMetrics()
+	sessionMetrics := container.getSessionMetrics(Aggregation{})
 	assert.Len(t, sessionMetrics.Locations, 1)
 	lm := sessionMetrics.Locations[0]
 	assert.Equal(t, cs, lm.Callstack)
@@ -131,3 +133,255 @@ func TestSessionData_LinearGrowth(t *testing.T) {
 	assert.Equal(t, float64(0), sixtySecondRates.InUseBytes) // mutually compensated
 	assert.Equal(t, float64(0), sixtySecondRates.InUseObjects)
 }
+
+// Two locations sharing the same allocation site function are merged into a single one
+func TestSessionData_Aggregation(t *testing.T) {
+	stubLogger := zerolog.New(os.Stderr).With().Timestamp().Logger()
+
+	var (
+		cs1 = &schema.Callstack{
+			Id: "cs1",
+			Frames: []*schema.StackFrame{
+				{Name: "github.com/org/lib.Alloc", File: "lib.go", Line: 1},
+				{Name: "github.com/org/app.Handle", File: "app.go", Line: 10},
+			},
+		}
+		cs2 = &schema.Callstack{
+			Id: "cs2",
+			Frames: []*schema.StackFrame{
+				{Name: "github.com/org/lib.Alloc", File: "lib.go", Line: 2},
+				{Name: "github.com/org/app.Serve", File: "app.go", Line: 20},
+			},
+		}
+		cs3 = &schema.Callstack{
+			Id: "cs3",
+			Frames: []*schema.StackFrame{
+				{Name: "github.com/org/lib.(*Cache).Put", File: "cache.go", Line: 3},
+				{Name: "github.com/org/app.Handle", File: "app.go", Line: 10},
+			},
+		}
+	)
+
+	// every location grows with a rate of 1 byte per second,
+	// the second location appears later than the others
+	start := time.Now().Add(-1 * 30 * time.Second)
+	step := 10 * time.Second
+	container := newSessionData(&stubLogger, []time.Duration{time.Minute}, newWorkerPool(context.Background(), 1), &olsEstimator{}, time.Minute)
+	for i := 0; i < 4; i++ {
+		tstamp, err := ptypes.TimestampProto(start.Add(time.Duration(i) * step))
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		value := int64(i) * int64(step/time.Second)
+		mm := &schema.Measurement{
+			ObservedAt: tstamp,
+			Locations: []*schema.Location{
+				{MemoryUsage: &schema.MemoryUsage{AllocBytes: value}, Callstack: cs1},
+				{MemoryUsage: &schema.MemoryUsage{AllocBytes: value}, Callstack: cs3},
+			},
+		}
+		if i > 0 {
+			mm.Locations = append(mm.Locations, &schema.Location{
+				MemoryUsage: &schema.MemoryUsage{AllocBytes: value}, Callstack: cs2,
+			})
+		}
+		if !assert.NoError(t, container.appendMeasurement(mm)) {
+			t.FailNow()
+		}
+	}
+
+	rates := func(sm *schema.SessionMetrics) map[string]float64 {
+		result := make(map[string]float64)
+		for _, lm := range sm.Locations {
+			result[lm.Callstack.Id] = lm.Rates[0].Values.InUseBytes
+		}
+		return result
+	}
+
+	// no aggregation
+	assert.Len(t, container.getSessionMetrics(Aggregation{}).Locations, 3)
+
+	// cs1 and cs2 are merged; the first point of summed series contains data only from cs1
+	byFunction := rates(container.getSessionMetrics(Aggregation{Mode: schema.AggregationMode_FUNCTION}))
+	assert.Len(t, byFunction, 2)
+	assert.InDelta(t, 2, byFunction["FUNCTION:github.com/org/lib.Alloc:lib.go:0"], 1e-9)
+	assert.InDelta(t, 1, byFunction["FUNCTION:github.com/org/lib.(*Cache).Put:cache.go:0"], 1e-9)
+
+	// all locations share the same package
+	byPackage := rates(container.getSessionMetrics(Aggregation{Mode: schema.AggregationMode_PACKAGE}))
+	assert.Len(t, byPackage, 1)
+	assert.InDelta(t, 3, byPackage["PACKAGE:github.com/org/lib::0"], 1e-9)
+
+	// cs1 and cs3 share the same frame within the user's code
+	byModule := rates(container.getSessionMetrics(
+		Aggregation{Mode: schema.AggregationMode_MODULE, ModulePrefix: "github.com/org/app"}),
+	)
+	assert.Len(t, byModule, 2)
+	assert.InDelta(t, 2, byModule["MODULE:github.com/org/app.Handle:app.go:10"], 1e-9)
+	assert.InDelta(t, 1, byModule["MODULE:github.com/org/app.Serve:app.go:20"], 1e-9)
+
+	assert.Error(t, Aggregation{Mode: schema.AggregationMode_MODULE}.Verify())
+}
+
+// Metrics computed concurrently with appending of measurements match the ones computed from scratch
+func TestSessionData_ConcurrentAccess(t *testing.T) {
+	stubLogger := zerolog.New(os.Stderr).With().Timestamp().Logger()
+
+	cs := &schema.Callstack{Id: "cs", Frames: []*schema.StackFrame{{Name: "main.main", File: "main.go", Line: 1}}}
+	start := time.Now().Add(-2 * time.Minute)
+	mms := make([]*schema.Measurement, 240)
+	for i := range mms {
+		tstamp, err := ptypes.TimestampProto(start.Add(time.Duration(i) * 500 * time.Millisecond))
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		mms[i] = &schema.Measurement{
+			ObservedAt: tstamp,
+			Locations: []*schema.Location{
+				{MemoryUsage: &schema.MemoryUsage{AllocBytes: int64(i * i)}, Callstack: cs},
+			},
+		}
+	}
+
+	newContainer := func() *sessionData {
+		return newSessionData(
+			&stubLogger,
+			[]time.Duration{10 * time.Second, time.Minute},
+			newWorkerPool(context.Background(), 2),
+			&olsEstimator{},
+			time.Minute,
+		)
+	}
+
+	container := newContainer()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, mm := range mms {
+			assert.NoError(t, container.appendMeasurement(mm))
+		}
+	}()
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+			container.getSessionMetrics(Aggregation{})
+			container.getSessionMetrics(Aggregation{Mode: schema.AggregationMode_FUNCTION})
+		}
+	}
+
+	expected := newContainer()
+	for _, mm := range mms {
+		assert.NoError(t, expected.appendMeasurement(mm))
+	}
+
+	actualRates := container.getSessionMetrics(Aggregation{}).Locations[0].Rates
+	expectedRates := expected.getSessionMetrics(Aggregation{}).Locations[0].Rates
+	if assert.Len(t, actualRates, len(expectedRates)) {
+		for i := range expectedRates {
+			assert.InDelta(t, expectedRates[i].Values.AllocBytes, actualRates[i].Values.AllocBytes, 1e-6)
+		}
+	}
+}
+
+// Callstacks sharing common callers are merged into a single tree
+func TestSessionData_FlameGraph(t *testing.T) {
+	stubLogger := zerolog.New(os.Stderr).With().Timestamp().Logger()
+
+	var (
+		alloc = &schema.StackFrame{Name: "lib.Alloc", File: "lib.go", Line: 1}
+		put   = &schema.StackFrame{Name: "lib.Put", File: "lib.go", Line: 2}
+		serve = &schema.StackFrame{Name: "app.Serve", File: "app.go", Line: 3}
+		main  = &schema.StackFrame{Name: "main.main", File: "main.go", Line: 4}
+		cs1   = &schema.Callstack{Id: "cs1", Frames: []*schema.StackFrame{alloc, serve, main}}
+		cs2   = &schema.Callstack{Id: "cs2", Frames: []*schema.StackFrame{put, serve, main}}
+		cs3   = &schema.Callstack{Id: "cs3", Frames: []*schema.StackFrame{serve, main}}
+	)
+
+	// cs1 grows with a rate of 1 byte per second, the others are constant
+	start := time.Now().Add(-1 * 30 * time.Second)
+	step := 10 * time.Second
+	container := newSessionData(&stubLogger, []time.Duration{time.Minute}, newWorkerPool(context.Background(), 1), &olsEstimator{}, time.Minute)
+	var tstamps []time.Time
+	for i := 0; i < 4; i++ {
+		tstamps = append(tstamps, start.Add(time.Duration(i)*step))
+		tstamp, err := ptypes.TimestampProto(tstamps[i])
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		mm := &schema.Measurement{
+			ObservedAt: tstamp,
+			Locations: []*schema.Location{
+				{MemoryUsage: &schema.MemoryUsage{AllocBytes: 100 + int64(i)*10, AllocObjects: 1}, Callstack: cs1},
+				{MemoryUsage: &schema.MemoryUsage{AllocBytes: 50, AllocObjects: 2}, Callstack: cs2},
+				{MemoryUsage: &schema.MemoryUsage{AllocBytes: 8, AllocObjects: 3}, Callstack: cs3},
+			},
+		}
+		if !assert.NoError(t, container.appendMeasurement(mm)) {
+			t.FailNow()
+		}
+	}
+
+	t.Run("Latest", func(t *testing.T) {
+		fg, err := container.flameGraph(time.Time{}, time.Minute)
+		if !assert.NoError(t, err) {
+			return
+		}
+		observedAt, err := ptypes.Timestamp(fg.ObservedAt)
+		assert.NoError(t, err)
+		assert.True(t, tstamps[3].Equal(observedAt))
+
+		root := fg.Root
+		assert.Equal(t, int64(188), root.InUseBytes)
+		assert.Equal(t, int64(6), root.InUseObjects)
+		assert.InDelta(t, 1, root.InUseBytesRate, 1e-9)
+		if !assert.Len(t, root.Children, 1) {
+			return
+		}
+
+		serveNode := root.Children[0].Children[0]
+		assert.Equal(t, "app.Serve", serveNode.Frame.Name)
+		assert.Equal(t, int64(188), serveNode.InUseBytes)
+		if !assert.Len(t, serveNode.Children, 2) {
+			return
+		}
+		assert.Equal(t, "lib.Alloc", serveNode.Children[0].Frame.Name)
+		assert.Equal(t, int64(130), serveNode.Children[0].InUseBytes)
+		assert.InDelta(t, 1, serveNode.Children[0].InUseBytesRate, 1e-9)
+		assert.Equal(t, "lib.Put", serveNode.Children[1].Frame.Name)
+		assert.Equal(t, int64(50), serveNode.Children[1].InUseBytes)
+		assert.InDelta(t, 0, serveNode.Children[1].InUseBytesRate, 1e-9)
+
+		expectedFolded := "main.main;app.Serve 8\n" +
+			"main.main;app.Serve;lib.Alloc 130\n" +
+			"main.main;app.Serve;lib.Put 50\n"
+		assert.Equal(t, expectedFolded, fg.Folded)
+	})
+
+	t.Run("Historical", func(t *testing.T) {
+		fg, err := container.flameGraph(tstamps[1].Add(time.Second), time.Minute)
+		if !assert.NoError(t, err) {
+			return
+		}
+		observedAt, err := ptypes.Timestamp(fg.ObservedAt)
+		assert.NoError(t, err)
+		assert.True(t, tstamps[1].Equal(observedAt))
+		assert.Equal(t, int64(168), fg.Root.InUseBytes)
+	})
+}
+
+// Folded stacks start from the children of the root, nodes without own allocations are skipped
+func TestFlameGraphNode_Fold(t *testing.T) {
+	root := newFlameGraphNode("root", "")
+	root.selfInUseBytes = 1
+	caller := newFlameGraphNode("main.main", "main.go")
+	callee := newFlameGraphNode("lib.Alloc", "lib.go")
+	callee.selfInUseBytes = 64
+	caller.children[callee.name] = callee
+	root.children[caller.name] = caller
+
+	var sb strings.Builder
+	root.fold(nil, &sb)
+	assert.Equal(t, "main.main;lib.Alloc 64\n", sb.String())
+}
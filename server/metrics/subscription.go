@@ -2,6 +2,9 @@ package metrics
 
 import (
 	"context"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/memprofiler/memprofiler/schema"
 )
@@ -10,42 +13,117 @@ type subscriptionID = uint64
 
 var _ Subscription = (*defaultSubscription)(nil)
 
+// defaultSubscription delivers updates with latest-value-wins semantics:
+// publishing never blocks, and if subscriber is slower than the session data updates,
+// intermediate updates are coalesced (dropped) in favor of the most recent one
 type defaultSubscription struct {
 	sessionDescription *schema.SessionDescription  // helps to identify sessions
+	aggregation        Aggregation                 // defines how session locations are grouped
+	interval           time.Duration               // minimal time between two consecutive updates
 	id                 subscriptionID              // unique subscription id
 	updates            chan *schema.SessionMetrics // channel to push data to client
+	pending            chan struct{}               // signals that there is unhandled update
+	data               *sessionData                // the most recent published session data
+	dataMutex          sync.Mutex                  // synchronizes access to data
+	dropped            uint64                      // number of coalesced updates
 	dispatcher         dispatcher                  // subscription dispatcher
 	ctx                context.Context             // subscription context
+	done               chan struct{}               // closed when subscription is terminated
+	closeOnce          sync.Once
 }
 
 func (s *defaultSubscription) Updates() <-chan *schema.SessionMetrics { return s.updates }
 
+func (s *defaultSubscription) Dropped() uint64 { return atomic.LoadUint64(&s.dropped) }
+
 func (s *defaultSubscription) Unsubscribe() {
 	s.dispatcher.dropSubscription(s.sessionDescription, s.id)
 }
 
-func (s *defaultSubscription) publish(msg *schema.SessionMetrics) {
+func (s *defaultSubscription) publish(data *sessionData) {
+	s.dataMutex.Lock()
+	s.data = data
+	s.dataMutex.Unlock()
+
 	select {
-	case s.updates <- msg:
-	case <-s.ctx.Done():
+	case s.pending <- struct{}{}:
+	default:
+		// previous update hasn't been handled yet, it will be replaced with the current one
+		atomic.AddUint64(&s.dropped, 1)
 	}
 }
 
-func (s *defaultSubscription) close() { close(s.updates) }
+func (s *defaultSubscription) close() { s.closeOnce.Do(func() { close(s.done) }) }
+
+// loop computes session metrics for the most recent update and pushes them to the subscriber
+func (s *defaultSubscription) loop() {
+	defer close(s.updates)
+
+	var last time.Time
+	for {
+		select {
+		case <-s.pending:
+		case <-s.ctx.Done():
+			return
+		case <-s.done:
+			return
+		}
+
+		// updates published until the interval is over are coalesced
+		if delay := s.interval - time.Since(last); delay > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-s.ctx.Done():
+				timer.Stop()
+				return
+			case <-s.done:
+				timer.Stop()
+				return
+			}
+			select {
+			case <-s.pending:
+				atomic.AddUint64(&s.dropped, 1)
+			default:
+			}
+		}
+		last = time.Now()
 
-const updatesChanCapacity = 256
+		s.dataMutex.Lock()
+		data := s.data
+		s.dataMutex.Unlock()
+
+		msg := data.getSessionMetrics(s.aggregation)
+
+		select {
+		case s.updates <- msg:
+		case <-s.ctx.Done():
+			return
+		case <-s.done:
+			return
+		}
+	}
+}
 
 func newSubscription(
 	ctx context.Context,
 	id subscriptionID,
 	sessionDescription *schema.SessionDescription,
+	aggregation Aggregation,
+	interval time.Duration,
 	dispatcher dispatcher,
 ) Subscription {
-	return &defaultSubscription{
+	s := &defaultSubscription{
 		ctx:                ctx,
 		sessionDescription: sessionDescription,
+		aggregation:        aggregation,
+		interval:           interval,
 		id:                 id,
 		dispatcher:         dispatcher,
-		updates:            make(chan *schema.SessionMetrics, updatesChanCapacity),
+		updates:            make(chan *schema.SessionMetrics),
+		pending:            make(chan struct{}, 1),
+		done:               make(chan struct{}),
 	}
+	go s.loop()
+	return s
 }
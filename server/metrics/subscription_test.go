@@ -0,0 +1,122 @@
+package metrics
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/memprofiler/memprofiler/schema"
+)
+
+// Slow subscriber must not block the publisher and receives only the most recent update
+func TestSubscription_SlowConsumer(t *testing.T) {
+	stubLogger := zerolog.New(os.Stderr).With().Timestamp().Logger()
+
+	sd := &schema.SessionDescription{
+		InstanceDescription: &schema.InstanceDescription{ServiceName: "service", InstanceName: "instance"},
+		Id:                  1,
+	}
+	cs := &schema.Callstack{Id: "abcd", Frames: []*schema.StackFrame{{Name: "a", File: "a.go", Line: 1}}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	d := newDispatcher()
+	subscription := d.createSubscription(ctx, sd, Aggregation{}, 0)
+
+	// publish a lot of updates without reading them
+	const updates = 1000
+	data := newSessionData(&stubLogger, []time.Duration{time.Minute}, newWorkerPool(context.Background(), 1), &olsEstimator{}, time.Minute)
+	start := time.Now().Add(-1 * time.Minute)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < updates; i++ {
+			tstamp, err := ptypes.TimestampProto(start.Add(time.Duration(i) * time.Millisecond))
+			if !assert.NoError(t, err) {
+				return
+			}
+			mm := &schema.Measurement{
+				ObservedAt: tstamp,
+				Locations:  []*schema.Location{{MemoryUsage: &schema.MemoryUsage{AllocObjects: int64(i)}, Callstack: cs}},
+			}
+			if !assert.NoError(t, data.appendMeasurement(mm)) {
+				return
+			}
+			d.broadcast(sd, data)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		assert.FailNow(t, "publisher is blocked by subscriber")
+	}
+
+	// subscriber receives at most one outdated message, and then the most recent one
+	var msg *schema.SessionMetrics
+	for i := 0; i < 2; i++ {
+		select {
+		case msg = <-subscription.Updates():
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	if assert.NotNil(t, msg) && assert.Len(t, msg.Locations, 1) {
+		assert.Equal(t, data.getSessionMetrics(Aggregation{}), msg)
+	}
+	assert.True(t, subscription.Dropped() >= updates-2)
+
+	// unsubscribe closes updates channel
+	subscription.Unsubscribe()
+	_, ok := <-subscription.Updates()
+	assert.False(t, ok)
+}
+
+// Subscriber doesn't receive updates more often than it has requested
+func TestSubscription_Interval(t *testing.T) {
+	stubLogger := zerolog.New(os.Stderr).With().Timestamp().Logger()
+
+	sd := &schema.SessionDescription{
+		InstanceDescription: &schema.InstanceDescription{ServiceName: "service", InstanceName: "instance"},
+		Id:                  1,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const interval = 200 * time.Millisecond
+	d := newDispatcher()
+	subscription := d.createSubscription(ctx, sd, Aggregation{}, interval)
+	defer subscription.Unsubscribe()
+
+	data := newSessionData(&stubLogger, []time.Duration{time.Minute}, newWorkerPool(context.Background(), 1), &olsEstimator{}, time.Minute)
+	receive := func() time.Time {
+		select {
+		case <-subscription.Updates():
+		case <-time.After(5 * time.Second):
+			assert.FailNow(t, "update is not received")
+		}
+		return time.Now()
+	}
+
+	// the first update is sent immediately, the following ones are coalesced until the interval is over
+	d.broadcast(sd, data)
+	first := receive()
+	for i := 0; i < 10; i++ {
+		d.broadcast(sd, data)
+	}
+	second := receive()
+	assert.True(t, second.Sub(first) >= interval*3/4)
+
+	select {
+	case <-subscription.Updates():
+		assert.Fail(t, "coalesced updates are sent")
+	case <-time.After(2 * interval):
+	}
+	assert.Equal(t, uint64(10-1), subscription.Dropped())
+}
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+// throttle limits the frequency of an action: if the action is called too often,
+// it's postponed until the interval is over, and the calls made meanwhile are coalesced
+type throttle struct {
+	interval  time.Duration
+	last      time.Time // the time of the latest action run
+	scheduled bool      // if action is already postponed
+	mutex     sync.Mutex
+}
+
+// run executes action immediately or schedules it for later execution
+func (t *throttle) run(action func()) {
+	t.mutex.Lock()
+
+	if t.scheduled {
+		t.mutex.Unlock()
+		return
+	}
+
+	delay := t.interval - time.Since(t.last)
+	if delay > 0 {
+		t.scheduled = true
+		t.mutex.Unlock()
+		time.AfterFunc(delay, func() {
+			t.mutex.Lock()
+			t.scheduled = false
+			t.last = time.Now()
+			t.mutex.Unlock()
+			action()
+		})
+		return
+	}
+
+	t.last = time.Now()
+	t.mutex.Unlock()
+	action()
+}
+
+func newThrottle(interval time.Duration) *throttle {
+	return &throttle{interval: interval}
+}
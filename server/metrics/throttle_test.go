@@ -0,0 +1,26 @@
+package metrics
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThrottle_Coalescing(t *testing.T) {
+	var (
+		counter  int32
+		throttle = newThrottle(100 * time.Millisecond)
+		action   = func() { atomic.AddInt32(&counter, 1) }
+	)
+
+	// the first call is executed immediately, the rest are postponed and coalesced
+	for i := 0; i < 100; i++ {
+		throttle.run(action)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&counter))
+
+	time.Sleep(300 * time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&counter))
+}
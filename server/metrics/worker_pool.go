@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+)
+
+// workerPool is a fixed set of goroutines shared by all sessions,
+// so that the CPU consumption of metrics computation is bounded
+// regardless of the number of sessions
+type workerPool struct {
+	tasks chan func()
+	wg    sync.WaitGroup
+	ctx   context.Context
+}
+
+// execute runs tasks within the pool and waits for their completion;
+// if the pool is stopped, remaining tasks are executed in the calling goroutine
+func (p *workerPool) execute(tasks []func()) {
+	var wg sync.WaitGroup
+	wg.Add(len(tasks))
+
+	for _, task := range tasks {
+		task := task
+		wrapped := func() {
+			defer wg.Done()
+			task()
+		}
+		select {
+		case p.tasks <- wrapped:
+		case <-p.ctx.Done():
+			wrapped()
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-p.ctx.Done():
+		// workers may have been stopped before queued tasks were taken
+		p.drain()
+		<-done
+	}
+}
+
+// drain executes the queued tasks in the calling goroutine
+func (p *workerPool) drain() {
+	for {
+		select {
+		case task := <-p.tasks:
+			task()
+		default:
+			return
+		}
+	}
+}
+
+func (p *workerPool) loop() {
+	defer p.wg.Done()
+	for {
+		select {
+		case task := <-p.tasks:
+			task()
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
+
+// wait blocks until all workers are stopped (after the context is canceled)
+func (p *workerPool) wait() { p.wg.Wait() }
+
+func newWorkerPool(ctx context.Context, workers int) *workerPool {
+	p := &workerPool{
+		tasks: make(chan func(), workers),
+		ctx:   ctx,
+	}
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.loop()
+	}
+	return p
+}
@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Tasks submitted after the pool is stopped are executed anyway
+func TestWorkerPool_Stopped(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	pool := newWorkerPool(ctx, 2)
+	cancel()
+	pool.wait()
+
+	var executed int32
+	tasks := make([]func(), 100)
+	for i := range tasks {
+		tasks[i] = func() { atomic.AddInt32(&executed, 1) }
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pool.execute(tasks)
+	}()
+
+	select {
+	case <-done:
+		assert.Equal(t, int32(len(tasks)), atomic.LoadInt32(&executed))
+	case <-time.After(10 * time.Second):
+		t.Fatal("tasks haven't been executed")
+	}
+}
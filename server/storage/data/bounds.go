@@ -0,0 +1,84 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+
+	"github.com/memprofiler/memprofiler/schema"
+)
+
+// InRange checks if measurement is observed within [from, to] time range; zero values mean no bound
+func InRange(observedAt *timestamp.Timestamp, from, to time.Time) bool {
+	t, err := ptypes.Timestamp(observedAt)
+	if err != nil {
+		return false
+	}
+	return (from.IsZero() || !t.Before(from)) && (to.IsZero() || !t.After(to))
+}
+
+// BoundsCollector accumulates session bounds from measurements
+type BoundsCollector struct {
+	first, last *timestamp.Timestamp
+	callstacks  map[string]struct{}
+}
+
+// Add takes measurement into account
+func (c *BoundsCollector) Add(mm *schema.Measurement) {
+	if c.first == nil {
+		c.first = mm.GetObservedAt()
+	}
+	c.last = mm.GetObservedAt()
+	for _, location := range mm.GetLocations() {
+		c.AddCallstack(location.GetCallstack().GetId())
+	}
+}
+
+// AddCallstack takes callstack into account
+func (c *BoundsCollector) AddCallstack(id string) {
+	if c.callstacks == nil {
+		c.callstacks = make(map[string]struct{})
+	}
+	c.callstacks[id] = struct{}{}
+}
+
+// SetFirst sets the time of the first measurement
+func (c *BoundsCollector) SetFirst(observedAt *timestamp.Timestamp) { c.first = observedAt }
+
+// Bounds returns collected bounds; nil means there were no measurements
+func (c *BoundsCollector) Bounds() *schema.SessionBounds {
+	if c.first == nil {
+		return nil
+	}
+	return &schema.SessionBounds{
+		FirstObservedAt: c.first,
+		LastObservedAt:  c.last,
+		Callstacks:      uint32(len(c.callstacks)),
+	}
+}
+
+// ScanBounds reads all session measurements to find session bounds
+func ScanBounds(ctx context.Context, loader Loader) (*schema.SessionBounds, error) {
+	// stop loading if an error occurs
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results, err := loader.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var collector BoundsCollector
+	for result := range results {
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		collector.Add(result.Measurement)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return collector.Bounds(), nil
+}
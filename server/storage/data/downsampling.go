@@ -0,0 +1,172 @@
+package data
+
+import (
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+
+	"github.com/memprofiler/memprofiler/schema"
+	"github.com/memprofiler/memprofiler/server/config"
+)
+
+// tierResolution returns the resolution required for a measurement of a given age;
+// zero value means that measurement is kept as is
+func tierResolution(tiers []config.DownsamplingTier, age time.Duration) time.Duration {
+	var result time.Duration
+	for _, tier := range tiers {
+		if age > tier.After {
+			result = tier.Resolution
+		}
+	}
+	return result
+}
+
+// DownsamplingCut returns the moment of time measurements before which are downsampled
+func DownsamplingCut(tiers []config.DownsamplingTier, now time.Time) time.Time {
+	return now.Add(-1 * tiers[0].After)
+}
+
+// Downsample merges measurements (sorted by time) falling into the same time bucket,
+// which size is defined by the measurement age; every merged location keeps
+// the last, the minimal and the maximal memory usage values within the bucket.
+// Downsampled measurements may be downsampled again into coarser resolution.
+func Downsample(
+	mms []*schema.Measurement,
+	tiers []config.DownsamplingTier,
+	now time.Time,
+) ([]*schema.Measurement, error) {
+
+	type bucket struct {
+		resolution time.Duration
+		start      time.Time
+	}
+
+	var (
+		result  []*schema.Measurement
+		current bucket
+		group   []*schema.Measurement
+	)
+
+	flush := func() error {
+		if len(group) == 0 {
+			return nil
+		}
+		if current.resolution == 0 {
+			result = append(result, group...)
+		} else {
+			result = append(result, mergeMeasurements(group, current.resolution))
+		}
+		group = group[:0]
+		return nil
+	}
+
+	for _, mm := range mms {
+		observedAt, err := ptypes.Timestamp(mm.GetObservedAt())
+		if err != nil {
+			return nil, err
+		}
+
+		resolution := tierResolution(tiers, now.Sub(observedAt))
+		if mm.GetResolution() != nil {
+			previous, err := ptypes.Duration(mm.GetResolution())
+			if err != nil {
+				return nil, err
+			}
+			if previous > resolution {
+				resolution = previous
+			}
+		}
+
+		key := bucket{resolution: resolution}
+		if resolution > 0 {
+			key.start = observedAt.Truncate(resolution)
+		} else {
+			// raw measurements are never merged
+			key.start = observedAt
+		}
+
+		if key != current {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			current = key
+		}
+		group = append(group, mm)
+	}
+
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// mergeMeasurements builds single downsampled measurement from a group of measurements
+func mergeMeasurements(group []*schema.Measurement, resolution time.Duration) *schema.Measurement {
+	var (
+		result = &schema.Measurement{
+			ObservedAt: group[len(group)-1].GetObservedAt(),
+			Resolution: ptypes.DurationProto(resolution),
+		}
+		locations = make(map[string]*schema.Location)
+	)
+
+	for _, mm := range group {
+		for _, location := range mm.GetLocations() {
+			var (
+				id      = location.GetCallstack().GetId()
+				minimum = location.GetMinMemoryUsage()
+				maximum = location.GetMaxMemoryUsage()
+			)
+			if minimum == nil {
+				minimum = location.GetMemoryUsage()
+			}
+			if maximum == nil {
+				maximum = location.GetMemoryUsage()
+			}
+
+			merged, exists := locations[id]
+			if !exists {
+				merged = &schema.Location{
+					Callstack:      location.GetCallstack(),
+					MemoryUsage:    location.GetMemoryUsage(),
+					MinMemoryUsage: copyMemoryUsage(minimum),
+					MaxMemoryUsage: copyMemoryUsage(maximum),
+				}
+				locations[id] = merged
+				result.Locations = append(result.Locations, merged)
+				continue
+			}
+
+			merged.MemoryUsage = location.GetMemoryUsage()
+			updateMemoryUsage(merged.MinMemoryUsage, minimum, func(a, b int64) bool { return a < b })
+			updateMemoryUsage(merged.MaxMemoryUsage, maximum, func(a, b int64) bool { return a > b })
+		}
+	}
+	return result
+}
+
+func copyMemoryUsage(mu *schema.MemoryUsage) *schema.MemoryUsage {
+	return &schema.MemoryUsage{
+		AllocObjects: mu.GetAllocObjects(),
+		AllocBytes:   mu.GetAllocBytes(),
+		FreeObjects:  mu.GetFreeObjects(),
+		FreeBytes:    mu.GetFreeBytes(),
+	}
+}
+
+// updateMemoryUsage replaces the fields of dst with the fields of src if they are better
+func updateMemoryUsage(dst, src *schema.MemoryUsage, better func(a, b int64) bool) {
+	for _, field := range []struct {
+		dst *int64
+		src int64
+	}{
+		{&dst.AllocObjects, src.GetAllocObjects()},
+		{&dst.AllocBytes, src.GetAllocBytes()},
+		{&dst.FreeObjects, src.GetFreeObjects()},
+		{&dst.FreeBytes, src.GetFreeBytes()},
+	} {
+		if better(field.src, *field.dst) {
+			*field.dst = field.src
+		}
+	}
+}
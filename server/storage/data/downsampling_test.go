@@ -0,0 +1,66 @@
+package data
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/memprofiler/memprofiler/schema"
+	"github.com/memprofiler/memprofiler/server/config"
+)
+
+func TestDownsample(t *testing.T) {
+	var (
+		cs    = &schema.Callstack{Id: "1"}
+		now   = time.Unix(36000, 0)
+		tiers = []config.DownsamplingTier{
+			{After: time.Hour, Resolution: time.Minute},
+			{After: 2 * time.Hour, Resolution: 10 * time.Minute},
+		}
+		mms []*schema.Measurement
+	)
+
+	// a measurement every 10 seconds during the last 3 hours, values oscillate
+	for i := 0; i < 3*360; i++ {
+		mms = append(mms, &schema.Measurement{
+			ObservedAt: &timestamp.Timestamp{Seconds: now.Unix() - 3*3600 + int64(i*10)},
+			Locations: []*schema.Location{
+				{Callstack: cs, MemoryUsage: &schema.MemoryUsage{AllocBytes: int64(i + 100*(i%2))}},
+			},
+		})
+	}
+
+	downsampled, err := Downsample(mms, tiers, now)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// the oldest hour is split into 10-minute buckets, the next one into 1-minute buckets,
+	// and the last hour is kept as is
+	assert.Len(t, downsampled, 6+60+360)
+
+	first := downsampled[0]
+	assert.Equal(t, ptypes.DurationProto(10*time.Minute), first.Resolution)
+	assert.Equal(t, mms[59].ObservedAt, first.ObservedAt)
+	assert.Equal(t, int64(159), first.Locations[0].MemoryUsage.AllocBytes)
+	assert.Equal(t, int64(0), first.Locations[0].MinMemoryUsage.AllocBytes)
+	assert.Equal(t, int64(159), first.Locations[0].MaxMemoryUsage.AllocBytes)
+	assert.Equal(t, ptypes.DurationProto(time.Minute), downsampled[6].Resolution)
+	assert.Nil(t, downsampled[66].Resolution)
+	assert.Equal(t, mms[720], downsampled[66])
+
+	// downsampling is idempotent
+	again, err := Downsample(downsampled, tiers, now)
+	assert.NoError(t, err)
+	assert.Equal(t, downsampled, again)
+
+	// time passes, so measurements get into coarser buckets; min and max values are preserved
+	later, err := Downsample(downsampled, tiers, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, later, 12+60)
+	assert.Equal(t, int64(0), later[0].Locations[0].MinMemoryUsage.AllocBytes)
+	assert.Equal(t, int64(159), later[0].Locations[0].MaxMemoryUsage.AllocBytes)
+}
@@ -0,0 +1,64 @@
+package filesystem
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/memprofiler/memprofiler/schema"
+	"github.com/memprofiler/memprofiler/server/storage/data"
+)
+
+// SessionBounds takes the first record time and callstacks from index,
+// so only the records following the last indexed one and downsampled measurements are read
+func (s *storage) SessionBounds(ctx context.Context, sessionDesc *schema.SessionDescription) (*schema.SessionBounds, error) {
+	filename := s.sessionDataFile(s.instanceDir(sessionDesc.InstanceDescription), sessionDesc.Id)
+
+	files, err := s.openSessionFiles(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer func() { _ = files.fd.Close() }()
+
+	// downsampled measurements precede the ones kept in data file
+	var collector data.BoundsCollector
+	for _, mm := range files.downsampled {
+		collector.Add(mm)
+	}
+
+	if idx := files.index; idx != nil && idx.tail() > 0 {
+		if _, err := files.fd.Seek(idx.tail(), io.SeekStart); err != nil {
+			return nil, errors.Wrap(err, "seek data file")
+		}
+		files.reader.Reset(files.fd)
+		if len(files.downsampled) == 0 {
+			collector.SetFirst(idx.first())
+		}
+		for id := range idx.callstacks {
+			collector.AddCallstack(id)
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		mm := &schema.Measurement{}
+		if err := files.decoder.decode(mm); err != nil {
+			// the last record of a live session may be incomplete yet
+			if err == io.EOF || err == errTornRecord {
+				return collector.Bounds(), nil
+			}
+			return nil, errors.Wrap(err, "decode record")
+		}
+		collector.Add(mm)
+	}
+}
@@ -1,36 +1,551 @@
 package filesystem
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
 	"io"
 
 	"github.com/gogo/protobuf/jsonpb"
 	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/memprofiler/memprofiler/server/config"
 )
 
-// codec is responsible for serialization-deserialization routines
+// maxRecordSize limits the size of a single measurement record
+const maxRecordSize = 64 * 1024 * 1024
+
+var (
+	// errTornRecord means that the last record was written partially (because of a crash)
+	errTornRecord = errors.New("torn record")
+	// errChecksumMismatch means that record content is damaged
+	errChecksumMismatch = errors.New("checksum mismatch")
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+const checksumSize = crc32.Size
+
+// codec is responsible for serialization-deserialization routines;
+// it also defines how records are delimited within a data file
 type codec interface {
-	encode(io.Writer, proto.Message) error
-	decode(io.Reader, proto.Message) error
+	// header describes the codec in the beginning of a data file
+	header() fileHeader
+	// newEncoder returns encoder writing records to a data file
+	newEncoder(io.Writer) encoder
+	// newDecoder returns decoder reading records from a data file
+	newDecoder(*bufio.Reader) decoder
+}
+
+// encoder writes records of a single data file
+type encoder interface {
+	// encode writes a single record
+	encode(proto.Message) error
+	// seekable reports if the last written record can be read without the preceding ones,
+	// so index may refer it
+	seekable() bool
 }
 
-// jsonCodec represents structs in JSON format
+// decoder reads records of a single data file
+type decoder interface {
+	// decode reads a single record; io.EOF means there are no more records
+	decode(proto.Message) error
+	// seekable reports if the last read record can be read without the preceding ones
+	seekable() bool
+}
+
+// plainEncoder writes records that are independent from each other
+type plainEncoder struct {
+	w            io.Writer
+	encodeRecord func(io.Writer, proto.Message) error
+}
+
+func (e *plainEncoder) encode(v proto.Message) error { return e.encodeRecord(e.w, v) }
+
+func (e *plainEncoder) seekable() bool { return true }
+
+// plainDecoder reads records that are independent from each other
+type plainDecoder struct {
+	r            *bufio.Reader
+	decodeRecord func(*bufio.Reader, proto.Message) error
+}
+
+func (d *plainDecoder) decode(v proto.Message) error { return d.decodeRecord(d.r, v) }
+
+func (d *plainDecoder) seekable() bool { return true }
+
+// jsonCodec represents structs in JSON format, one record per line;
+// if checksums are enabled, the line ends with tab and hex-encoded checksum of JSON
 type jsonCodec struct {
 	marshaller *jsonpb.Marshaler
+	checksums  bool
+}
+
+func (c *jsonCodec) header() fileHeader {
+	return fileHeader{version: c.version(), format: jsonFormatCode}
+}
+
+func (c *jsonCodec) version() byte {
+	if c.checksums {
+		return fileHeaderVersion
+	}
+	return fileHeaderVersionNoChecksums
+}
+
+func (c *jsonCodec) newEncoder(w io.Writer) encoder {
+	return &plainEncoder{w: w, encodeRecord: c.encode}
+}
+
+func (c *jsonCodec) newDecoder(r *bufio.Reader) decoder {
+	return &plainDecoder{r: r, decodeRecord: c.decode}
 }
 
 func (c *jsonCodec) encode(w io.Writer, v proto.Message) error {
-	return c.marshaller.Marshal(w, v)
+	var buf bytes.Buffer
+	if err := c.marshaller.Marshal(&buf, v); err != nil {
+		return err
+	}
+	if c.checksums {
+		var checksum [checksumSize]byte
+		binary.BigEndian.PutUint32(checksum[:], crc32.Checksum(buf.Bytes(), crcTable))
+		buf.WriteByte('\t')
+		buf.WriteString(hex.EncodeToString(checksum[:]))
+	}
+	buf.WriteByte('\n')
+
+	// the whole record is written at once, so the only possible damage is a torn tail
+	_, err := w.Write(buf.Bytes())
+	return err
 }
 
-func (c *jsonCodec) decode(r io.Reader, v proto.Message) error {
-	return jsonpb.Unmarshal(r, v)
+func (c *jsonCodec) decode(r *bufio.Reader, v proto.Message) error {
+	for {
+		// lines are not limited in size, unlike the ones read with bufio.Scanner
+		line, err := r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if len(line) > maxRecordSize {
+			return fmt.Errorf("record is too large: %d bytes", len(line))
+		}
+		// legacy files start with empty line
+		if len(bytes.TrimSpace(line)) == 0 {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if !c.checksums {
+			if jsonErr := jsonpb.Unmarshal(bytes.NewReader(line), v); jsonErr != nil {
+				// only the last record may be written partially
+				if err == io.EOF {
+					return errTornRecord
+				}
+				return jsonErr
+			}
+			return nil
+		}
+
+		// record must be terminated with a line break
+		if err == io.EOF {
+			return errTornRecord
+		}
+		// the line is complete, so the record without checksum is damaged rather than torn
+		line = line[:len(line)-1]
+		separator := bytes.LastIndexByte(line, '\t')
+		if separator < 0 || hex.DecodedLen(len(line)-separator-1) != checksumSize {
+			return errChecksumMismatch
+		}
+		var checksum [checksumSize]byte
+		if _, err := hex.Decode(checksum[:], line[separator+1:]); err != nil {
+			return errChecksumMismatch
+		}
+		if binary.BigEndian.Uint32(checksum[:]) != crc32.Checksum(line[:separator], crcTable) {
+			return errChecksumMismatch
+		}
+		return jsonpb.Unmarshal(bytes.NewReader(line[:separator]), v)
+	}
 }
 
-func newJSONCodec() codec {
+func newJSONCodec(checksums bool) codec {
 	return &jsonCodec{
 		marshaller: &jsonpb.Marshaler{
 			EnumsAsInts:  true,
 			EmitDefaults: true,
 		},
+		checksums: checksums,
+	}
+}
+
+// binaryCodec represents structs as varint length-prefixed protobuf messages,
+// which are compressed in blocks if compression is enabled (see blockEncoder);
+// if checksums are enabled, every message is followed by the checksum of its (compressed) bytes
+type binaryCodec struct {
+	compression config.FilesystemCompression
+	checksums   bool
+}
+
+func (c *binaryCodec) header() fileHeader {
+	h := fileHeader{version: fileHeaderVersionNoChecksums, format: binaryFormatCode}
+	if c.checksums {
+		h.version = fileHeaderVersion
+	}
+	if c.compression == config.GzipCompression {
+		h.compression = gzipCompressionCode
+	}
+	return h
+}
+
+func (c *binaryCodec) newEncoder(w io.Writer) encoder {
+	if c.compression == config.GzipCompression {
+		return &blockEncoder{w: w, checksums: c.checksums}
+	}
+	return &plainEncoder{w: w, encodeRecord: c.encode}
+}
+
+func (c *binaryCodec) newDecoder(r *bufio.Reader) decoder {
+	if c.compression == config.GzipCompression {
+		return &blockDecoder{r: r, checksums: c.checksums}
+	}
+	return &plainDecoder{r: r, decodeRecord: c.decode}
+}
+
+func (c *binaryCodec) encode(w io.Writer, v proto.Message) error {
+	payload, err := proto.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return writeFrame(w, payload, c.checksums)
+}
+
+func (c *binaryCodec) decode(r *bufio.Reader, v proto.Message) error {
+	payload, err := readFrame(r, c.checksums)
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(payload, v)
+}
+
+// writeFrame writes varint length-prefixed payload, which is followed by its checksum if required
+func writeFrame(w io.Writer, payload []byte, checksums bool) error {
+	buf := make([]byte, binary.MaxVarintLen64, binary.MaxVarintLen64+len(payload)+checksumSize)
+	n := binary.PutUvarint(buf, uint64(len(payload)))
+	buf = append(buf[:n], payload...)
+	if checksums {
+		var checksum [checksumSize]byte
+		binary.BigEndian.PutUint32(checksum[:], crc32.Checksum(payload, crcTable))
+		buf = append(buf, checksum[:]...)
+	}
+
+	// the whole frame is written at once, so the only possible damage is a torn tail
+	_, err := w.Write(buf)
+	return err
+}
+
+// readFrame reads payload written with writeFrame
+func readFrame(r *bufio.Reader, checksums bool) ([]byte, error) {
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		if err == io.ErrUnexpectedEOF {
+			return nil, errTornRecord
+		}
+		return nil, fmt.Errorf("read record size: %v", err)
+	}
+	if size > maxRecordSize {
+		return nil, fmt.Errorf("record is too large: %d bytes", size)
+	}
+
+	if checksums {
+		size += checksumSize
+	}
+	payload := make([]byte, size)
+	if _, err = io.ReadFull(r, payload); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, errTornRecord
+		}
+		return nil, fmt.Errorf("read record: %v", err)
+	}
+	if checksums {
+		checksum := payload[len(payload)-checksumSize:]
+		payload = payload[:len(payload)-checksumSize]
+		if binary.BigEndian.Uint32(checksum) != crc32.Checksum(payload, crcTable) {
+			return nil, errChecksumMismatch
+		}
+	}
+	return payload, nil
+}
+
+// Compressed binary files consist of blocks of up to maxBlockRecords records, which are compressed
+// as a single gzip stream, so compression benefits from similarity of consecutive measurements.
+// The stream is flushed after every record, and the compressed bytes of each record are written
+// as a separate frame, so records are readable as soon as they're written, and a torn tail
+// damages the last record only. The first byte of frame tells if the record starts a block;
+// only such records can be read without the preceding ones, so index refers nothing else.
+
+const (
+	// maxBlockRecords limits the number of records in a block; block is not longer than index interval,
+	// so seeking never makes loader to decompress more than one extra block
+	maxBlockRecords = indexInterval
+	// maxBlockSize limits the uncompressed size of a block
+	maxBlockSize = 1024 * 1024
+)
+
+const (
+	blockContinuationFrame byte = 0
+	blockStartFrame        byte = 1
+)
+
+// blockEncoder writes records compressed in blocks
+type blockEncoder struct {
+	w         io.Writer
+	checksums bool
+	zw        *gzip.Writer
+	buf       bytes.Buffer // compressed bytes of the last record
+	open      bool         // the last block is not finished yet
+	started   bool         // the last record has started a block
+	records   int          // number of records in the open block
+	size      int          // uncompressed size of the open block
+}
+
+func (e *blockEncoder) encode(v proto.Message) error {
+	payload, err := proto.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	e.buf.Reset()
+	e.started = !e.open
+	if e.started {
+		e.buf.WriteByte(blockStartFrame)
+		if e.zw == nil {
+			e.zw = gzip.NewWriter(&e.buf)
+		} else {
+			e.zw.Reset(&e.buf)
+		}
+		e.open, e.records, e.size = true, 0, 0
+	} else {
+		e.buf.WriteByte(blockContinuationFrame)
+	}
+
+	var size [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(size[:], uint64(len(payload)))
+	if _, err := e.zw.Write(size[:n]); err != nil {
+		return err
+	}
+	if _, err := e.zw.Write(payload); err != nil {
+		return err
+	}
+	e.records++
+	e.size += n + len(payload)
+
+	// full block is finished, otherwise the stream is flushed to make the record readable
+	if e.records >= maxBlockRecords || e.size >= maxBlockSize {
+		e.open = false
+		err = e.zw.Close()
+	} else {
+		err = e.zw.Flush()
+	}
+	if err != nil {
+		return err
+	}
+	if err := writeFrame(e.w, e.buf.Bytes(), e.checksums); err != nil {
+		// the following records can't continue the block whose part is lost
+		e.open = false
+		return err
+	}
+	return nil
+}
+
+func (e *blockEncoder) seekable() bool { return e.started }
+
+// blockDecoder reads records compressed in blocks
+type blockDecoder struct {
+	r         *bufio.Reader
+	checksums bool
+	zr        *gzip.Reader
+	buf       bytes.Buffer // compressed bytes of the open block that haven't been decompressed yet
+	open      bool         // the beginning of the current block has been read
+	started   bool         // the last record has started a block
+}
+
+func (d *blockDecoder) decode(v proto.Message) error {
+	frame, err := readFrame(d.r, d.checksums)
+	if err != nil {
+		return err
+	}
+	if len(frame) == 0 {
+		return fmt.Errorf("empty frame")
+	}
+
+	switch frame[0] {
+	case blockStartFrame:
+		d.started = true
+		d.buf.Reset()
+		d.buf.Write(frame[1:])
+		if d.zr == nil {
+			d.zr, err = gzip.NewReader(&d.buf)
+		} else {
+			err = d.zr.Reset(&d.buf)
+		}
+		if err != nil {
+			d.open = false
+			return fmt.Errorf("decompress record: %v", err)
+		}
+		d.open = true
+	case blockContinuationFrame:
+		d.started = false
+		// e.g. if reading has started with a wrong offset
+		if !d.open {
+			return fmt.Errorf("record continues unknown block")
+		}
+		d.buf.Write(frame[1:])
+	default:
+		return fmt.Errorf("unknown frame type %d", frame[0])
+	}
+
+	// the stream has been flushed after the record, so it's decompressed without the following records
+	payload, err := d.decompress()
+	if err != nil {
+		d.open = false
+		return fmt.Errorf("decompress record: %v", err)
+	}
+	return proto.Unmarshal(payload, v)
+}
+
+func (d *blockDecoder) decompress() ([]byte, error) {
+	size, err := binary.ReadUvarint(byteReader{d.zr})
+	if err != nil {
+		return nil, err
+	}
+	if size > maxRecordSize {
+		return nil, fmt.Errorf("record is too large: %d bytes", size)
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(d.zr, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+func (d *blockDecoder) seekable() bool { return d.started }
+
+// byteReader reads bytes one by one, so it never consumes more than required
+type byteReader struct {
+	r io.Reader
+}
+
+func (r byteReader) ReadByte() (byte, error) {
+	var b [1]byte
+	_, err := io.ReadFull(r.r, b[:])
+	return b[0], err
+}
+
+func newBinaryCodec(compression config.FilesystemCompression, checksums bool) codec {
+	return &binaryCodec{compression: compression, checksums: checksums}
+}
+
+// newCodec builds codec for new data files; they always contain checksums
+func newCodec(cfg *config.FilesystemStorageConfig) codec {
+	if cfg.Format == config.BinaryFormat {
+		return newBinaryCodec(cfg.Compression, true)
+	}
+	return newJSONCodec(true)
+}
+
+// fileMagic starts every data file having a header;
+// files without it are newline-delimited JSON files written by older versions
+var fileMagic = []byte("MPRF")
+
+const (
+	// fileHeaderVersionNoChecksums is used by files without record checksums
+	fileHeaderVersionNoChecksums byte = 1
+	// fileHeaderVersion is used by files with record checksums
+	fileHeaderVersion byte = 2
+)
+
+const (
+	jsonFormatCode   byte = 1
+	binaryFormatCode byte = 2
+)
+
+const (
+	noCompressionCode   byte = 0
+	gzipCompressionCode byte = 1
+)
+
+// fileHeaderSize is a size of magic, version, format and compression bytes
+var fileHeaderSize = len(fileMagic) + 3
+
+// fileHeader describes data file format
+type fileHeader struct {
+	version     byte
+	format      byte
+	compression byte
+}
+
+func (h fileHeader) codec() (codec, error) {
+	var checksums bool
+	switch h.version {
+	case fileHeaderVersionNoChecksums:
+	case fileHeaderVersion:
+		checksums = true
+	default:
+		return nil, fmt.Errorf("unsupported header version %d", h.version)
+	}
+
+	switch h.format {
+	case jsonFormatCode:
+		if h.compression != noCompressionCode {
+			return nil, fmt.Errorf("unexpected compression %d for json format", h.compression)
+		}
+		return newJSONCodec(checksums), nil
+	case binaryFormatCode:
+		switch h.compression {
+		case noCompressionCode:
+			return newBinaryCodec(config.NoCompression, checksums), nil
+		case gzipCompressionCode:
+			return newBinaryCodec(config.GzipCompression, checksums), nil
+		default:
+			return nil, fmt.Errorf("unknown compression %d", h.compression)
+		}
+	default:
+		return nil, fmt.Errorf("unknown format %d", h.format)
+	}
+}
+
+// writeHeader puts codec description to the beginning of a data file
+func writeHeader(w io.Writer, c codec) error {
+	h := c.header()
+	buf := append(append([]byte{}, fileMagic...), h.version, h.format, h.compression)
+	_, err := w.Write(buf)
+	return err
+}
+
+// readHeader reads data file header and returns codec that should be used to decode the file
+func readHeader(r *bufio.Reader) (codec, error) {
+	magic, err := r.Peek(len(fileMagic))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if !bytes.Equal(magic, fileMagic) {
+		return newJSONCodec(false), nil
+	}
+
+	buf := make([]byte, fileHeaderSize)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, fmt.Errorf("read header: %v", err)
 	}
+	return fileHeader{
+		version:     buf[len(fileMagic)],
+		format:      buf[len(fileMagic)+1],
+		compression: buf[len(fileMagic)+2],
+	}.codec()
 }
@@ -0,0 +1,185 @@
+package filesystem
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/memprofiler/memprofiler/schema"
+	"github.com/memprofiler/memprofiler/server/config"
+)
+
+func TestCodecs(t *testing.T) {
+	// the second measurement exceeds the default bufio.Scanner line limit
+	var mms []*schema.Measurement
+	for i := 1; i <= 2; i++ {
+		mm := &schema.Measurement{ObservedAt: &timestamp.Timestamp{Seconds: int64(i)}}
+		for j := 0; j < i*1000; j++ {
+			mm.Locations = append(mm.Locations, &schema.Location{
+				MemoryUsage: &schema.MemoryUsage{AllocBytes: int64(j)},
+				Callstack: &schema.Callstack{
+					Id:     fmt.Sprint(j),
+					Frames: []*schema.StackFrame{{Name: "main.allocate", File: "main.go", Line: int32(j)}},
+				},
+			})
+		}
+		mms = append(mms, mm)
+	}
+
+	cfgs := []*config.FilesystemStorageConfig{
+		{Format: config.JSONFormat},
+		{Format: config.BinaryFormat, Compression: config.NoCompression},
+		{Format: config.BinaryFormat, Compression: config.GzipCompression},
+	}
+	for _, cfg := range cfgs {
+		t.Run(fmt.Sprintf("%s_%s", cfg.Format, cfg.Compression), func(t *testing.T) {
+			c := newCodec(cfg)
+
+			var buf bytes.Buffer
+			assert.NoError(t, writeHeader(&buf, c))
+			encoder := c.newEncoder(&buf)
+			for _, mm := range mms {
+				assert.NoError(t, encoder.encode(mm))
+			}
+
+			r := bufio.NewReader(&buf)
+			codec, err := readHeader(r)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, c.header(), codec.header())
+			decoder := codec.newDecoder(r)
+			for _, mm := range mms {
+				var receiver schema.Measurement
+				assert.NoError(t, decoder.decode(&receiver))
+				assert.True(t, proto.Equal(mm, &receiver))
+			}
+			assert.Equal(t, io.EOF, decoder.decode(&schema.Measurement{}))
+		})
+	}
+
+	t.Run("legacy", func(t *testing.T) {
+		// files written by older versions have no header and start with a delimiter
+		var buf bytes.Buffer
+		for _, mm := range mms {
+			buf.WriteByte('\n')
+			assert.NoError(t, newJSONCodec(false).(*jsonCodec).marshaller.Marshal(&buf, mm))
+		}
+
+		r := bufio.NewReader(&buf)
+		codec, err := readHeader(r)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		decoder := codec.newDecoder(r)
+		for _, mm := range mms {
+			var receiver schema.Measurement
+			assert.NoError(t, decoder.decode(&receiver))
+			assert.True(t, proto.Equal(mm, &receiver))
+		}
+		assert.Equal(t, io.EOF, decoder.decode(&schema.Measurement{}))
+	})
+
+}
+
+func TestCodecChecksums(t *testing.T) {
+	mm := &schema.Measurement{ObservedAt: &timestamp.Timestamp{Seconds: 1}}
+
+	cfgs := []*config.FilesystemStorageConfig{
+		{Format: config.JSONFormat},
+		{Format: config.BinaryFormat, Compression: config.NoCompression},
+		{Format: config.BinaryFormat, Compression: config.GzipCompression},
+	}
+	for _, cfg := range cfgs {
+		c := newCodec(cfg)
+		name := fmt.Sprintf("%s_%s", cfg.Format, cfg.Compression)
+
+		var buf bytes.Buffer
+		assert.NoError(t, c.newEncoder(&buf).encode(mm))
+		record := buf.Bytes()
+
+		// flip a bit within the message
+		damaged := append([]byte{}, record...)
+		damaged[len(damaged)/2] ^= 1
+		decoder := c.newDecoder(bufio.NewReader(bytes.NewReader(damaged)))
+		assert.Equal(t, errChecksumMismatch, decoder.decode(&schema.Measurement{}), name)
+
+		// cut off the record tail
+		torn := record[:len(record)-2]
+		decoder = c.newDecoder(bufio.NewReader(bytes.NewReader(torn)))
+		assert.Equal(t, errTornRecord, decoder.decode(&schema.Measurement{}), name)
+	}
+}
+
+func TestBlockCompression(t *testing.T) {
+	// memory usage of a location changes rarely, so consecutive measurements are similar
+	var mms []*schema.Measurement
+	for i := 0; i < 3*maxBlockRecords; i++ {
+		mm := &schema.Measurement{ObservedAt: &timestamp.Timestamp{Seconds: int64(i)}}
+		for j := 0; j < 100; j++ {
+			mm.Locations = append(mm.Locations, &schema.Location{
+				MemoryUsage: &schema.MemoryUsage{AllocBytes: int64(j * (1 + (i+j)/16)), AllocObjects: int64(j)},
+				Callstack: &schema.Callstack{
+					Id:     fmt.Sprintf("callstack%d", j),
+					Frames: []*schema.StackFrame{{Name: "main.allocate", File: "main.go", Line: int32(j)}},
+				},
+			})
+		}
+		mms = append(mms, mm)
+	}
+
+	// records are readable as soon as they're written
+	var (
+		c       = newCodec(&config.FilesystemStorageConfig{Format: config.BinaryFormat, Compression: config.GzipCompression})
+		buf     bytes.Buffer // consumed by decoder
+		written bytes.Buffer
+		w       = &countingWriter{w: io.MultiWriter(&buf, &written)}
+		encoder = c.newEncoder(w)
+		decoder = c.newDecoder(bufio.NewReader(&buf))
+		offsets []int64
+	)
+	for i, mm := range mms {
+		offsets = append(offsets, w.n)
+		assert.NoError(t, encoder.encode(mm))
+		assert.Equal(t, i%maxBlockRecords == 0, encoder.seekable())
+
+		var receiver schema.Measurement
+		assert.NoError(t, decoder.decode(&receiver))
+		assert.Equal(t, i%maxBlockRecords == 0, decoder.seekable())
+		assert.True(t, proto.Equal(mm, &receiver))
+	}
+	assert.Equal(t, io.EOF, decoder.decode(&schema.Measurement{}))
+	file := written.Bytes()
+
+	// reading may start only at the beginning of a block
+	decoder = c.newDecoder(bufio.NewReader(bytes.NewReader(file[offsets[maxBlockRecords]:])))
+	for _, mm := range mms[maxBlockRecords:] {
+		var receiver schema.Measurement
+		assert.NoError(t, decoder.decode(&receiver))
+		assert.True(t, proto.Equal(mm, &receiver))
+	}
+	decoder = c.newDecoder(bufio.NewReader(bytes.NewReader(file[offsets[maxBlockRecords+1]:])))
+	assert.Error(t, decoder.decode(&schema.Measurement{}))
+
+	// blocks are several times smaller than records compressed independently
+	var independent int
+	for _, mm := range mms {
+		payload, err := proto.Marshal(mm)
+		assert.NoError(t, err)
+		var compressed bytes.Buffer
+		zw := gzip.NewWriter(&compressed)
+		_, err = zw.Write(payload)
+		assert.NoError(t, err)
+		assert.NoError(t, zw.Close())
+		independent += compressed.Len()
+	}
+	t.Logf("blocks: %d bytes, independent records: %d bytes", len(file), independent)
+	assert.True(t, len(file)*4 < independent)
+}
@@ -2,11 +2,14 @@ package filesystem
 
 import (
 	"bufio"
-	"bytes"
 	"context"
+	"io"
 	"os"
-	"path/filepath"
 	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/pkg/errors"
 
 	"github.com/memprofiler/memprofiler/server/storage/data"
 
@@ -16,11 +19,15 @@ import (
 )
 
 type defaultDataLoader struct {
-	codec  codec
-	sd     *schema.SessionDescription
-	fd     *os.File
-	logger *zerolog.Logger
-	wg     *sync.WaitGroup
+	decoder     decoder // defined by the data file header
+	downsampled []*schema.Measurement
+	index       *sessionIndex // read together with the data file, so it refers the same version of file
+	sd          *schema.SessionDescription
+	fd          *os.File
+	reader      *bufio.Reader
+	filename    string
+	logger      *zerolog.Logger
+	wg          *sync.WaitGroup
 }
 
 const (
@@ -28,23 +35,70 @@ const (
 )
 
 func (l *defaultDataLoader) Load(ctx context.Context) (<-chan *data.LoadResult, error) {
+	return l.LoadRange(ctx, time.Time{}, time.Time{})
+}
+
+// LoadRange reads downsampled measurements first, and the raw ones then; index is used
+// to skip the records observed before the range, and reading stops after the range end,
+// since records are ordered by time
+func (l *defaultDataLoader) LoadRange(ctx context.Context, from, to time.Time) (<-chan *data.LoadResult, error) {
+
+	if !from.IsZero() {
+		if err := l.seek(from); err != nil {
+			return nil, err
+		}
+	}
 
 	// prepare buffered channel for results
 	results := make(chan *data.LoadResult, loadChanCapacity)
 
-	scanner := bufio.NewScanner(l.fd)
-	scanner.Split(bufio.ScanLines)
-
-	// scan records line by line
+	// read records one by one
 	go func() {
 		defer close(results)
-		for scanner.Scan() {
-			if len(scanner.Bytes()) > 0 {
-				select {
-				case results <- l.loadMeasurement(scanner.Bytes()):
-				case <-ctx.Done():
+
+		var last time.Time
+		for _, measurement := range l.downsampled {
+			observedAt, err := ptypes.Timestamp(measurement.GetObservedAt())
+			if err != nil {
+				continue
+			}
+			last = observedAt
+			if !data.InRange(measurement.GetObservedAt(), from, to) {
+				continue
+			}
+			select {
+			case results <- &data.LoadResult{Measurement: measurement}:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		for {
+			result := l.loadMeasurement()
+			if result == nil {
+				return
+			}
+			if result.Err == nil {
+				observedAt, err := ptypes.Timestamp(result.Measurement.GetObservedAt())
+				if err == nil && !to.IsZero() && observedAt.After(to) {
 					return
 				}
+				if err == nil && !from.IsZero() && observedAt.Before(from) {
+					continue
+				}
+				// skip the records that have been downsampled, but not removed yet
+				if err == nil && !observedAt.After(last) {
+					continue
+				}
+			}
+			select {
+			case results <- result:
+			case <-ctx.Done():
+				return
+			}
+			// decoding errors break records alignment, so it's impossible to continue
+			if result.Err != nil {
+				return
 			}
 		}
 	}()
@@ -52,10 +106,31 @@ func (l *defaultDataLoader) Load(ctx context.Context) (<-chan *data.LoadResult,
 	return results, nil
 }
 
-// loadMeasurement disk
-func (l *defaultDataLoader) loadMeasurement(in []byte) *data.LoadResult {
+// seek moves to the record preceded only by the records observed before the given moment;
+// if index is missing, file is read from the beginning
+func (l *defaultDataLoader) seek(from time.Time) error {
+	if l.index == nil {
+		return nil
+	}
+
+	offset := l.index.seek(from)
+	if offset == 0 {
+		return nil
+	}
+	if _, err := l.fd.Seek(offset, io.SeekStart); err != nil {
+		return errors.Wrap(err, "seek data file")
+	}
+	l.reader.Reset(l.fd)
+	return nil
+}
+
+// loadMeasurement reads the next measurement from disk; nil means the end of file
+func (l *defaultDataLoader) loadMeasurement() *data.LoadResult {
 	var receiver schema.Measurement
-	err := l.codec.decode(bytes.NewReader(in), &receiver)
+	err := l.decoder.decode(&receiver)
+	if err == io.EOF {
+		return nil
+	}
 	return &data.LoadResult{Measurement: &receiver, Err: err}
 }
 
@@ -68,30 +143,26 @@ func (l *defaultDataLoader) Close() error {
 
 func newDataLoader(
 	dataFilePath string,
+	files *sessionFiles,
 	sessionDesc *schema.SessionDescription,
-	codec codec,
 	logger *zerolog.Logger,
 	wg *sync.WaitGroup,
-) (data.Loader, error) {
-
-	// open file to load records
-	fd, err := os.Open(filepath.Clean(dataFilePath))
-	if err != nil {
-		return nil, err
-	}
-
+) data.Loader {
 	contextLogger := logger.With().Fields(map[string]interface{}{
 		"service":    sessionDesc.InstanceDescription.ServiceName,
 		"instance":   sessionDesc.InstanceDescription.InstanceName,
 		"session_id": sessionDesc.Id,
 	}).Logger()
 
-	loader := &defaultDataLoader{
-		sd:     sessionDesc,
-		fd:     fd,
-		codec:  codec,
-		logger: &contextLogger,
-		wg:     wg,
+	return &defaultDataLoader{
+		downsampled: files.downsampled,
+		index:       files.index,
+		sd:          sessionDesc,
+		fd:          files.fd,
+		reader:      files.reader,
+		filename:    dataFilePath,
+		decoder:     files.decoder,
+		logger:      &contextLogger,
+		wg:          wg,
 	}
-	return loader, nil
 }
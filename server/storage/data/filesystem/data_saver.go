@@ -2,6 +2,7 @@ package filesystem
 
 import (
 	"context"
+	"io"
 	"os"
 	"sync"
 
@@ -18,9 +19,11 @@ var _ data.Saver = (*defaultDataSaver)(nil)
 
 // defaultDataSaver puts records to a file sequentially
 type defaultDataSaver struct {
-	codec           codec
-	delimiter       []byte
-	fd              *os.File // data file
+	encoder         encoder
+	fd              *os.File        // data file
+	w               *countingWriter // counts data file size
+	indexFd         *os.File        // index file
+	index           *indexWriter
 	metadataStorage metadata.Storage
 	sessionDesc     *schema.SessionDescription
 	cfg             *config.FilesystemStorageConfig
@@ -29,21 +32,25 @@ type defaultDataSaver struct {
 
 func (s *defaultDataSaver) Save(mm *schema.Measurement) error {
 
-	// put delimiter after last record
-	if _, err := s.fd.Write(s.delimiter); err != nil {
+	// serialize measurement into the file
+	recordOffset := s.w.n
+	if err := s.encoder.encode(mm); err != nil {
 		return err
 	}
 
-	// serialize measurement into the file
-	if err := s.codec.encode(s.fd, mm); err != nil {
-		return err
+	// index is updated after the record is written, so it never refers missing records
+	if err := s.index.add(mm, recordOffset, s.encoder.seekable()); err != nil {
+		return errors.Wrap(err, "update index")
 	}
 
-	// sync file if required
+	// sync files if required
 	if s.cfg.SyncWrite {
 		if err := s.fd.Sync(); err != nil {
 			return err
 		}
+		if err := s.indexFd.Sync(); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -59,6 +66,9 @@ func (s *defaultDataSaver) Close() error {
 	if err := s.fd.Close(); err != nil {
 		return errors.Wrap(err, "close file descriptor")
 	}
+	if err := s.indexFd.Close(); err != nil {
+		return errors.Wrap(err, "close index file descriptor")
+	}
 	return nil
 }
 
@@ -79,10 +89,31 @@ func newDataSaver(
 		return nil, err
 	}
 
+	// describe file format for loaders
+	if err := writeHeader(fd, codec); err != nil {
+		_ = fd.Close()
+		return nil, errors.Wrap(err, "write data file header")
+	}
+
+	indexFd, err := os.OpenFile(indexFile(dataFilePath), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, filePermissions)
+	if err != nil {
+		_ = fd.Close()
+		return nil, errors.Wrap(err, "open index file")
+	}
+	index, err := newIndexWriter(indexFd)
+	if err != nil {
+		_ = fd.Close()
+		_ = indexFd.Close()
+		return nil, errors.Wrap(err, "write index file header")
+	}
+
+	w := &countingWriter{w: fd, n: int64(fileHeaderSize)}
 	saver := &defaultDataSaver{
 		fd:              fd,
-		delimiter:       []byte{10}, // '\n'
-		codec:           codec,
+		w:               w,
+		encoder:         codec.newEncoder(w),
+		indexFd:         indexFd,
+		index:           index,
 		sessionDesc:     sessionDesc,
 		cfg:             cfg,
 		wg:              wg,
@@ -91,3 +122,15 @@ func newDataSaver(
 
 	return saver, nil
 }
+
+// countingWriter counts bytes written to the underlying writer
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
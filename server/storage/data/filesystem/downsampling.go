@@ -0,0 +1,223 @@
+package filesystem
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/pkg/errors"
+
+	"github.com/memprofiler/memprofiler/schema"
+	"github.com/memprofiler/memprofiler/server/config"
+	"github.com/memprofiler/memprofiler/server/storage/data"
+)
+
+// Downsample moves expired measurements of the sessions to downsampled measurements files
+func (s *storage) Downsample(
+	ctx context.Context,
+	sds []*schema.SessionDescription,
+	tiers []config.DownsamplingTier,
+	now time.Time,
+) error {
+	var failed int
+	for _, sd := range sds {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		if err := s.downsampleSession(ctx, sd, tiers, now); err != nil {
+			s.logger.Error().Err(err).Int64("session_id", sd.GetId()).Msg("Failed to downsample session")
+			failed++
+		}
+	}
+	if failed > 0 {
+		return errors.Errorf("%d sessions failed", failed)
+	}
+	return nil
+}
+
+// downsampledFileSuffix is used by files keeping downsampled measurements of sessions;
+// raw measurements are moved there from data files once they become older than the first tier threshold
+const downsampledFileSuffix = ".downsampled"
+
+func downsampledFile(dataFile string) string { return dataFile + downsampledFileSuffix }
+
+// downsampleSession moves expired measurements from session data file to downsampled measurements file;
+// both files are replaced atomically, so loaders that have already opened them continue reading the old versions
+func (s *storage) downsampleSession(
+	ctx context.Context,
+	sessionDesc *schema.SessionDescription,
+	tiers []config.DownsamplingTier,
+	now time.Time,
+) error {
+	// session can't be deleted while its files are rewritten
+	s.filesMutex.Lock()
+	defer s.filesMutex.Unlock()
+
+	filename := s.sessionDataFile(s.instanceDir(sessionDesc.InstanceDescription), sessionDesc.Id)
+	raw, err := readMeasurements(filename)
+	if err != nil {
+		// session has been deleted
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Wrap(err, "read measurements")
+	}
+
+	mms, err := readMeasurements(downsampledFile(filename))
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "read downsampled measurements")
+	}
+	var last time.Time
+	if len(mms) > 0 {
+		if last, err = ptypes.Timestamp(mms[len(mms)-1].GetObservedAt()); err != nil {
+			return err
+		}
+	}
+
+	// measurements observed not later than the last downsampled one have been already moved,
+	// but haven't been removed from data file (e.g. because of failure)
+	var (
+		cut   = data.DownsamplingCut(tiers, now)
+		kept  []*schema.Measurement
+		moved int
+		stale int
+	)
+	for _, mm := range raw {
+		observedAt, err := ptypes.Timestamp(mm.GetObservedAt())
+		if err != nil {
+			return err
+		}
+		switch {
+		case !observedAt.Before(cut):
+			kept = append(kept, mm)
+		case observedAt.After(last):
+			mms = append(mms, mm)
+			moved++
+		default:
+			stale++
+		}
+	}
+
+	downsampled, err := data.Downsample(mms, tiers, now)
+	if err != nil {
+		return errors.Wrap(err, "downsample measurements")
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	if moved > 0 || !equalMeasurements(mms, downsampled) {
+		if err := s.writeMeasurements(downsampledFile(filename), downsampled); err != nil {
+			return errors.Wrap(err, "write downsampled measurements")
+		}
+	}
+
+	// measurements are removed from data file only when they're safely stored in downsampled measurements file
+	if moved+stale == 0 {
+		return nil
+	}
+	if err := s.writeMeasurements(filename, kept); err != nil {
+		return errors.Wrap(err, "write measurements")
+	}
+	if err := buildIndex(filename); err != nil {
+		return errors.Wrap(err, "build index")
+	}
+	s.logger.Info().Fields(map[string]interface{}{
+		"data_file": filename,
+		"moved":     moved,
+		"after":     len(downsampled),
+	}).Msg("Session data downsampled")
+	return nil
+}
+
+// readMeasurements reads all measurements of the file
+func readMeasurements(filename string) ([]*schema.Measurement, error) {
+	fd, err := os.Open(filepath.Clean(filename))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = fd.Close() }()
+
+	reader := bufio.NewReader(fd)
+	codec, err := readHeader(reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "read data file header")
+	}
+
+	var (
+		decoder = codec.newDecoder(reader)
+		result  []*schema.Measurement
+	)
+	for {
+		mm := &schema.Measurement{}
+		if err := decoder.decode(mm); err != nil {
+			if err == io.EOF {
+				return result, nil
+			}
+			return nil, err
+		}
+		result = append(result, mm)
+	}
+}
+
+// writeMeasurements writes measurements to the temporary file and renames it then;
+// the file is written with the configured codec, so old files are converted to the current format
+func (s *storage) writeMeasurements(filename string, mms []*schema.Measurement) error {
+	tmpFilename := filename + tmpFileSuffix
+	fd, err := os.OpenFile(tmpFilename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, filePermissions)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(fd)
+	if err := writeHeader(w, s.codec); err != nil {
+		_ = fd.Close()
+		return err
+	}
+	encoder := s.codec.newEncoder(w)
+	for _, mm := range mms {
+		if err := encoder.encode(mm); err != nil {
+			_ = fd.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		_ = fd.Close()
+		return err
+	}
+	if err := fd.Sync(); err != nil {
+		_ = fd.Close()
+		return err
+	}
+	if err := fd.Close(); err != nil {
+		return err
+	}
+
+	// the old index refers wrong offsets, so it's removed before the file is replaced
+	if err := os.Remove(indexFile(filename)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Rename(tmpFilename, filename)
+}
+
+func equalMeasurements(a, b []*schema.Measurement) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !proto.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,241 @@
+package filesystem
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/pkg/errors"
+
+	"github.com/memprofiler/memprofiler/schema"
+)
+
+// Every session data file has a sidecar index file, which is a sequence of entries of two kinds:
+// offset entries refer every indexInterval-th record, callstack entries enumerate callstacks seen in the session.
+// Index may lag behind the data file (e.g. after crash), so records following the last offset entry are always scanned.
+
+// indexInterval is a minimal number of records between two offset entries;
+// offset entries refer only the records that can be read without the preceding ones
+const indexInterval = 64
+
+// indexFileSuffix is appended to data file name to build index file name
+const indexFileSuffix = ".idx"
+
+// indexMagic starts every index file
+var indexMagic = []byte("MPRX")
+
+const indexVersion byte = 1
+
+const (
+	offsetEntryTag    byte = 'o'
+	callstackEntryTag byte = 'c'
+)
+
+// offsetEntrySize is a size of tag, observation time [ns] and offset
+const offsetEntrySize = 1 + 8 + 8
+
+func indexFile(dataFile string) string { return dataFile + indexFileSuffix }
+
+// indexEntry refers a record within data file
+type indexEntry struct {
+	observedAt int64 // unix time [ns]
+	offset     int64
+}
+
+// sessionIndex is a sparse index of a session data file
+type sessionIndex struct {
+	entries    []indexEntry
+	callstacks map[string]struct{}
+}
+
+// seek returns offset of a record preceded only by the records observed earlier than the given moment;
+// zero means that the file should be read from the beginning
+func (idx *sessionIndex) seek(from time.Time) int64 {
+	i := sort.Search(len(idx.entries), func(i int) bool { return idx.entries[i].observedAt >= from.UnixNano() })
+	if i == 0 {
+		return 0
+	}
+	return idx.entries[i-1].offset
+}
+
+// tail returns offset of the last indexed record; zero means that there are no indexed records
+func (idx *sessionIndex) tail() int64 {
+	if len(idx.entries) == 0 {
+		return 0
+	}
+	return idx.entries[len(idx.entries)-1].offset
+}
+
+// first returns observation time of the first record; nil means that there are no indexed records
+func (idx *sessionIndex) first() *timestamp.Timestamp {
+	if len(idx.entries) == 0 {
+		return nil
+	}
+	observedAt := idx.entries[0].observedAt
+	return &timestamp.Timestamp{Seconds: observedAt / int64(time.Second), Nanos: int32(observedAt % int64(time.Second))}
+}
+
+// readIndex reads index file; the torn tail entry is ignored
+func readIndex(path string) (*sessionIndex, error) {
+	content, err := ioutil.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(content, indexMagic) || len(content) < len(indexMagic)+1 {
+		return nil, fmt.Errorf("invalid index header")
+	}
+	if version := content[len(indexMagic)]; version != indexVersion {
+		return nil, fmt.Errorf("unsupported index version %d", version)
+	}
+
+	idx := &sessionIndex{callstacks: make(map[string]struct{})}
+	r := bytes.NewReader(content[len(indexMagic)+1:])
+	for {
+		tag, err := r.ReadByte()
+		if err == io.EOF {
+			return idx, nil
+		}
+
+		switch tag {
+		case offsetEntryTag:
+			var buf [offsetEntrySize - 1]byte
+			if _, err := io.ReadFull(r, buf[:]); err != nil {
+				return idx, nil
+			}
+			idx.entries = append(idx.entries, indexEntry{
+				observedAt: int64(binary.BigEndian.Uint64(buf[:8])),
+				offset:     int64(binary.BigEndian.Uint64(buf[8:])),
+			})
+		case callstackEntryTag:
+			size, err := binary.ReadUvarint(r)
+			if err != nil || size > uint64(r.Len()) {
+				return idx, nil
+			}
+			id := make([]byte, size)
+			if _, err := io.ReadFull(r, id); err != nil {
+				return idx, nil
+			}
+			idx.callstacks[string(id)] = struct{}{}
+		default:
+			return nil, fmt.Errorf("unknown index entry %d", tag)
+		}
+	}
+}
+
+// indexWriter appends entries to index file
+type indexWriter struct {
+	w          io.Writer
+	records    int
+	next       int // number of record that may get the next offset entry
+	callstacks map[string]struct{}
+}
+
+// add indexes a record written at the given offset; seekable tells if the record
+// can be read without the preceding ones
+func (iw *indexWriter) add(mm *schema.Measurement, offset int64, seekable bool) error {
+	var buf []byte
+
+	if seekable && iw.records >= iw.next {
+		iw.next = iw.records + indexInterval
+		observedAt := mm.GetObservedAt().GetSeconds()*int64(time.Second) + int64(mm.GetObservedAt().GetNanos())
+		var entry [offsetEntrySize]byte
+		entry[0] = offsetEntryTag
+		binary.BigEndian.PutUint64(entry[1:9], uint64(observedAt))
+		binary.BigEndian.PutUint64(entry[9:], uint64(offset))
+		buf = append(buf, entry[:]...)
+	}
+	iw.records++
+
+	for _, location := range mm.GetLocations() {
+		id := location.GetCallstack().GetId()
+		if _, exists := iw.callstacks[id]; exists {
+			continue
+		}
+		iw.callstacks[id] = struct{}{}
+		var size [binary.MaxVarintLen64]byte
+		n := binary.PutUvarint(size[:], uint64(len(id)))
+		buf = append(buf, callstackEntryTag)
+		buf = append(buf, size[:n]...)
+		buf = append(buf, id...)
+	}
+
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := iw.w.Write(buf)
+	return err
+}
+
+// newIndexWriter writes index header and returns writer for index entries
+func newIndexWriter(w io.Writer) (*indexWriter, error) {
+	header := append(append([]byte{}, indexMagic...), indexVersion)
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &indexWriter{w: w, callstacks: make(map[string]struct{})}, nil
+}
+
+// buildIndex builds index for the existing data file; index file is replaced atomically
+func buildIndex(dataFile string) error {
+	fd, err := os.Open(filepath.Clean(dataFile))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = fd.Close() }()
+
+	var (
+		cr     = &countingReader{r: fd}
+		reader = bufio.NewReader(cr)
+		offset = func() int64 { return cr.n - int64(reader.Buffered()) }
+	)
+	codec, err := readHeader(reader)
+	if err != nil {
+		return errors.Wrap(err, "read data file header")
+	}
+
+	tmpFilename := indexFile(dataFile) + tmpFileSuffix
+	out, err := os.OpenFile(tmpFilename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, filePermissions)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(out)
+
+	iw, err := newIndexWriter(w)
+	if err != nil {
+		_ = out.Close()
+		return err
+	}
+	decoder := codec.newDecoder(reader)
+	for {
+		recordOffset := offset()
+		mm := &schema.Measurement{}
+		if err := decoder.decode(mm); err != nil {
+			if err == io.EOF {
+				break
+			}
+			_ = out.Close()
+			return errors.Wrap(err, "decode record")
+		}
+		if err := iw.add(mm, recordOffset, decoder.seekable()); err != nil {
+			_ = out.Close()
+			return err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFilename, indexFile(dataFile))
+}
@@ -0,0 +1,131 @@
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/memprofiler/memprofiler/schema"
+	"github.com/memprofiler/memprofiler/server/config"
+	"github.com/memprofiler/memprofiler/server/storage/metadata"
+	"github.com/memprofiler/memprofiler/utils"
+)
+
+func TestIndex(t *testing.T) {
+	for _, compression := range []config.FilesystemCompression{config.NoCompression, config.GzipCompression} {
+		t.Run(string(compression), func(t *testing.T) { testIndex(t, compression) })
+	}
+}
+
+func testIndex(t *testing.T, compression config.FilesystemCompression) {
+	logger := utils.NewLogger(&config.LoggingConfig{Level: zerolog.DebugLevel})
+
+	rootDir, err := ioutil.TempDir("", "memprofiler")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(rootDir)
+
+	metadataStorage, err := metadata.NewStorageSQLite(logger, &config.MetadataStorageConfig{DataDir: filepath.Join(rootDir, "metadata")})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer metadataStorage.Quit()
+
+	cfg := &config.FilesystemStorageConfig{DataDir: filepath.Join(rootDir, "data"), Format: config.BinaryFormat, Compression: compression}
+	dataStorage, err := NewStorage(logger, cfg, metadataStorage)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer dataStorage.Quit()
+
+	// a new callstack appears every 100 measurements
+	saver, err := dataStorage.NewDataSaver(&schema.InstanceDescription{ServiceName: "service", InstanceName: "instance"})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	for i := 0; i < 1000; i++ {
+		mm := &schema.Measurement{ObservedAt: &timestamp.Timestamp{Seconds: int64(i)}}
+		for j := 0; j <= i/100; j++ {
+			mm.Locations = append(mm.Locations, &schema.Location{
+				Callstack:   &schema.Callstack{Id: fmt.Sprint(j)},
+				MemoryUsage: &schema.MemoryUsage{AllocBytes: int64(i)},
+			})
+		}
+		assert.NoError(t, saver.Save(mm))
+	}
+	assert.NoError(t, saver.Close())
+	sd := saver.SessionDescription()
+
+	s := dataStorage.(*storage)
+	filename := s.sessionDataFile(s.instanceDir(sd.InstanceDescription), sd.Id)
+	idx, err := readIndex(indexFile(filename))
+	if assert.NoError(t, err) {
+		assert.Len(t, idx.entries, 1000/indexInterval+1)
+		assert.Len(t, idx.callstacks, 10)
+	}
+
+	checkRange := func(from, to int64) {
+		loader, err := dataStorage.NewDataLoader(sd)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		defer func() { assert.NoError(t, loader.Close()) }()
+
+		results, err := loader.LoadRange(context.Background(), time.Unix(from, 0), time.Unix(to, 0))
+		assert.NoError(t, err)
+
+		expected := from
+		for result := range results {
+			assert.NoError(t, result.Err)
+			assert.Equal(t, expected, result.Measurement.GetObservedAt().GetSeconds())
+			expected++
+		}
+		assert.Equal(t, to+1, expected)
+	}
+
+	checkBounds := func() {
+		bounds, err := dataStorage.SessionBounds(context.Background(), sd)
+		assert.NoError(t, err)
+		assert.Equal(t, &schema.SessionBounds{
+			FirstObservedAt: &timestamp.Timestamp{Seconds: 0},
+			LastObservedAt:  &timestamp.Timestamp{Seconds: 999},
+			Callstacks:      10,
+		}, bounds)
+	}
+
+	checkRange(500, 510)
+	checkRange(0, 63)
+	checkRange(64, 999)
+	checkBounds()
+
+	// storage works without index, and the index is rebuilt during recovery
+	assert.NoError(t, os.Remove(indexFile(filename)))
+	checkRange(500, 510)
+	checkBounds()
+
+	reports, err := Check(cfg, true)
+	if assert.NoError(t, err) && assert.Len(t, reports, 1) {
+		assert.True(t, reports[0].IndexRebuilt)
+	}
+	rebuilt, err := readIndex(indexFile(filename))
+	assert.NoError(t, err)
+	assert.Equal(t, idx, rebuilt)
+	checkBounds()
+
+	// the session without data has no bounds
+	empty, err := dataStorage.SessionBounds(context.Background(), &schema.SessionDescription{
+		InstanceDescription: sd.InstanceDescription,
+		Id:                  sd.Id + 1,
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, empty)
+}
@@ -0,0 +1,272 @@
+package filesystem
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/pkg/errors"
+
+	"github.com/memprofiler/memprofiler/schema"
+	"github.com/memprofiler/memprofiler/server/config"
+	"github.com/memprofiler/memprofiler/utils"
+)
+
+// FileReport contains results of a data file check
+type FileReport struct {
+	// Path is a path to the data file
+	Path string
+	// Records is a number of valid records
+	Records int
+	// Size is a size of the file before repair
+	Size int64
+	// ValidSize is a size of the file prefix containing header and valid records
+	ValidSize int64
+	// LastObservedAt is a time of the last valid measurement (zero if there are no measurements)
+	LastObservedAt time.Time
+	// Err describes the first damaged record; nil if file is healthy
+	Err error
+	// Repaired is set if the torn tail has been truncated
+	Repaired bool
+	// IndexRebuilt is set if the index file has been rebuilt
+	IndexRebuilt bool
+}
+
+// Damaged checks if file contains damaged records
+func (r *FileReport) Damaged() bool { return r.Err != nil }
+
+// Torn checks if the only damaged record is the last one, which has been written partially;
+// such files are safely repaired by truncation, while the others need manual investigation
+func (r *FileReport) Torn() bool { return errors.Cause(r.Err) == errTornRecord }
+
+// sessionFilePattern matches names of session data files
+var sessionFilePattern = regexp.MustCompile(`^\d{10}$`)
+
+// tmpFileSuffix is used by files that are being rewritten
+const tmpFileSuffix = ".tmp"
+
+// Check validates records of every data file in the data directory;
+// if repair is set, torn tails are truncated, missing or outdated indexes are rebuilt,
+// and temporary files left after crash are removed; files damaged otherwise are left as is
+func Check(cfg *config.FilesystemStorageConfig, repair bool) ([]*FileReport, error) {
+	var reports []*FileReport
+
+	err := filepath.Walk(cfg.DataDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		if strings.HasSuffix(path, tmpFileSuffix) {
+			if repair {
+				return errors.Wrap(os.Remove(path), "remove temporary file")
+			}
+			return nil
+		}
+
+		if !sessionFilePattern.MatchString(info.Name()) {
+			return nil
+		}
+
+		report, err := checkAndRepairFile(path, repair)
+		if err != nil {
+			return err
+		}
+		// files written by older versions have no index
+		if repair && !report.Damaged() && !utils.FileExists(indexFile(path)) {
+			if err := buildIndex(path); err != nil {
+				return errors.Wrapf(err, "build index for file %s", path)
+			}
+			report.IndexRebuilt = true
+		}
+		reports = append(reports, report)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reports, nil
+}
+
+// checkAndRepairFile checks data file; if repair is set, torn tail is truncated and index is rebuilt
+func checkAndRepairFile(path string, repair bool) (*FileReport, error) {
+	report, err := checkFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "check file %s", path)
+	}
+	if !repair || !report.Torn() {
+		return report, nil
+	}
+
+	if err := os.Truncate(path, report.ValidSize); err != nil {
+		return nil, errors.Wrapf(err, "truncate file %s", path)
+	}
+	report.Repaired = true
+
+	// index may refer truncated records
+	if err := buildIndex(path); err != nil {
+		return nil, errors.Wrapf(err, "build index for file %s", path)
+	}
+	report.IndexRebuilt = true
+	return report, nil
+}
+
+// countingReader counts bytes read from the underlying reader
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// checkFile reads data file until the first damaged record
+func checkFile(path string) (*FileReport, error) {
+	fd, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = fd.Close() }()
+
+	info, err := fd.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		report = &FileReport{Path: path, Size: info.Size()}
+		cr     = &countingReader{r: fd}
+		reader = bufio.NewReader(cr)
+		offset = func() int64 { return cr.n - int64(reader.Buffered()) }
+	)
+
+	codec, err := readHeader(reader)
+	if err != nil {
+		// torn header is dropped, otherwise header can't be trusted, so file is left as is
+		if report.Size < int64(fileHeaderSize) {
+			report.Err = errors.Wrap(errTornRecord, "read header")
+			return report, nil
+		}
+		report.Err = err
+		return report, nil
+	}
+	report.ValidSize = offset()
+
+	decoder := codec.newDecoder(reader)
+	for {
+		mm := &schema.Measurement{}
+		if err := decoder.decode(mm); err != nil {
+			if err != io.EOF {
+				report.Err = err
+			}
+			return report, nil
+		}
+		report.Records++
+		report.ValidSize = offset()
+		if observedAt, err := ptypes.Timestamp(mm.GetObservedAt()); err == nil {
+			report.LastObservedAt = observedAt
+		}
+	}
+}
+
+// recover repairs data files of sessions interrupted by crash and closes them;
+// session finish time is considered equal to the time of its last measurement.
+// Metadata may be shared by several servers, so only the sessions having local data files are closed:
+// recovery is done before any saver is created, so none of them is live.
+func (s *storage) recover(ctx context.Context) error {
+	if err := removeTmpFiles(s.cfg.DataDir); err != nil {
+		return errors.Wrap(err, "remove temporary files")
+	}
+
+	services, err := s.metadataStorage.GetServices(ctx)
+	if err != nil {
+		return errors.Wrap(err, "get services")
+	}
+	for _, service := range services {
+		instances, err := s.metadataStorage.GetInstances(ctx, service)
+		if err != nil {
+			return errors.Wrap(err, "get instances")
+		}
+		for _, instance := range instances {
+			sessions, err := s.metadataStorage.GetSessions(ctx, instance)
+			if err != nil {
+				return errors.Wrap(err, "get sessions")
+			}
+			for _, session := range sessions {
+				if session.GetMetadata().GetFinishedAt() != nil {
+					continue
+				}
+				if err := s.recoverSession(ctx, session); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (s *storage) recoverSession(ctx context.Context, session *schema.Session) error {
+	sessionDesc := session.GetDescription()
+	path := s.sessionDataFile(s.instanceDir(sessionDesc.InstanceDescription), sessionDesc.Id)
+	if !utils.FileExists(path) {
+		return nil
+	}
+
+	report, err := checkAndRepairFile(path, true)
+	if err != nil {
+		return errors.Wrap(err, "repair data file")
+	}
+	if report.Damaged() {
+		s.logger.Warn().Err(report.Err).Fields(map[string]interface{}{
+			"data_file": report.Path,
+			"records":   report.Records,
+			"damaged":   report.Size - report.ValidSize,
+			"repaired":  report.Repaired,
+		}).Msg("Damaged data file")
+	}
+
+	finishedAt := report.LastObservedAt
+	if finishedAt.IsZero() {
+		if finishedAt, err = ptypes.Timestamp(session.GetMetadata().GetStartedAt()); err != nil {
+			return errors.Wrap(err, "convert started_at timestamp to time")
+		}
+	}
+	if err := s.metadataStorage.FinishSession(ctx, sessionDesc, finishedAt); err != nil {
+		return errors.Wrap(err, "finish session")
+	}
+	s.logger.Warn().Fields(map[string]interface{}{
+		"service":     sessionDesc.InstanceDescription.ServiceName,
+		"instance":    sessionDesc.InstanceDescription.InstanceName,
+		"session_id":  sessionDesc.Id,
+		"finished_at": finishedAt,
+	}).Msg("Dangling session closed")
+	return nil
+}
+
+// removeTmpFiles removes temporary files left by interrupted rewrites
+func removeTmpFiles(dataDir string) error {
+	return filepath.Walk(dataDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !info.IsDir() && strings.HasSuffix(path, tmpFileSuffix) {
+			return os.Remove(path)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,175 @@
+package filesystem
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/memprofiler/memprofiler/schema"
+	"github.com/memprofiler/memprofiler/server/config"
+	"github.com/memprofiler/memprofiler/server/storage/metadata"
+	"github.com/memprofiler/memprofiler/utils"
+)
+
+func TestRecovery(t *testing.T) {
+	logger := utils.NewLogger(&config.LoggingConfig{Level: zerolog.DebugLevel})
+
+	rootDir, err := ioutil.TempDir("", "memprofiler")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(rootDir)
+
+	metadataStorage, err := metadata.NewStorageSQLite(logger, &config.MetadataStorageConfig{DataDir: filepath.Join(rootDir, "metadata")})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer metadataStorage.Quit()
+
+	cfg := &config.FilesystemStorageConfig{DataDir: filepath.Join(rootDir, "data"), Format: config.BinaryFormat}
+	instanceDesc := &schema.InstanceDescription{ServiceName: "service", InstanceName: "instance"}
+	ctx := context.Background()
+
+	// the server has crashed during the session: the last record is torn, and the session is not finished
+	sessionDesc, err := metadataStorage.StartSession(ctx, instanceDesc)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	s := &storage{cfg: cfg}
+	dir := s.instanceDir(instanceDesc)
+	assert.NoError(t, os.MkdirAll(dir, dirPermissions))
+	path := s.sessionDataFile(dir, sessionDesc.Id)
+
+	fd, err := os.Create(path)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	c := newCodec(cfg)
+	assert.NoError(t, writeHeader(fd, c))
+	encoder := c.newEncoder(fd)
+	for i := int64(1); i <= 3; i++ {
+		assert.NoError(t, encoder.encode(&schema.Measurement{ObservedAt: &timestamp.Timestamp{Seconds: i * 1000}}))
+	}
+	info, err := fd.Stat()
+	assert.NoError(t, err)
+	validSize := info.Size()
+	_, err = fd.Write([]byte{100, 1, 2, 3})
+	assert.NoError(t, err)
+	assert.NoError(t, fd.Close())
+
+	// the record in the middle of another session file is damaged, so it can't be truncated safely
+	damagedDesc, err := metadataStorage.StartSession(ctx, instanceDesc)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	damagedPath := s.sessionDataFile(dir, damagedDesc.Id)
+	fd, err = os.Create(damagedPath)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.NoError(t, writeHeader(fd, c))
+	var damagedOffset int64
+	encoder = c.newEncoder(fd)
+	for i := int64(1); i <= 3; i++ {
+		assert.NoError(t, encoder.encode(&schema.Measurement{ObservedAt: &timestamp.Timestamp{Seconds: i * 1000}}))
+		if i == 2 {
+			info, err := fd.Stat()
+			assert.NoError(t, err)
+			damagedOffset = info.Size() - 1
+		}
+	}
+	_, err = fd.WriteAt([]byte{0xff}, damagedOffset)
+	assert.NoError(t, err)
+	info, err = fd.Stat()
+	assert.NoError(t, err)
+	damagedSize := info.Size()
+	assert.NoError(t, fd.Close())
+
+	// the session of another replica sharing metadata has no local data file
+	remoteDesc, err := metadataStorage.StartSession(ctx, &schema.InstanceDescription{ServiceName: "service", InstanceName: "remote"})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// temporary file has been left by interrupted rewrite
+	assert.NoError(t, ioutil.WriteFile(path+tmpFileSuffix, []byte("garbage"), filePermissions))
+
+	// check without repair reports damage only
+	reports, err := Check(cfg, false)
+	if assert.NoError(t, err) && assert.Len(t, reports, 2) {
+		report := findReport(reports, path)
+		assert.Equal(t, errTornRecord, report.Err)
+		assert.Equal(t, 3, report.Records)
+		assert.Equal(t, validSize, report.ValidSize)
+		assert.False(t, report.Repaired)
+
+		report = findReport(reports, damagedPath)
+		assert.Equal(t, errChecksumMismatch, report.Err)
+		assert.Equal(t, 1, report.Records)
+		assert.False(t, report.Torn())
+	}
+
+	// storage start repairs file and closes session
+	dataStorage, err := NewStorage(logger, cfg, metadataStorage)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer dataStorage.Quit()
+
+	info, err = os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, validSize, info.Size())
+	assert.False(t, utils.FileExists(path+tmpFileSuffix))
+
+	// damaged file is left as is and session is closed at the last valid measurement
+	info, err = os.Stat(damagedPath)
+	assert.NoError(t, err)
+	assert.Equal(t, damagedSize, info.Size())
+
+	sessions, err := metadataStorage.GetSessions(ctx, instanceDesc)
+	if assert.NoError(t, err) && assert.Len(t, sessions, 2) {
+		for _, session := range sessions {
+			finishedAt, err := ptypes.Timestamp(session.GetMetadata().GetFinishedAt())
+			assert.NoError(t, err)
+			switch session.GetDescription().GetId() {
+			case sessionDesc.Id:
+				assert.Equal(t, time.Unix(3000, 0).UTC(), finishedAt)
+			case damagedDesc.Id:
+				assert.Equal(t, time.Unix(1000, 0).UTC(), finishedAt)
+			}
+		}
+	}
+
+	// session of another replica is not finished
+	sessions, err = metadataStorage.GetSessions(ctx, remoteDesc.InstanceDescription)
+	if assert.NoError(t, err) && assert.Len(t, sessions, 1) {
+		assert.Nil(t, sessions[0].GetMetadata().GetFinishedAt())
+	}
+
+	reports, err = Check(cfg, true)
+	if assert.NoError(t, err) && assert.Len(t, reports, 2) {
+		assert.False(t, findReport(reports, path).Damaged())
+		assert.True(t, findReport(reports, damagedPath).Damaged())
+		assert.False(t, findReport(reports, damagedPath).Repaired)
+	}
+	info, err = os.Stat(damagedPath)
+	assert.NoError(t, err)
+	assert.Equal(t, damagedSize, info.Size())
+}
+
+func findReport(reports []*FileReport, path string) *FileReport {
+	for _, report := range reports {
+		if report.Path == path {
+			return report
+		}
+	}
+	return &FileReport{}
+}
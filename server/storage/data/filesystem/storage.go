@@ -1,8 +1,10 @@
 package filesystem
 
 import (
+	"bufio"
 	"context"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sync"
@@ -23,11 +25,12 @@ var _ data.Storage = (*storage)(nil)
 // storage uses filesystem as a persistent storage;
 type storage struct {
 	metadataStorage metadata.Storage
-	codec           codec
+	codec           codec // used for new data files
 	cfg             *config.FilesystemStorageConfig
 	ctx             context.Context
 	cancel          context.CancelFunc
 	wg              sync.WaitGroup
+	filesMutex      sync.Mutex // serializes rewrites of session files with their deletion
 	logger          *zerolog.Logger
 }
 
@@ -67,7 +70,12 @@ func (s *storage) NewDataSaver(instanceDesc *schema.InstanceDescription) (data.S
 	dataFile := s.sessionDataFile(instanceDir, sessionDesc.Id)
 	s.logger.Info().Fields(map[string]interface{}{"data_file": dataFile}).Msg("Starting new session")
 	s.wg.Add(1)
-	return newDataSaver(dataFile, sessionDesc, s.cfg, &s.wg, s.codec, s.metadataStorage)
+	saver, err := newDataSaver(dataFile, sessionDesc, s.cfg, &s.wg, s.codec, s.metadataStorage)
+	if err != nil {
+		s.wg.Done()
+		return nil, err
+	}
+	return saver, nil
 }
 
 func (s *storage) NewDataLoader(sessionDesc *schema.SessionDescription) (data.Loader, error) {
@@ -78,7 +86,132 @@ func (s *storage) NewDataLoader(sessionDesc *schema.SessionDescription) (data.Lo
 		s.wg.Add(1)
 	}
 	filename := s.sessionDataFile(s.instanceDir(sessionDesc.InstanceDescription), sessionDesc.Id)
-	return newDataLoader(filename, sessionDesc, s.codec, s.logger, &s.wg)
+
+	files, err := s.openSessionFiles(filename)
+	if err != nil {
+		s.wg.Done()
+		return nil, err
+	}
+	return newDataLoader(filename, files, sessionDesc, s.logger, &s.wg), nil
+}
+
+// sessionFiles contains the opened data file of a session together with the contents of its sidecar files
+type sessionFiles struct {
+	fd          *os.File
+	reader      *bufio.Reader
+	decoder     decoder // defined by the data file header
+	index       *sessionIndex
+	downsampled []*schema.Measurement
+}
+
+// openSessionFiles opens data file and reads its sidecar files; they're replaced by downsampling,
+// so all of them are read under the lock to refer the same version of session data
+func (s *storage) openSessionFiles(filename string) (*sessionFiles, error) {
+	s.filesMutex.Lock()
+	defer s.filesMutex.Unlock()
+
+	fd, err := os.Open(filepath.Clean(filename))
+	if err != nil {
+		return nil, err
+	}
+
+	// choose codec according to the file header
+	files := &sessionFiles{fd: fd, reader: bufio.NewReader(fd)}
+	codec, err := readHeader(files.reader)
+	if err != nil {
+		_ = fd.Close()
+		return nil, errors.Wrap(err, "read data file header")
+	}
+	files.decoder = codec.newDecoder(files.reader)
+
+	// index may be missing (e.g. in files written by older versions)
+	if files.index, err = readIndex(indexFile(filename)); err != nil && !os.IsNotExist(err) {
+		_ = fd.Close()
+		return nil, errors.Wrap(err, "read index")
+	}
+
+	files.downsampled, err = readMeasurements(downsampledFile(filename))
+	if err != nil && !os.IsNotExist(err) {
+		_ = fd.Close()
+		return nil, errors.Wrap(err, "read downsampled measurements")
+	}
+	return files, nil
+}
+
+func (s *storage) SessionSize(sessionDesc *schema.SessionDescription) (int64, error) {
+	filename := s.sessionDataFile(s.instanceDir(sessionDesc.InstanceDescription), sessionDesc.Id)
+
+	var size int64
+	for _, path := range []string{filename, indexFile(filename), downsampledFile(filename)} {
+		info, err := os.Stat(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return 0, errors.Wrap(err, "stat session file")
+		}
+		size += info.Size()
+	}
+	return size, nil
+}
+
+func (s *storage) DeleteSessions(ctx context.Context, sessionDescs []*schema.SessionDescription) (int64, error) {
+	var reclaimed int64
+	for _, sessionDesc := range sessionDescs {
+		select {
+		case <-ctx.Done():
+			return reclaimed, ctx.Err()
+		default:
+		}
+
+		size, err := s.deleteSession(sessionDesc)
+		reclaimed += size
+		if err != nil {
+			return reclaimed, err
+		}
+	}
+	return reclaimed, nil
+}
+
+func (s *storage) deleteSession(sessionDesc *schema.SessionDescription) (int64, error) {
+	// session files can't be deleted while they're rewritten
+	s.filesMutex.Lock()
+	defer s.filesMutex.Unlock()
+
+	size, err := s.SessionSize(sessionDesc)
+	if err != nil {
+		return 0, err
+	}
+
+	instanceDir := s.instanceDir(sessionDesc.InstanceDescription)
+	filename := s.sessionDataFile(instanceDir, sessionDesc.Id)
+	if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+		return 0, errors.Wrap(err, "remove session data file")
+	}
+	if err := os.Remove(indexFile(filename)); err != nil && !os.IsNotExist(err) {
+		return 0, errors.Wrap(err, "remove session index file")
+	}
+	if err := os.Remove(downsampledFile(filename)); err != nil && !os.IsNotExist(err) {
+		return 0, errors.Wrap(err, "remove downsampled measurements file")
+	}
+	s.logger.Info().Fields(map[string]interface{}{"data_file": filename}).Msg("Session data deleted")
+
+	// drop directory of the instance that has no more sessions
+	return size, removeEmptyDir(instanceDir)
+}
+
+func removeEmptyDir(dir string) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Wrap(err, "read directory")
+	}
+	if len(files) > 0 {
+		return nil
+	}
+	return errors.Wrap(os.Remove(dir), "remove empty directory")
 }
 
 // instanceDir builds a path for a filesystem direcory with instance data
@@ -120,7 +253,7 @@ func NewStorage(
 	ctx, cancel := context.WithCancel(context.Background())
 
 	s := &storage{
-		codec:           newJSONCodec(),
+		codec:           newCodec(cfg),
 		metadataStorage: metadataStorage,
 		cfg:             cfg,
 		ctx:             ctx,
@@ -129,5 +262,10 @@ func NewStorage(
 		logger:          logger,
 	}
 
+	// the previous run could have been interrupted
+	if err := s.recover(ctx); err != nil {
+		return nil, errors.Wrap(err, "recover storage")
+	}
+
 	return s, nil
 }
@@ -3,9 +3,11 @@ package data
 import (
 	"context"
 	"io"
+	"time"
 
 	"github.com/memprofiler/memprofiler/schema"
 	"github.com/memprofiler/memprofiler/server/common"
+	"github.com/memprofiler/memprofiler/server/config"
 )
 
 // Storage provides interface for storing and loading service measurements
@@ -13,6 +15,15 @@ import (
 type Storage interface {
 	NewDataSaver(description *schema.InstanceDescription) (Saver, error)
 	NewDataLoader(*schema.SessionDescription) (Loader, error)
+	// SessionSize returns disk space occupied by the session data [bytes]
+	SessionSize(*schema.SessionDescription) (int64, error)
+	// DeleteSessions removes the data of finished sessions and returns the amount of reclaimed disk space [bytes]
+	DeleteSessions(context.Context, []*schema.SessionDescription) (int64, error)
+	// Downsample rewrites the measurements of finished sessions into coarser resolution according to their age;
+	// loaders return downsampled measurements along with the raw ones
+	Downsample(ctx context.Context, sds []*schema.SessionDescription, tiers []config.DownsamplingTier, now time.Time) error
+	// SessionBounds returns time bounds and the number of callstacks of the session data; nil means no data
+	SessionBounds(context.Context, *schema.SessionDescription) (*schema.SessionBounds, error)
 	common.Subsystem
 }
 
@@ -29,6 +40,8 @@ type Saver interface {
 type Loader interface {
 	// Load loads all the measurements that belong to the particular service;
 	Load(context.Context) (<-chan *LoadResult, error)
+	// LoadRange loads the measurements observed within [from, to] time range; zero values mean no bound
+	LoadRange(ctx context.Context, from, to time.Time) (<-chan *LoadResult, error)
 	io.Closer
 }
 
@@ -2,6 +2,7 @@ package data
 
 import (
 	"context"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -19,5 +20,11 @@ func (m *LoaderMock) Load(ctx context.Context) (<-chan *LoadResult, error) {
 	return args.Get(0).(chan *LoadResult), args.Error(1)
 }
 
+// LoadRange ...
+func (m *LoaderMock) LoadRange(ctx context.Context, from, to time.Time) (<-chan *LoadResult, error) {
+	args := m.Called(ctx, from, to)
+	return args.Get(0).(chan *LoadResult), args.Error(1)
+}
+
 // Close ...
 func (m *LoaderMock) Close() error { return m.Called().Error(0) }
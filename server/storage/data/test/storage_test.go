@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/golang/protobuf/ptypes/timestamp"
 
@@ -278,3 +279,205 @@ func newStorage(t *testing.T, dataStorageType config.DataStorageType) data.Stora
 
 	return dataStorage
 }
+
+// TestStorageDeleteSessions checks that session data is removed from storage
+func TestStorageDeleteSessions(t *testing.T) {
+	input := []*schema.Measurement{
+		{
+			ObservedAt: &timestamp.Timestamp{Seconds: 1},
+			Locations: []*schema.Location{
+				{
+					MemoryUsage: &schema.MemoryUsage{AllocBytes: 1},
+					Callstack:   &schema.Callstack{Id: "abcd", Frames: []*schema.StackFrame{{Name: "a", File: "b.go", Line: 1}}},
+				},
+			},
+		},
+	}
+
+	for _, dataStorageType := range []config.DataStorageType{config.FilesystemDataStorage, config.TSDBDataStorage} {
+		s := newStorage(t, dataStorageType)
+
+		saver, err := s.NewDataSaver(&schema.InstanceDescription{ServiceName: "service", InstanceName: "instance"})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		for _, mm := range input {
+			assert.NoError(t, saver.Save(mm))
+		}
+		assert.NoError(t, saver.Close())
+		sd := saver.SessionDescription()
+
+		size, err := s.SessionSize(sd)
+		assert.NoError(t, err)
+		assert.True(t, size > 0)
+
+		_, err = s.DeleteSessions(context.Background(), []*schema.SessionDescription{sd})
+		assert.NoError(t, err)
+
+		size, err = s.SessionSize(sd)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), size)
+
+		s.Quit()
+	}
+}
+
+// TestStorageDownsample checks that downsampled measurements are loaded instead of raw ones
+func TestStorageDownsample(t *testing.T) {
+	var (
+		callstack = &schema.Callstack{Id: "abcd", Frames: []*schema.StackFrame{{Name: "a", File: "b.go", Line: 1}}}
+		now       = time.Unix(7200, 0)
+		tiers     = []config.DownsamplingTier{{After: time.Hour, Resolution: time.Minute}}
+		input     []*schema.Measurement
+	)
+
+	// two hours of measurements every 10 seconds
+	for i := 0; i < 720; i++ {
+		input = append(input, &schema.Measurement{
+			ObservedAt: &timestamp.Timestamp{Seconds: int64(i * 10)},
+			Locations: []*schema.Location{
+				{MemoryUsage: &schema.MemoryUsage{AllocBytes: int64(i)}, Callstack: callstack},
+			},
+		})
+	}
+
+	for _, dataStorageType := range []config.DataStorageType{config.FilesystemDataStorage, config.TSDBDataStorage} {
+		s := newStorage(t, dataStorageType)
+
+		saver, err := s.NewDataSaver(&schema.InstanceDescription{ServiceName: "service", InstanceName: "instance"})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		for _, mm := range input {
+			assert.NoError(t, saver.Save(mm))
+		}
+		assert.NoError(t, saver.Close())
+		sd := saver.SessionDescription()
+
+		// loader created before downsampling reads consistent data
+		staleLoader, err := s.NewDataLoader(sd)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		// downsampling twice gives the same result
+		assert.NoError(t, s.Downsample(context.Background(), []*schema.SessionDescription{sd}, tiers, now))
+		assert.NoError(t, s.Downsample(context.Background(), []*schema.SessionDescription{sd}, tiers, now))
+
+		results, err := staleLoader.LoadRange(context.Background(), time.Unix(5000, 0), time.Unix(6000, 0))
+		assert.NoError(t, err)
+		var loaded []int64
+		for result := range results {
+			if assert.NoError(t, result.Err, dataStorageType) {
+				loaded = append(loaded, result.Measurement.GetObservedAt().GetSeconds())
+			}
+		}
+		if assert.Len(t, loaded, 101, dataStorageType) {
+			assert.Equal(t, int64(5000), loaded[0])
+			assert.Equal(t, int64(6000), loaded[100])
+		}
+		assert.NoError(t, staleLoader.Close())
+
+		loader, err := s.NewDataLoader(sd)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		results, err = loader.Load(context.Background())
+		assert.NoError(t, err)
+
+		var output []*schema.Measurement
+		for result := range results {
+			assert.NoError(t, result.Err)
+			output = append(output, result.Measurement)
+		}
+		assert.NoError(t, loader.Close())
+
+		// the first hour is merged into 1-minute buckets, the second one is untouched
+		if assert.Len(t, output, 60+360, dataStorageType) {
+			location := output[0].Locations[0]
+			assert.Equal(t, int64(50), output[0].ObservedAt.Seconds)
+			assert.Equal(t, int64(60), output[0].Resolution.Seconds)
+			assert.Equal(t, int64(5), location.MemoryUsage.AllocBytes)
+			assert.Equal(t, int64(0), location.MinMemoryUsage.AllocBytes)
+			assert.Equal(t, int64(5), location.MaxMemoryUsage.AllocBytes)
+			assert.Nil(t, output[60].Resolution)
+			assert.Equal(t, int64(3600), output[60].ObservedAt.Seconds)
+		}
+
+		// range covering both downsampled and raw measurements
+		loader, err = s.NewDataLoader(sd)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		results, err = loader.LoadRange(context.Background(), time.Unix(3000, 0), time.Unix(4000, 0))
+		assert.NoError(t, err)
+		var downsampled, raw int
+		for result := range results {
+			assert.NoError(t, result.Err)
+			if result.Measurement.GetResolution() != nil {
+				downsampled++
+			} else {
+				raw++
+			}
+		}
+		assert.NoError(t, loader.Close())
+		assert.Equal(t, 10, downsampled, dataStorageType)
+		assert.Equal(t, 41, raw, dataStorageType)
+
+		// deleted session is not restored by downsampling
+		_, err = s.DeleteSessions(context.Background(), []*schema.SessionDescription{sd})
+		assert.NoError(t, err)
+		assert.NoError(t, s.Downsample(context.Background(), []*schema.SessionDescription{sd}, tiers, now.Add(time.Hour)))
+		size, err := s.SessionSize(sd)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), size, dataStorageType)
+
+		s.Quit()
+	}
+}
+
+// TestStorageLoadRange checks range loads and session bounds
+func TestStorageLoadRange(t *testing.T) {
+	callstack := &schema.Callstack{Id: "abcd", Frames: []*schema.StackFrame{{Name: "a", File: "b.go", Line: 1}}}
+
+	for _, dataStorageType := range []config.DataStorageType{config.FilesystemDataStorage, config.TSDBDataStorage} {
+		s := newStorage(t, dataStorageType)
+
+		saver, err := s.NewDataSaver(&schema.InstanceDescription{ServiceName: "service", InstanceName: "instance"})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		for i := int64(1); i <= 10; i++ {
+			assert.NoError(t, saver.Save(&schema.Measurement{
+				ObservedAt: &timestamp.Timestamp{Seconds: i},
+				Locations:  []*schema.Location{{MemoryUsage: &schema.MemoryUsage{AllocBytes: i}, Callstack: callstack}},
+			}))
+		}
+		assert.NoError(t, saver.Close())
+		sd := saver.SessionDescription()
+
+		loader, err := s.NewDataLoader(sd)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		results, err := loader.LoadRange(context.Background(), time.Unix(3, 0), time.Unix(5, 0))
+		assert.NoError(t, err)
+		var observedAt []int64
+		for result := range results {
+			assert.NoError(t, result.Err)
+			observedAt = append(observedAt, result.Measurement.GetObservedAt().GetSeconds())
+		}
+		assert.NoError(t, loader.Close())
+		assert.Equal(t, []int64{3, 4, 5}, observedAt, dataStorageType)
+
+		bounds, err := s.SessionBounds(context.Background(), sd)
+		assert.NoError(t, err)
+		assert.Equal(t, &schema.SessionBounds{
+			FirstObservedAt: &timestamp.Timestamp{Seconds: 1},
+			LastObservedAt:  &timestamp.Timestamp{Seconds: 10},
+			Callstacks:      1,
+		}, bounds, dataStorageType)
+
+		s.Quit()
+	}
+}
@@ -0,0 +1,164 @@
+package tsdb
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/pkg/errors"
+	"github.com/prometheus/tsdb/labels"
+
+	"github.com/memprofiler/memprofiler/schema"
+)
+
+// callstacksFile keeps callstacks referred by series labels, one JSON record per line
+const callstacksFile = "callstacks"
+
+// callstackDictionary maps callstack ids to callstacks: series are labeled with ids only,
+// and every callstack is persisted before the first series referring it
+type callstackDictionary struct {
+	callstacks map[string]*schema.Callstack
+	fd         *os.File
+	marshaller *jsonpb.Marshaler
+	legacy     codec // decodes callstacks kept in labels by older versions
+	mutex      sync.RWMutex
+}
+
+// put stores callstacks that haven't been stored yet; new callstacks are written at once,
+// so the file is synced once per batch
+func (d *callstackDictionary) put(css ...*schema.Callstack) error {
+	var unknown []*schema.Callstack
+	d.mutex.RLock()
+	for _, cs := range css {
+		if cs.GetId() == "" {
+			d.mutex.RUnlock()
+			return fmt.Errorf("callstack without id")
+		}
+		if _, exists := d.callstacks[cs.GetId()]; !exists {
+			unknown = append(unknown, cs)
+		}
+	}
+	d.mutex.RUnlock()
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	var (
+		buf     bytes.Buffer
+		written = make(map[string]*schema.Callstack, len(unknown))
+	)
+	for _, cs := range unknown {
+		if _, exists := d.callstacks[cs.GetId()]; exists {
+			continue
+		}
+		if _, exists := written[cs.GetId()]; exists {
+			continue
+		}
+		if err := d.marshaller.Marshal(&buf, cs); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+		written[cs.GetId()] = cs
+	}
+	if len(written) == 0 {
+		return nil
+	}
+
+	if _, err := d.fd.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	if err := d.fd.Sync(); err != nil {
+		return err
+	}
+
+	for id, cs := range written {
+		d.callstacks[id] = cs
+	}
+	return nil
+}
+
+// resolve returns callstack of a series
+func (d *callstackDictionary) resolve(lbls labels.Labels) (*schema.Callstack, error) {
+	if id := lbls.Get(callstackLabelName); id != "" {
+		d.mutex.RLock()
+		cs, exists := d.callstacks[id]
+		d.mutex.RUnlock()
+		if !exists {
+			return nil, fmt.Errorf("unknown callstack %s", id)
+		}
+		return cs, nil
+	}
+
+	if meta := lbls.Get(metaLabelName); meta != "" {
+		cs := &schema.Callstack{}
+		if err := d.legacy.decode(meta, cs); err != nil {
+			return nil, errors.Wrap(err, "decode legacy callstack label")
+		}
+		return cs, nil
+	}
+
+	return nil, fmt.Errorf("series %s has no callstack", lbls.String())
+}
+
+func (d *callstackDictionary) Close() error { return d.fd.Close() }
+
+// openCallstackDictionary loads callstacks and opens dictionary file for appending;
+// the torn record left by crash is dropped
+func openCallstackDictionary(dataDir string) (*callstackDictionary, error) {
+	filename := filepath.Join(dataDir, callstacksFile)
+
+	fd, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0640)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &callstackDictionary{
+		callstacks: make(map[string]*schema.Callstack),
+		fd:         fd,
+		marshaller: &jsonpb.Marshaler{EnumsAsInts: true},
+		legacy:     newB64Codec(),
+	}
+
+	var (
+		reader = bufio.NewReader(fd)
+		offset int64
+	)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			_ = fd.Close()
+			return nil, errors.Wrap(err, "read callstacks")
+		}
+
+		cs := &schema.Callstack{}
+		if err := jsonpb.Unmarshal(bytes.NewReader(line), cs); err != nil {
+			_ = fd.Close()
+			return nil, errors.Wrap(err, "decode callstack")
+		}
+		d.callstacks[cs.GetId()] = cs
+		offset += int64(len(line))
+	}
+
+	// drop incomplete record and continue writing after the last complete one
+	if err := fd.Truncate(offset); err != nil {
+		_ = fd.Close()
+		return nil, errors.Wrap(err, "truncate callstacks")
+	}
+	if _, err := fd.Seek(offset, io.SeekStart); err != nil {
+		_ = fd.Close()
+		return nil, errors.Wrap(err, "seek callstacks")
+	}
+
+	return d, nil
+}
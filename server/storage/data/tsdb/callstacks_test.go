@@ -0,0 +1,107 @@
+package tsdb
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/tsdb/labels"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/memprofiler/memprofiler/schema"
+)
+
+func TestCallstackDictionary(t *testing.T) {
+	dataDir, err := ioutil.TempDir("", "memprofiler")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(dataDir)
+
+	cs := &schema.Callstack{
+		Id:     "1",
+		Frames: []*schema.StackFrame{{Name: "main.main", File: "main.go", Line: 10}},
+	}
+
+	d, err := openCallstackDictionary(dataDir)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.NoError(t, d.put(cs))
+	assert.NoError(t, d.put(cs))
+	assert.Error(t, d.put(&schema.Callstack{}))
+	assert.NoError(t, d.Close())
+
+	// torn record left by crash
+	fd, err := os.OpenFile(filepath.Join(dataDir, callstacksFile), os.O_WRONLY|os.O_APPEND, 0640)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	_, err = fd.WriteString(`{"id":"2","fra`)
+	assert.NoError(t, err)
+	assert.NoError(t, fd.Close())
+
+	d, err = openCallstackDictionary(dataDir)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer d.Close()
+
+	resolved, err := d.resolve(labels.Labels{callstackLabel(cs)})
+	assert.NoError(t, err)
+	assert.Equal(t, cs.String(), resolved.String())
+
+	_, err = d.resolve(labels.Labels{{Name: callstackLabelName, Value: "2"}})
+	assert.Error(t, err)
+
+	// dictionary is still appendable after the torn record has been dropped
+	assert.NoError(t, d.put(&schema.Callstack{Id: "2"}))
+	_, err = d.resolve(labels.Labels{{Name: callstackLabelName, Value: "2"}})
+	assert.NoError(t, err)
+
+	// series written by older versions keep callstacks in labels
+	meta, err := newB64Codec().encode(cs)
+	assert.NoError(t, err)
+	resolved, err = d.resolve(labels.Labels{{Name: metaLabelName, Value: meta}})
+	assert.NoError(t, err)
+	assert.Equal(t, cs.String(), resolved.String())
+}
+
+func TestCallstackDictionary_Batch(t *testing.T) {
+	dataDir, err := ioutil.TempDir("", "memprofiler")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(dataDir)
+
+	d, err := openCallstackDictionary(dataDir)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.NoError(t, d.put(&schema.Callstack{Id: "1"}))
+
+	// known and repeated callstacks are written once
+	assert.NoError(t, d.put(&schema.Callstack{Id: "1"}, &schema.Callstack{Id: "2"}, &schema.Callstack{Id: "2"}, &schema.Callstack{Id: "3"}))
+
+	// batch with invalid callstack is rejected as a whole
+	assert.Error(t, d.put(&schema.Callstack{Id: "4"}, &schema.Callstack{}))
+	assert.NoError(t, d.Close())
+
+	content, err := ioutil.ReadFile(filepath.Join(dataDir, callstacksFile))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, bytes.Count(content, []byte("\n")))
+
+	d, err = openCallstackDictionary(dataDir)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer d.Close()
+	for _, id := range []string{"1", "2", "3"} {
+		_, err = d.resolve(labels.Labels{{Name: callstackLabelName, Value: id}})
+		assert.NoError(t, err)
+	}
+	_, err = d.resolve(labels.Labels{{Name: callstackLabelName, Value: "4"}})
+	assert.Error(t, err)
+}
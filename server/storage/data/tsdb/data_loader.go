@@ -2,10 +2,10 @@ package tsdb
 
 import (
 	"context"
-	"fmt"
+	"math"
 	"sync"
+	"time"
 
-	"github.com/prometheus/tsdb/labels"
 	"github.com/rs/zerolog"
 
 	"github.com/memprofiler/memprofiler/schema"
@@ -18,21 +18,33 @@ const (
 )
 
 type defaultDataLoader struct {
-	storage prometheus.TSDB
-	codec   codec
-	sd      *schema.SessionDescription
-	logger  *zerolog.Logger
-	wg      *sync.WaitGroup
+	storage         prometheus.TSDB
+	callstacks      *callstackDictionary
+	sd              *schema.SessionDescription
+	downsampledFile string
+	logger          *zerolog.Logger
+	wg              *sync.WaitGroup
 }
 
-// Load read data from TSDB
 func (l *defaultDataLoader) Load(ctx context.Context) (<-chan *data.LoadResult, error) {
-	var sessionLabel = labels.Label{
-		Name:  sessionLabelName,
-		Value: fmt.Sprintf("%d", l.sd.GetId()),
+	return l.LoadRange(ctx, time.Time{}, time.Time{})
+}
+
+// LoadRange reads downsampled measurements first, and the measurements kept in TSDB then
+func (l *defaultDataLoader) LoadRange(ctx context.Context, from, to time.Time) (<-chan *data.LoadResult, error) {
+	downsampled, err := readDownsampled(l.downsampledFile)
+	if err != nil {
+		return nil, err
 	}
 
-	li, err := NewMeasurementIterator(l.storage, l.codec, sessionLabel)
+	mint, maxt := int64(0), toTimestamp(time.Now())
+	if !from.IsZero() {
+		mint = toTimestamp(from)
+	}
+	if !to.IsZero() {
+		maxt = toTimestamp(to)
+	}
+	li, err := NewMeasurementIterator(l.storage, l.callstacks, sessionLabel(l.sd), mint, maxt)
 	if err != nil {
 		return nil, err
 	}
@@ -41,17 +53,43 @@ func (l *defaultDataLoader) Load(ctx context.Context) (<-chan *data.LoadResult,
 	results := make(chan *data.LoadResult, loadChanCapacity)
 	go func() {
 		defer close(results)
+		// blocks can't be deleted until querier is closed
+		defer func() {
+			if err := li.Close(); err != nil {
+				l.logger.Error().Err(err).Msg("close measurement iterator")
+			}
+		}()
+
+		var last int64 = math.MinInt64
+		for _, measurement := range downsampled {
+			if !data.InRange(measurement.GetObservedAt(), from, to) {
+				continue
+			}
+			select {
+			case results <- &data.LoadResult{Measurement: measurement}:
+			case <-ctx.Done():
+				return
+			}
+			last = protoToTimestamp(measurement.GetObservedAt())
+		}
+
 		for li.Next() {
 			var (
 				measurement = li.At()
 				err         = li.Error()
 			)
 
+			// skip the samples that have been downsampled, but not deleted yet
+			if err == nil && protoToTimestamp(measurement.GetObservedAt()) <= last {
+				continue
+			}
+
 			m := &data.LoadResult{Measurement: measurement, Err: err}
 
 			select {
 			case results <- m:
 			case <-ctx.Done():
+				return
 			}
 		}
 	}()
@@ -66,10 +104,11 @@ func (l *defaultDataLoader) Close() error {
 
 func newDataLoader(
 	sessionDesc *schema.SessionDescription,
-	codec codec,
+	callstacks *callstackDictionary,
 	logger *zerolog.Logger,
 	wg *sync.WaitGroup,
 	stor prometheus.TSDB,
+	downsampledFile string,
 ) (data.Loader, error) {
 	// open file to load records
 	contextLogger := logger.With().Fields(map[string]interface{}{
@@ -79,11 +118,12 @@ func newDataLoader(
 	}).Logger()
 
 	loader := &defaultDataLoader{
-		storage: stor,
-		sd:      sessionDesc,
-		codec:   codec,
-		logger:  &contextLogger,
-		wg:      wg,
+		storage:         stor,
+		sd:              sessionDesc,
+		callstacks:      callstacks,
+		downsampledFile: downsampledFile,
+		logger:          &contextLogger,
+		wg:              wg,
 	}
 	return loader, nil
 }
@@ -2,7 +2,6 @@ package tsdb
 
 import (
 	"context"
-	"fmt"
 	"sync"
 
 	"github.com/pkg/errors"
@@ -13,6 +12,7 @@ import (
 	"github.com/memprofiler/memprofiler/server/storage/metadata"
 
 	"github.com/golang/protobuf/ptypes"
+	"github.com/prometheus/tsdb"
 	"github.com/prometheus/tsdb/labels"
 )
 
@@ -22,50 +22,94 @@ var _ data.Saver = (*defaultDataSaver)(nil)
 type defaultDataSaver struct {
 	tsdbStorage     prometheus.TSDB
 	metadataStorage metadata.Storage
-	codec           codec
+	callstacks      *callstackDictionary
 	sessionDesc     *schema.SessionDescription
+	metrics         []*schema.Metric
+	refs            map[string][]uint64 // series references of every stored metric by callstack id
 	wg              *sync.WaitGroup
 }
 
-// Save store Measurement to TSDB
+// Save store Measurement to TSDB; all samples of a measurement are committed at once
 func (s *defaultDataSaver) Save(mm *schema.Measurement) error {
-	var (
-		sessionLabel = labels.Label{Name: sessionLabelName, Value: fmt.Sprintf("%v", s.SessionDescription().GetId())}
-		location     = mm.GetLocations()
-	)
-
-	time, err := ptypes.Timestamp(mm.GetObservedAt())
+	observedAt, err := ptypes.Timestamp(mm.GetObservedAt())
 	if err != nil {
 		return err
 	}
+	t := toTimestamp(observedAt)
 
-	for _, l := range location {
-		appender := s.tsdbStorage.Appender()
-		mu := l.GetMemoryUsage()
-		callStack, err := s.codec.encode(l.GetCallstack())
+	var (
+		appender = s.tsdbStorage.Appender()
+		// references of new series are cached only after commit
+		newRefs    = make(map[string][]uint64)
+		callstacks = make([]*schema.Callstack, 0, len(mm.GetLocations()))
+	)
+	for _, l := range mm.GetLocations() {
+		refs, err := s.appendLocation(appender, l, t)
 		if err != nil {
-			return err
+			_ = appender.Rollback()
+			return errors.Wrap(err, "append samples")
 		}
-
-		metaLabel := labels.Label{Name: metaLabelName, Value: callStack}
-		mi := measurementsInfo{
-			{labels.Labels{sessionLabel, metaLabel, allocBytesLabel()}, float64(mu.GetAllocBytes())},
-			{labels.Labels{sessionLabel, metaLabel, allocObjectsLabel()}, float64(mu.GetAllocObjects())},
-			{labels.Labels{sessionLabel, metaLabel, freeBytesLabel()}, float64(mu.GetFreeBytes())},
-			{labels.Labels{sessionLabel, metaLabel, freeObjectsLabel()}, float64(mu.GetFreeObjects())},
+		if refs != nil {
+			newRefs[l.GetCallstack().GetId()] = refs
 		}
-		for i := range mi {
-			_, err = appender.Add(mi[i].Labels, time.Unix(), mi[i].Value)
-			if err != nil {
-				return err
-			}
+		callstacks = append(callstacks, l.GetCallstack())
+	}
+
+	// callstacks must be persisted before the series referring them
+	if err := s.callstacks.put(callstacks...); err != nil {
+		_ = appender.Rollback()
+		return errors.Wrap(err, "put callstacks")
+	}
+
+	if err := appender.Commit(); err != nil {
+		return errors.Wrap(err, "commit samples")
+	}
+	for id, refs := range newRefs {
+		s.refs[id] = refs
+	}
+	return nil
+}
+
+// appendLocation appends samples of every stored metric of the location;
+// references are returned if location series haven't been cached yet or have been dropped from TSDB head
+func (s *defaultDataSaver) appendLocation(appender tsdb.Appender, l *schema.Location, t int64) ([]uint64, error) {
+	var (
+		mu       = l.GetMemoryUsage()
+		refs     = make([]uint64, len(s.metrics))
+		appended int
+	)
+
+	if cached, exists := s.refs[l.GetCallstack().GetId()]; exists {
+		var err error
+		appended, err = s.appendCached(appender, cached, mu, t)
+		if errors.Cause(err) != tsdb.ErrNotFound {
+			return nil, err
 		}
-		err = appender.Commit()
+		// samples that have been appended already must not be added twice
+		copy(refs, cached[:appended])
+	}
+
+	session := sessionLabel(s.sessionDesc)
+	callstack := callstackLabel(l.GetCallstack())
+	for i := appended; i < len(s.metrics); i++ {
+		metric := s.metrics[i]
+		ref, err := appender.Add(labels.Labels{session, callstack, metricLabel(metric)}, t, metric.Value(mu))
 		if err != nil {
-			return err
+			return nil, err
 		}
+		refs[i] = ref
 	}
-	return nil
+	return refs, nil
+}
+
+// appendCached appends samples using cached series references; the number of appended samples is returned
+func (s *defaultDataSaver) appendCached(appender tsdb.Appender, refs []uint64, mu *schema.MemoryUsage, t int64) (int, error) {
+	for i, metric := range s.metrics {
+		if err := appender.AddFast(refs[i], t, metric.Value(mu)); err != nil {
+			return i, err
+		}
+	}
+	return len(s.metrics), nil
 }
 
 // Close close data saver
@@ -75,9 +119,7 @@ func (s *defaultDataSaver) Close() error {
 	if err := s.metadataStorage.StopSession(context.Background(), s.sessionDesc); err != nil {
 		return errors.Wrap(err, "stop session")
 	}
-	if err := s.tsdbStorage.Close(); err != nil {
-		return errors.Wrap(err, "close TSDB storage")
-	}
+	// TSDB is shared by all sessions, so it's closed by storage
 	return nil
 }
 
@@ -86,15 +128,17 @@ func (s *defaultDataSaver) SessionDescription() *schema.SessionDescription { ret
 
 func newDataSaver(
 	sessionDesc *schema.SessionDescription,
-	codec codec,
+	callstacks *callstackDictionary,
 	wg *sync.WaitGroup,
 	tsdbStorage prometheus.TSDB,
 	metadataStorage metadata.Storage,
 ) (data.Saver, error) {
 	saver := &defaultDataSaver{
 		tsdbStorage:     tsdbStorage,
-		codec:           codec,
+		callstacks:      callstacks,
 		sessionDesc:     sessionDesc,
+		metrics:         schema.StoredMetrics(),
+		refs:            make(map[string][]uint64),
 		metadataStorage: metadataStorage,
 		wg:              wg,
 	}
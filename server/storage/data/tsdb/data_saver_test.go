@@ -0,0 +1,238 @@
+package tsdb
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/prometheus/tsdb"
+	"github.com/prometheus/tsdb/labels"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/memprofiler/memprofiler/schema"
+	"github.com/memprofiler/memprofiler/server/config"
+	"github.com/memprofiler/memprofiler/server/storage/data/tsdb/prometheus"
+	"github.com/memprofiler/memprofiler/utils"
+)
+
+// BenchmarkSave measures throughput of saving measurements with 10k locations (one measurement per op)
+func BenchmarkSave(b *testing.B) {
+	const locations = 10000
+
+	logger := utils.NewLogger(&config.LoggingConfig{Level: zerolog.WarnLevel})
+
+	dataDir, err := ioutil.TempDir("", "memprofiler")
+	if !assert.NoError(b, err) {
+		b.FailNow()
+	}
+	defer os.RemoveAll(dataDir)
+
+	goKitWrapper, err := NewGoKitLogWrapper(logger)
+	if !assert.NoError(b, err) {
+		b.FailNow()
+	}
+	db, err := prometheus.OpenTSDB(dataDir, goKitWrapper)
+	if !assert.NoError(b, err) {
+		b.FailNow()
+	}
+	defer db.Close()
+
+	callstacks, err := openCallstackDictionary(dataDir)
+	if !assert.NoError(b, err) {
+		b.FailNow()
+	}
+	defer callstacks.Close()
+
+	saver := &defaultDataSaver{
+		tsdbStorage: db,
+		callstacks:  callstacks,
+		sessionDesc: &schema.SessionDescription{Id: 1},
+		metrics:     schema.StoredMetrics(),
+		refs:        make(map[string][]uint64),
+	}
+
+	mm := &schema.Measurement{Locations: make([]*schema.Location, locations)}
+	for i := range mm.Locations {
+		mm.Locations[i] = &schema.Location{
+			Callstack: &schema.Callstack{
+				Id:     fmt.Sprintf("%d", i),
+				Frames: []*schema.StackFrame{{Name: "main.main", File: "main.go", Line: int32(i)}},
+			},
+			MemoryUsage: &schema.MemoryUsage{AllocObjects: int64(i), AllocBytes: int64(i) * 64},
+		}
+	}
+
+	start := time.Now()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if mm.ObservedAt, err = ptypes.TimestampProto(start.Add(time.Duration(i) * time.Millisecond)); err != nil {
+			b.Fatal(err)
+		}
+		if err := saver.Save(mm); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// countingTSDB counts samples added by appenders
+type countingTSDB struct {
+	prometheus.TSDB
+	adds, fastAdds int
+}
+
+func (db *countingTSDB) Appender() tsdb.Appender {
+	return &countingAppender{Appender: db.TSDB.Appender(), db: db}
+}
+
+type countingAppender struct {
+	tsdb.Appender
+	db *countingTSDB
+}
+
+func (a *countingAppender) Add(l labels.Labels, t int64, v float64) (uint64, error) {
+	a.db.adds++
+	return a.Appender.Add(l, t, v)
+}
+
+func (a *countingAppender) AddFast(ref uint64, t int64, v float64) error {
+	a.db.fastAdds++
+	return a.Appender.AddFast(ref, t, v)
+}
+
+func newTestDataSaver(t *testing.T) (*defaultDataSaver, *countingTSDB, func()) {
+	logger := utils.NewLogger(&config.LoggingConfig{Level: zerolog.WarnLevel})
+
+	dataDir, err := ioutil.TempDir("", "memprofiler")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	goKitWrapper, err := NewGoKitLogWrapper(logger)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	db, err := prometheus.OpenTSDB(dataDir, goKitWrapper)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	callstacks, err := openCallstackDictionary(dataDir)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	counting := &countingTSDB{TSDB: db}
+	saver := &defaultDataSaver{
+		tsdbStorage: counting,
+		callstacks:  callstacks,
+		sessionDesc: &schema.SessionDescription{Id: 1},
+		metrics:     schema.StoredMetrics(),
+		refs:        make(map[string][]uint64),
+	}
+	cleanup := func() {
+		assert.NoError(t, callstacks.Close())
+		assert.NoError(t, db.Close())
+		assert.NoError(t, os.RemoveAll(dataDir))
+	}
+	return saver, counting, cleanup
+}
+
+func testMeasurement(t *testing.T, seconds int64, ids ...string) *schema.Measurement {
+	observedAt, err := ptypes.TimestampProto(time.Unix(seconds, 0))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	mm := &schema.Measurement{ObservedAt: observedAt}
+	for _, id := range ids {
+		mm.Locations = append(mm.Locations, &schema.Location{
+			Callstack:   &schema.Callstack{Id: id},
+			MemoryUsage: &schema.MemoryUsage{AllocBytes: seconds},
+		})
+	}
+	return mm
+}
+
+// countSamples returns the number of samples of every location series
+func countSamples(t *testing.T, db prometheus.TSDB) map[string]int {
+	querier, err := db.Querier(math.MinInt64, math.MaxInt64)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer querier.Close()
+
+	seriesSet, err := querier.Select(labels.NewEqualMatcher(sessionLabelName, "1"))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	result := make(map[string]int)
+	for seriesSet.Next() {
+		it := seriesSet.At().Iterator()
+		for it.Next() {
+			result[seriesSet.At().Labels().Get(callstackLabelName)]++
+		}
+		assert.NoError(t, it.Err())
+	}
+	assert.NoError(t, seriesSet.Err())
+	return result
+}
+
+// Samples of a measurement are rolled back, if any of them can't be appended
+func TestDataSaver_Rollback(t *testing.T) {
+	saver, db, cleanup := newTestDataSaver(t)
+	defer cleanup()
+
+	metrics := len(schema.StoredMetrics())
+	assert.NoError(t, saver.Save(testMeasurement(t, 2, "a")))
+
+	// the sample of the new location is appended, but the one of the existing location is out of order
+	assert.Error(t, saver.Save(testMeasurement(t, 1, "b", "a")))
+	assert.Equal(t, map[string]int{"a": metrics}, countSamples(t, db))
+	assert.Contains(t, saver.refs, "a")
+	assert.NotContains(t, saver.refs, "b")
+
+	// callstack can't be stored
+	assert.Error(t, saver.Save(testMeasurement(t, 3, "c", "")))
+	assert.Equal(t, map[string]int{"a": metrics}, countSamples(t, db))
+	assert.NotContains(t, saver.refs, "c")
+
+	// saver is still usable
+	assert.NoError(t, saver.Save(testMeasurement(t, 4, "a", "b")))
+	assert.Equal(t, map[string]int{"a": 2 * metrics, "b": metrics}, countSamples(t, db))
+}
+
+// Series dropped from TSDB head (e.g. after compaction) are added again, the appended samples are not repeated
+func TestDataSaver_SeriesNotFound(t *testing.T) {
+	saver, db, cleanup := newTestDataSaver(t)
+	defer cleanup()
+
+	metrics := len(schema.StoredMetrics())
+	assert.NoError(t, saver.Save(testMeasurement(t, 1, "a")))
+	assert.Equal(t, metrics, db.adds)
+	assert.Equal(t, 0, db.fastAdds)
+
+	// cached references of all metrics but the first one are outdated
+	valid := saver.refs["a"]
+	outdated := make([]uint64, metrics)
+	outdated[0] = valid[0]
+	for i := 1; i < metrics; i++ {
+		outdated[i] = math.MaxUint64
+	}
+	saver.refs["a"] = outdated
+
+	db.adds = 0
+	assert.NoError(t, saver.Save(testMeasurement(t, 2, "a")))
+	assert.Equal(t, metrics-1, db.adds)
+	assert.Equal(t, 2, db.fastAdds)
+	assert.Equal(t, valid, saver.refs["a"])
+	assert.Equal(t, map[string]int{"a": 2 * metrics}, countSamples(t, db))
+
+	// references are cached again
+	db.adds, db.fastAdds = 0, 0
+	assert.NoError(t, saver.Save(testMeasurement(t, 3, "a")))
+	assert.Equal(t, 0, db.adds)
+	assert.Equal(t, metrics, db.fastAdds)
+	assert.Equal(t, map[string]int{"a": 3 * metrics}, countSamples(t, db))
+}
@@ -0,0 +1,249 @@
+package tsdb
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	"github.com/prometheus/tsdb/labels"
+
+	"github.com/memprofiler/memprofiler/schema"
+	"github.com/memprofiler/memprofiler/server/config"
+	"github.com/memprofiler/memprofiler/server/storage/data"
+)
+
+const (
+	// downsampledDir keeps downsampled measurements: TSDB doesn't accept samples older
+	// than its head block, so they are stored in a separate file per session
+	downsampledDir = "downsampled"
+	// maxRecordSize limits the size of a single downsampled measurement record
+	maxRecordSize = 64 * 1024 * 1024
+)
+
+// Downsample moves the measurements older than the first tier threshold from TSDB
+// to the downsampled measurements files of the sessions
+func (s *storage) Downsample(
+	ctx context.Context,
+	sds []*schema.SessionDescription,
+	tiers []config.DownsamplingTier,
+	now time.Time,
+) error {
+	var deleted, failed int
+	for _, sd := range sds {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		ok, err := s.downsampleSession(ctx, sd, tiers, now)
+		if err != nil {
+			s.logger.Error().Err(err).Int64("session_id", sd.GetId()).Msg("Failed to downsample session")
+			failed++
+			continue
+		}
+		if ok {
+			deleted++
+		}
+	}
+
+	// every call rewrites all the blocks containing deleted samples, so it's done once for all sessions
+	if deleted > 0 {
+		if err := s.tsdbStorage.CleanTombstones(); err != nil {
+			return errors.Wrap(err, "clean tombstones")
+		}
+	}
+	if failed > 0 {
+		return errors.Errorf("%d sessions failed", failed)
+	}
+	return nil
+}
+
+// downsampleSession moves session measurements to the downsampled measurements file
+// and reports if some samples have been deleted from TSDB
+func (s *storage) downsampleSession(
+	ctx context.Context,
+	sd *schema.SessionDescription,
+	tiers []config.DownsamplingTier,
+	now time.Time,
+) (bool, error) {
+	filename := s.downsampledFile(sd)
+
+	mms, err := readDownsampled(filename)
+	if err != nil {
+		return false, errors.Wrap(err, "read downsampled measurements")
+	}
+	var last int64 = math.MinInt64
+	if len(mms) > 0 {
+		last = protoToTimestamp(mms[len(mms)-1].GetObservedAt())
+	}
+
+	cut := toTimestamp(data.DownsamplingCut(tiers, now))
+	moved, stale, err := s.readExpired(sd, last, cut)
+	if err != nil {
+		return false, err
+	}
+	mms = append(mms, moved...)
+
+	downsampled, err := data.Downsample(mms, tiers, now)
+	if err != nil {
+		return false, errors.Wrap(err, "downsample measurements")
+	}
+
+	select {
+	case <-ctx.Done():
+		return false, ctx.Err()
+	default:
+	}
+
+	if !equalMeasurements(mms, downsampled) {
+		if err := writeDownsampled(filename, downsampled); err != nil {
+			return false, errors.Wrap(err, "write downsampled measurements")
+		}
+	}
+
+	if len(moved)+stale == 0 {
+		return false, nil
+	}
+
+	// samples are deleted only when they're safely stored in the file
+	label := sessionLabel(sd)
+	if err := s.tsdbStorage.Delete(math.MinInt64, cut-1, labels.NewEqualMatcher(label.Name, label.Value)); err != nil {
+		return false, errors.Wrap(err, "delete downsampled series")
+	}
+	if len(moved) > 0 {
+		s.logger.Info().Fields(map[string]interface{}{
+			"session_id": sd.GetId(),
+			"moved":      len(moved),
+			"after":      len(downsampled),
+		}).Msg("Session data downsampled")
+	}
+	return true, nil
+}
+
+// readExpired reads session measurements observed before the cut; the ones observed not later than
+// the last downsampled measurement have been already moved, but not deleted (e.g. because of failure),
+// so they're only counted as stale
+func (s *storage) readExpired(sd *schema.SessionDescription, last, cut int64) ([]*schema.Measurement, int, error) {
+	it, err := NewMeasurementIterator(s.tsdbStorage, s.callstacks, sessionLabel(sd), math.MinInt64, cut-1)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "create measurement iterator")
+	}
+	// querier must be closed before samples are deleted
+	defer func() {
+		if err := it.Close(); err != nil {
+			s.logger.Error().Err(err).Msg("close measurement iterator")
+		}
+	}()
+
+	var (
+		moved []*schema.Measurement
+		stale int
+	)
+	for it.Next() {
+		mm := it.At()
+		if err := it.Error(); err != nil {
+			return nil, 0, errors.Wrap(err, "iterate measurements")
+		}
+		if protoToTimestamp(mm.GetObservedAt()) > last {
+			moved = append(moved, mm)
+		} else {
+			stale++
+		}
+	}
+	return moved, stale, nil
+}
+
+// downsampledFile builds a path for a file with downsampled measurements of a session
+func (s *storage) downsampledFile(sd *schema.SessionDescription) string {
+	return filepath.Join(s.cfg.DataDir, downsampledDir, fmt.Sprintf("%010d", sd.GetId()))
+}
+
+// readDownsampled reads downsampled measurements; missing file means there are no such measurements
+func readDownsampled(filename string) ([]*schema.Measurement, error) {
+	fd, err := os.Open(filepath.Clean(filename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer fd.Close()
+
+	var (
+		result  []*schema.Measurement
+		scanner = bufio.NewScanner(fd)
+	)
+	scanner.Buffer(nil, maxRecordSize)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		mm := &schema.Measurement{}
+		if err := jsonpb.Unmarshal(bytes.NewReader(scanner.Bytes()), mm); err != nil {
+			return nil, err
+		}
+		result = append(result, mm)
+	}
+	return result, scanner.Err()
+}
+
+// writeDownsampled writes measurements to the temporary file and renames it then
+func writeDownsampled(filename string, mms []*schema.Measurement) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0750); err != nil {
+		return err
+	}
+
+	tmpFilename := filename + ".tmp"
+	fd, err := os.OpenFile(tmpFilename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0640)
+	if err != nil {
+		return err
+	}
+
+	var (
+		w          = bufio.NewWriter(fd)
+		marshaller = &jsonpb.Marshaler{EnumsAsInts: true}
+	)
+	for _, mm := range mms {
+		if err := marshaller.Marshal(w, mm); err != nil {
+			_ = fd.Close()
+			return err
+		}
+		if err := w.WriteByte('\n'); err != nil {
+			_ = fd.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		_ = fd.Close()
+		return err
+	}
+	if err := fd.Sync(); err != nil {
+		_ = fd.Close()
+		return err
+	}
+	if err := fd.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFilename, filename)
+}
+
+func equalMeasurements(a, b []*schema.Measurement) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !proto.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
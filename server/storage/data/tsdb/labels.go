@@ -1,31 +1,32 @@
 package tsdb
 
 import (
+	"fmt"
+
 	"github.com/prometheus/tsdb/labels"
+
+	"github.com/memprofiler/memprofiler/schema"
 )
 
 const (
-	sessionLabelName    = "session"
+	sessionLabelName   = "session"
+	callstackLabelName = "callstack"
+	// metaLabelName keeps the whole encoded callstack in series written by older versions
 	metaLabelName       = "meta"
 	metricTypeLabelName = "metric_type"
 )
 
-func allocBytesLabel() labels.Label {
-	return labels.Label{Name: metricTypeLabelName, Value: "AllocBytes"}
-}
-func allocObjectsLabel() labels.Label {
-	return labels.Label{Name: metricTypeLabelName, Value: "AllocObjects"}
-}
-func freeBytesLabel() labels.Label {
-	return labels.Label{Name: metricTypeLabelName, Value: "FreeBytes"}
-}
-func freeObjectsLabel() labels.Label {
-	return labels.Label{Name: metricTypeLabelName, Value: "FreeObjects"}
+// sessionLabel identifies series of a particular session
+func sessionLabel(sd *schema.SessionDescription) labels.Label {
+	return labels.Label{Name: sessionLabelName, Value: fmt.Sprintf("%d", sd.GetId())}
 }
 
-type measurementInfo struct {
-	Labels labels.Labels
-	Value  float64
+// callstackLabel identifies series of a particular location
+func callstackLabel(cs *schema.Callstack) labels.Label {
+	return labels.Label{Name: callstackLabelName, Value: cs.GetId()}
 }
 
-type measurementsInfo []measurementInfo
+// metricLabel identifies series of a particular metric
+func metricLabel(metric *schema.Metric) labels.Label {
+	return labels.Label{Name: metricTypeLabelName, Value: metric.Name}
+}
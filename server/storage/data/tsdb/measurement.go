@@ -1,7 +1,8 @@
 package tsdb
 
 import (
-	"time"
+	"fmt"
+	"math"
 
 	"github.com/memprofiler/memprofiler/server/storage/data/tsdb/prometheus"
 
@@ -17,13 +18,15 @@ type MeasurementIterator interface {
 	Next() bool
 	At() *schema.Measurement
 	Error() error
+	// Close releases TSDB blocks; blocks can't be deleted or rewritten while they're read
+	Close() error
 }
 
 var _ MeasurementIterator = (*measurementIterator)(nil)
 
 type measurementIterator struct {
-	querier tsdb.Querier
-	codec   codec
+	querier    tsdb.Querier
+	callstacks map[string]*schema.Callstack
 
 	// current data state
 	currentTime            int64
@@ -44,8 +47,7 @@ func (i *measurementIterator) Next() bool {
 
 // At get current measurement from state
 func (i *measurementIterator) At() *schema.Measurement {
-	// TODO: use nano seconds instead of milliseconds
-	t, err := ptypes.TimestampProto(time.Unix(i.currentTime, 0))
+	t, err := ptypes.TimestampProto(fromTimestamp(i.currentTime))
 	if err != nil {
 		i.error = err
 	}
@@ -66,9 +68,14 @@ func (i *measurementIterator) Error() error {
 	return i.error
 }
 
+// Close releases querier
+func (i *measurementIterator) Close() error {
+	return i.querier.Close()
+}
+
 func (i *measurementIterator) updateMin() {
-	// reset time to time now for get next min
-	i.currentTime = time.Now().Unix()
+	// reset time for get next min
+	i.currentTime = math.MaxInt64
 
 	// get minimum time from all measurements
 	for _, v := range i.memoryUsageIteratorMap {
@@ -105,12 +112,10 @@ func (i *measurementIterator) currentLocations() ([]*schema.Location, error) {
 	return currentLocations, nil
 }
 
-func (i *measurementIterator) getLocation(callStack string, memUsage *schema.MemoryUsage) (*schema.Location, error) {
-	cs := &schema.Callstack{}
-
-	err := i.codec.decode(callStack, cs)
-	if err != nil {
-		return nil, err
+func (i *measurementIterator) getLocation(key string, memUsage *schema.MemoryUsage) (*schema.Location, error) {
+	cs, exists := i.callstacks[key]
+	if !exists {
+		return nil, fmt.Errorf("unknown location %s", key)
 	}
 
 	return &schema.Location{
@@ -119,35 +124,70 @@ func (i *measurementIterator) getLocation(callStack string, memUsage *schema.Mem
 	}, nil
 }
 
-// NewMeasurementIterator iterator over measurements in session
-func NewMeasurementIterator(tsdb prometheus.TSDB, codec codec, sessionLabel labels.Label) (MeasurementIterator, error) {
-	querier, err := tsdb.Querier(0, time.Now().Unix())
+// locationKey identifies location of a series: callstack id, or callstack itself for series written by older versions
+func locationKey(lbls labels.Labels) string {
+	if id := lbls.Get(callstackLabelName); id != "" {
+		return id
+	}
+	return lbls.Get(metaLabelName)
+}
+
+// NewMeasurementIterator iterator over measurements in session observed within [mint, maxt] time range
+func NewMeasurementIterator(
+	db prometheus.TSDB,
+	callstacks *callstackDictionary,
+	sessionLabel labels.Label,
+	mint, maxt int64,
+) (MeasurementIterator, error) {
+	querier, err := db.Querier(mint, maxt)
 	if err != nil {
 		return nil, err
 	}
 
-	// TODO: use LabelValuesFor when implemented
-	// metaLabels, err := querier.LabelValuesFor(metaLabelName, sessionLabel)
-	metaLabels, err := querier.LabelValues(metaLabelName)
+	seriesSet, err := querier.Select(labels.NewEqualMatcher(sessionLabel.Name, sessionLabel.Value))
 	if err != nil {
+		_ = querier.Close()
 		return nil, err
 	}
 
-	locationsIterMap := make(map[string]MemoryUsageIterator, len(metaLabels))
+	// group session series by location and metric
+	var (
+		locationSeries    = make(map[string]map[string]tsdb.Series)
+		locationCallstack = make(map[string]*schema.Callstack)
+	)
+	for seriesSet.Next() {
+		series := seriesSet.At()
+		lbls := series.Labels()
+
+		key := locationKey(lbls)
+		if _, exists := locationSeries[key]; !exists {
+			cs, err := callstacks.resolve(lbls)
+			if err != nil {
+				_ = querier.Close()
+				return nil, err
+			}
+			locationSeries[key] = make(map[string]tsdb.Series)
+			locationCallstack[key] = cs
+		}
+		locationSeries[key][lbls.Get(metricTypeLabelName)] = series
+	}
+	if err := seriesSet.Err(); err != nil {
+		_ = querier.Close()
+		return nil, err
+	}
 
-	// create map (map[Session]LocationIterator)
-	for _, m := range metaLabels {
-		metaLabel := labels.Label{Name: metaLabelName, Value: m}
-		mui, ok := NewMemoryUsageIterator(querier, sessionLabel, metaLabel)
+	locationsIterMap := make(map[string]MemoryUsageIterator, len(locationSeries))
+	for key, series := range locationSeries {
+		mui, ok := NewMemoryUsageIterator(series)
 		if ok {
-			locationsIterMap[m] = mui
+			locationsIterMap[key] = mui
 		}
 	}
 	li := &measurementIterator{
 		querier:                querier,
-		currentTime:            time.Now().Unix(),
+		currentTime:            math.MaxInt64,
 		memoryUsageIteratorMap: locationsIterMap,
-		codec:                  codec,
+		callstacks:             locationCallstack,
 		error:                  nil,
 	}
 	return li, nil
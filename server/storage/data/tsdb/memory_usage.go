@@ -4,7 +4,6 @@ import (
 	"fmt"
 
 	"github.com/prometheus/tsdb"
-	"github.com/prometheus/tsdb/labels"
 
 	"github.com/memprofiler/memprofiler/schema"
 )
@@ -24,11 +23,9 @@ type memoryUsageIterator struct {
 	currentMemUsage *schema.MemoryUsage
 	error           error
 
-	// iterator for each memory usage type
-	allocObjectsIterator tsdb.SeriesIterator
-	allocBytesIterator   tsdb.SeriesIterator
-	freeObjectsIterator  tsdb.SeriesIterator
-	freeBytesIterator    tsdb.SeriesIterator
+	// iterator for each stored metric
+	metrics   []*schema.Metric
+	iterators []tsdb.SeriesIterator
 }
 
 // CurrentTime current time and memory usage for location
@@ -38,34 +35,26 @@ func (i *memoryUsageIterator) At() (int64, *schema.MemoryUsage) {
 
 // Next check for next element and update state
 func (i *memoryUsageIterator) Next() bool {
-	next := i.allocObjectsIterator.Next() &&
-		i.allocBytesIterator.Next() &&
-		i.freeObjectsIterator.Next() &&
-		i.freeBytesIterator.Next()
-
-	if next {
-		t1, allocObjects := i.allocObjectsIterator.At()
-		t2, allocBytes := i.allocBytesIterator.At()
-		t3, freeObjects := i.freeObjectsIterator.At()
-		t4, freeBytes := i.freeBytesIterator.At()
-
-		if !(t1 == t2 && t2 == t3 && t3 == t4) {
-			i.error = fmt.Errorf("time for measurement is incorrect")
+	for _, iterator := range i.iterators {
+		if !iterator.Next() {
+			return false
 		}
+	}
 
-		// set current time
-		i.currentTime = t1
+	// set current time
+	i.currentTime, _ = i.iterators[0].At()
 
-		// set current memory usage
-		i.currentMemUsage = &schema.MemoryUsage{
-			AllocObjects: int64(allocObjects),
-			AllocBytes:   int64(allocBytes),
-			FreeObjects:  int64(freeObjects),
-			FreeBytes:    int64(freeBytes),
+	// set current memory usage
+	i.currentMemUsage = &schema.MemoryUsage{}
+	for j, iterator := range i.iterators {
+		t, value := iterator.At()
+		if t != i.currentTime {
+			i.error = fmt.Errorf("time for measurement is incorrect")
 		}
+		i.metrics[j].SetValue(i.currentMemUsage, value)
 	}
 
-	return next
+	return true
 }
 
 // Error current time and memory usage for location
@@ -73,32 +62,19 @@ func (i *memoryUsageIterator) Error() error {
 	return i.error
 }
 
-// NewMemoryUsageIterator iterator for simple Location
-func NewMemoryUsageIterator(querier tsdb.Querier, sessionLabel, metaLabel labels.Label) (MemoryUsageIterator, bool) {
-	allocObjectsIterator, ok := createSeriesIterator(querier, sessionLabel, metaLabel, allocObjectsLabel())
-	if !ok {
-		return nil, false
-	}
-	allocBytesIterator, ok := createSeriesIterator(querier, sessionLabel, metaLabel, allocBytesLabel())
-	if !ok {
-		return nil, false
-	}
-	freeObjectsIterator, ok := createSeriesIterator(querier, sessionLabel, metaLabel, freeObjectsLabel())
-	if !ok {
-		return nil, false
-	}
-	freeBytesIterator, ok := createSeriesIterator(querier, sessionLabel, metaLabel, freeBytesLabel())
-	if !ok {
-		return nil, false
-	}
-
+// NewMemoryUsageIterator iterator for simple Location; series are keyed by metric name
+func NewMemoryUsageIterator(series map[string]tsdb.Series) (MemoryUsageIterator, bool) {
 	mui := &memoryUsageIterator{
-		allocObjectsIterator: allocObjectsIterator,
-		allocBytesIterator:   allocBytesIterator,
-		freeObjectsIterator:  freeObjectsIterator,
-		freeBytesIterator:    freeBytesIterator,
+		metrics: schema.StoredMetrics(),
+		error:   nil,
+	}
 
-		error: nil,
+	for _, metric := range mui.metrics {
+		s, ok := series[metric.Name]
+		if !ok {
+			return nil, false
+		}
+		mui.iterators = append(mui.iterators, s.Iterator())
 	}
 
 	// initial call Next
@@ -112,20 +88,3 @@ func NewMemoryUsageIterator(querier tsdb.Querier, sessionLabel, metaLabel labels
 
 	return mui, true
 }
-
-func createSeriesIterator(querier tsdb.Querier, sessionLabel, metaLabel, l labels.Label) (tsdb.SeriesIterator, bool) {
-	seriesSet, _ := querier.Select([]labels.Matcher{
-		labels.NewEqualMatcher(sessionLabel.Name, sessionLabel.Value),
-		labels.NewEqualMatcher(metaLabel.Name, metaLabel.Value),
-		labels.NewEqualMatcher(l.Name, l.Value),
-	}...)
-
-	// we iterate once because must be single series for current labels
-	next := seriesSet.Next()
-	if !next {
-		return nil, false
-	}
-	series := seriesSet.At()
-
-	return series.Iterator(), true
-}
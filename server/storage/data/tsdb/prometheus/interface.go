@@ -4,6 +4,7 @@ import (
 	"io"
 
 	"github.com/prometheus/tsdb"
+	"github.com/prometheus/tsdb/labels"
 )
 
 // TSDB wrap prometheus tsdb as a point for possible expansion
@@ -17,4 +18,30 @@ type TSDB interface {
 
 	// StartTime return lowest time in storage
 	StartTime() int64
+	// EndTime return highest time in storage
+	EndTime() int64
+
+	// Delete marks the matching series samples within a time range as deleted
+	Delete(mint, maxt int64, ms ...labels.Matcher) error
+	// CleanTombstones rewrites blocks to reclaim disk space occupied by deleted samples
+	CleanTombstones() error
+	// Size returns disk space occupied by persisted blocks [bytes]
+	Size() int64
+	// NumSamples returns the number of samples in persisted blocks
+	NumSamples() uint64
+	// Snapshot writes all persisted blocks and the head block to the directory
+	Snapshot(dir string) error
+	// SeriesTimeRanges returns time ranges of the matching series; they're taken from chunk metadata,
+	// so only the chunks partially covered by tombstones and the open head chunks are read
+	SeriesTimeRanges(ms ...labels.Matcher) ([]SeriesTimeRange, error)
+	// SeriesSamples returns the number of samples of the matching series; it's taken from chunk headers,
+	// so samples aren't decoded
+	SeriesSamples(ms ...labels.Matcher) (int64, error)
+}
+
+// SeriesTimeRange contains the time of the first and the last sample of series
+type SeriesTimeRange struct {
+	Labels  labels.Labels
+	MinTime int64
+	MaxTime int64
 }
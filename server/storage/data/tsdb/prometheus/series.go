@@ -0,0 +1,195 @@
+package prometheus
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/tsdb"
+	"github.com/prometheus/tsdb/chunks"
+	"github.com/prometheus/tsdb/labels"
+)
+
+// SeriesTimeRanges returns time ranges of the matching series; they're taken from chunk metadata,
+// so only the chunks partially covered by tombstones and the open head chunks are read
+func (s *defaultTSDB) SeriesTimeRanges(ms ...labels.Matcher) ([]SeriesTimeRange, error) {
+	ranges := make(map[string]*SeriesTimeRange)
+	for _, reader := range s.blockReaders() {
+		if err := blockSeriesTimeRanges(reader, ranges, ms...); err != nil {
+			return nil, err
+		}
+	}
+
+	result := make([]SeriesTimeRange, 0, len(ranges))
+	for _, r := range ranges {
+		result = append(result, *r)
+	}
+	return result, nil
+}
+
+// blockSeriesTimeRanges extends time ranges of the matching series with the samples of a block
+func blockSeriesTimeRanges(block tsdb.BlockReader, ranges map[string]*SeriesTimeRange, ms ...labels.Matcher) error {
+	// block can't be closed (e.g. by compaction) while its readers are open
+	ir, err := block.Index()
+	if err != nil {
+		return errors.Wrap(err, "open index reader")
+	}
+	defer ir.Close()
+	tr, err := block.Tombstones()
+	if err != nil {
+		return errors.Wrap(err, "open tombstone reader")
+	}
+	defer tr.Close()
+	cr, err := block.Chunks()
+	if err != nil {
+		return errors.Wrap(err, "open chunk reader")
+	}
+	defer cr.Close()
+
+	seriesSet, err := tsdb.LookupChunkSeries(ir, tr, ms...)
+	if err != nil {
+		return errors.Wrap(err, "lookup series")
+	}
+	for seriesSet.Next() {
+		lbls, metas, deleted := seriesSet.At()
+		mint, maxt := int64(math.MaxInt64), int64(math.MinInt64)
+		for _, meta := range metas {
+			cmint, cmaxt, ok := meta.MinTime, meta.MaxTime, true
+			// the max time of the open head chunk is unknown
+			if meta.MaxTime == math.MaxInt64 || overlaps(meta, deleted) {
+				if cmint, cmaxt, ok, err = chunkTimeRange(cr, meta, deleted); err != nil {
+					return err
+				}
+			}
+			if !ok {
+				continue
+			}
+			if cmint < mint {
+				mint = cmint
+			}
+			if cmaxt > maxt {
+				maxt = cmaxt
+			}
+		}
+		if mint > maxt {
+			continue
+		}
+
+		key := lbls.String()
+		r, exists := ranges[key]
+		if !exists {
+			ranges[key] = &SeriesTimeRange{Labels: lbls, MinTime: mint, MaxTime: maxt}
+			continue
+		}
+		if mint < r.MinTime {
+			r.MinTime = mint
+		}
+		if maxt > r.MaxTime {
+			r.MaxTime = maxt
+		}
+	}
+	return errors.Wrap(seriesSet.Err(), "iterate series")
+}
+
+func overlaps(meta chunks.Meta, deleted tsdb.Intervals) bool {
+	for _, interval := range deleted {
+		if interval.Mint <= meta.MaxTime && meta.MinTime <= interval.Maxt {
+			return true
+		}
+	}
+	return false
+}
+
+// chunkTimeRange reads chunk samples to find the time range of samples that are not deleted
+func chunkTimeRange(
+	cr tsdb.ChunkReader,
+	meta chunks.Meta,
+	deleted tsdb.Intervals,
+) (mint, maxt int64, ok bool, err error) {
+	chunk, err := cr.Chunk(meta.Ref)
+	if err != nil {
+		return 0, 0, false, errors.Wrap(err, "read chunk")
+	}
+
+	mint, maxt = math.MaxInt64, math.MinInt64
+	it := chunk.Iterator(nil)
+SAMPLES:
+	for it.Next() {
+		t, _ := it.At()
+		for _, interval := range deleted {
+			if interval.Mint <= t && t <= interval.Maxt {
+				continue SAMPLES
+			}
+		}
+		if t < mint {
+			mint = t
+		}
+		if t > maxt {
+			maxt = t
+		}
+	}
+	if err := it.Err(); err != nil {
+		return 0, 0, false, errors.Wrap(err, "iterate chunk samples")
+	}
+	return mint, maxt, mint <= maxt, nil
+}
+
+// SeriesSamples returns the number of samples of the matching series; it's taken from chunk headers,
+// so samples aren't decoded. Chunks entirely covered by tombstones are skipped, while the partially
+// deleted ones are counted as a whole, since they occupy disk space until compaction
+func (s *defaultTSDB) SeriesSamples(ms ...labels.Matcher) (int64, error) {
+	var samples int64
+	for _, reader := range s.blockReaders() {
+		n, err := blockSeriesSamples(reader, ms...)
+		if err != nil {
+			return 0, err
+		}
+		samples += n
+	}
+	return samples, nil
+}
+
+// blockSeriesSamples counts samples of the matching series within a block
+func blockSeriesSamples(block tsdb.BlockReader, ms ...labels.Matcher) (int64, error) {
+	ir, err := block.Index()
+	if err != nil {
+		return 0, errors.Wrap(err, "open index reader")
+	}
+	defer ir.Close()
+	tr, err := block.Tombstones()
+	if err != nil {
+		return 0, errors.Wrap(err, "open tombstone reader")
+	}
+	defer tr.Close()
+	cr, err := block.Chunks()
+	if err != nil {
+		return 0, errors.Wrap(err, "open chunk reader")
+	}
+	defer cr.Close()
+
+	seriesSet, err := tsdb.LookupChunkSeries(ir, tr, ms...)
+	if err != nil {
+		return 0, errors.Wrap(err, "lookup series")
+	}
+	var samples int64
+	for seriesSet.Next() {
+		_, metas, _ := seriesSet.At()
+		for _, meta := range metas {
+			chunk, err := cr.Chunk(meta.Ref)
+			if err != nil {
+				return 0, errors.Wrap(err, "read chunk")
+			}
+			samples += int64(chunk.NumSamples())
+		}
+	}
+	return samples, errors.Wrap(seriesSet.Err(), "iterate series")
+}
+
+// blockReaders returns persisted blocks followed by the head block
+func (s *defaultTSDB) blockReaders() []tsdb.BlockReader {
+	blocks := s.db.Blocks()
+	readers := make([]tsdb.BlockReader, 0, len(blocks)+1)
+	for _, block := range blocks {
+		readers = append(readers, block)
+	}
+	return append(readers, s.db.Head())
+}
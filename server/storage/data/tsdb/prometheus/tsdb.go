@@ -3,6 +3,7 @@ package prometheus
 import (
 	"github.com/go-kit/kit/log"
 	"github.com/prometheus/tsdb"
+	"github.com/prometheus/tsdb/labels"
 )
 
 var _ TSDB = (*defaultTSDB)(nil)
@@ -39,6 +40,53 @@ func (s *defaultTSDB) StartTime() int64 {
 	return startTime
 }
 
+// EndTime return highest time in storage
+func (s *defaultTSDB) EndTime() int64 {
+	endTime := s.db.Head().MaxTime()
+
+	// block max time is exclusive
+	if blocks := s.db.Blocks(); len(blocks) > 0 {
+		if maxTime := blocks[len(blocks)-1].Meta().MaxTime - 1; maxTime > endTime {
+			endTime = maxTime
+		}
+	}
+
+	return endTime
+}
+
+// Delete marks the matching series samples within a time range as deleted
+func (s *defaultTSDB) Delete(mint, maxt int64, ms ...labels.Matcher) error {
+	return s.db.Delete(mint, maxt, ms...)
+}
+
+// CleanTombstones rewrites blocks to reclaim disk space occupied by deleted samples
+func (s *defaultTSDB) CleanTombstones() error {
+	return s.db.CleanTombstones()
+}
+
+// Size returns disk space occupied by persisted blocks
+func (s *defaultTSDB) Size() int64 {
+	var size int64
+	for _, block := range s.db.Blocks() {
+		size += block.Size()
+	}
+	return size
+}
+
+// NumSamples returns the number of samples in persisted blocks
+func (s *defaultTSDB) NumSamples() uint64 {
+	var samples uint64
+	for _, block := range s.db.Blocks() {
+		samples += block.Meta().Stats.NumSamples
+	}
+	return samples
+}
+
+// Snapshot writes all persisted blocks and the head block to the directory
+func (s *defaultTSDB) Snapshot(dir string) error {
+	return s.db.Snapshot(dir, true)
+}
+
 // OpenTSDB open tsdb in specified dir
 func OpenTSDB(dir string, l log.Logger) (TSDB, error) {
 	db, err := tsdb.Open(dir, l, nil, tsdb.DefaultOptions)
@@ -2,10 +2,13 @@ package tsdb
 
 import (
 	"context"
+	"math"
 	"os"
 	"sync"
 
+	"github.com/golang/protobuf/ptypes"
 	"github.com/pkg/errors"
+	"github.com/prometheus/tsdb/labels"
 
 	"github.com/memprofiler/memprofiler/schema"
 	"github.com/memprofiler/memprofiler/server/config"
@@ -18,9 +21,12 @@ import (
 
 var _ data.Storage = (*storage)(nil)
 
+// defaultBytesPerSample is used to estimate session size when there are no persisted blocks yet
+const defaultBytesPerSample = 2
+
 // storage uses Prometheus TSDB as a persistent storage
 type storage struct {
-	codec           codec
+	callstacks      *callstackDictionary
 	cfg             *config.TSDBStorageConfig
 	ctx             context.Context
 	cancel          context.CancelFunc
@@ -43,7 +49,7 @@ func (s *storage) NewDataSaver(instanceDesc *schema.InstanceDescription) (data.S
 	if err != nil {
 		return nil, errors.Wrap(err, "data session")
 	}
-	return newDataSaver(sessionDesc, s.codec, &s.wg, s.tsdbStorage, s.metadataStorage)
+	return newDataSaver(sessionDesc, s.callstacks, &s.wg, s.tsdbStorage, s.metadataStorage)
 }
 
 func (s *storage) NewDataLoader(sd *schema.SessionDescription) (data.Loader, error) {
@@ -54,12 +60,149 @@ func (s *storage) NewDataLoader(sd *schema.SessionDescription) (data.Loader, err
 		s.wg.Add(1)
 	}
 
-	return newDataLoader(sd, s.codec, s.logger, &s.wg, s.tsdbStorage)
+	return newDataLoader(sd, s.callstacks, s.logger, &s.wg, s.tsdbStorage, s.downsampledFile(sd))
+}
+
+// SessionBounds combines bounds of downsampled measurements with time ranges of the session series;
+// the latter are taken from TSDB chunk metadata, so samples are not read
+func (s *storage) SessionBounds(ctx context.Context, sd *schema.SessionDescription) (*schema.SessionBounds, error) {
+	downsampled, err := readDownsampled(s.downsampledFile(sd))
+	if err != nil {
+		return nil, err
+	}
+	var collector data.BoundsCollector
+	for _, measurement := range downsampled {
+		collector.Add(measurement)
+	}
+
+	label := sessionLabel(sd)
+	ranges, err := s.tsdbStorage.SeriesTimeRanges(labels.NewEqualMatcher(label.Name, label.Value))
+	if err != nil {
+		return nil, errors.Wrap(err, "get session series time ranges")
+	}
+	if len(ranges) == 0 {
+		return collector.Bounds(), nil
+	}
+
+	var (
+		mint, maxt = int64(math.MaxInt64), int64(math.MinInt64)
+		locations  = make(map[string]struct{})
+	)
+	for _, r := range ranges {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if r.MinTime < mint {
+			mint = r.MinTime
+		}
+		if r.MaxTime > maxt {
+			maxt = r.MaxTime
+		}
+
+		key := locationKey(r.Labels)
+		if _, exists := locations[key]; exists {
+			continue
+		}
+		locations[key] = struct{}{}
+		cs, err := s.callstacks.resolve(r.Labels)
+		if err != nil {
+			return nil, errors.Wrap(err, "resolve callstack")
+		}
+		collector.AddCallstack(cs.GetId())
+	}
+
+	// downsampled measurements precede the ones kept in TSDB
+	first, err := ptypes.TimestampProto(fromTimestamp(mint))
+	if err != nil {
+		return nil, err
+	}
+	last, err := ptypes.TimestampProto(fromTimestamp(maxt))
+	if err != nil {
+		return nil, err
+	}
+	if len(downsampled) == 0 {
+		collector.SetFirst(first)
+	}
+	bounds := collector.Bounds()
+	bounds.LastObservedAt = last
+	return bounds, nil
+}
+
+// SessionSize estimates disk space occupied by the session data, since the session
+// samples are stored in blocks together with the samples of other sessions;
+// the number of samples is taken from chunk headers, so samples aren't read
+func (s *storage) SessionSize(sd *schema.SessionDescription) (int64, error) {
+	label := sessionLabel(sd)
+	samples, err := s.tsdbStorage.SeriesSamples(labels.NewEqualMatcher(label.Name, label.Value))
+	if err != nil {
+		return 0, errors.Wrap(err, "count session samples")
+	}
+
+	bytesPerSample := float64(defaultBytesPerSample)
+	if total := s.tsdbStorage.NumSamples(); total > 0 {
+		bytesPerSample = float64(s.tsdbStorage.Size()) / float64(total)
+	}
+	size := int64(float64(samples) * bytesPerSample)
+
+	// downsampled measurements are kept outside of TSDB
+	info, err := os.Stat(s.downsampledFile(sd))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return size, nil
+		}
+		return 0, errors.Wrap(err, "stat downsampled measurements file")
+	}
+	return size + info.Size(), nil
+}
+
+func (s *storage) DeleteSessions(ctx context.Context, sds []*schema.SessionDescription) (int64, error) {
+	var (
+		sizeBefore = s.tsdbStorage.Size()
+		reclaimed  int64
+	)
+
+	for _, sd := range sds {
+		select {
+		case <-ctx.Done():
+			return reclaimed, ctx.Err()
+		default:
+		}
+
+		filename := s.downsampledFile(sd)
+		if info, err := os.Stat(filename); err == nil {
+			if err := os.Remove(filename); err != nil {
+				return reclaimed, errors.Wrap(err, "remove downsampled measurements file")
+			}
+			reclaimed += info.Size()
+		}
+
+		label := sessionLabel(sd)
+		if err := s.tsdbStorage.Delete(math.MinInt64, math.MaxInt64, labels.NewEqualMatcher(label.Name, label.Value)); err != nil {
+			return reclaimed, errors.Wrap(err, "delete session series")
+		}
+		s.logger.Info().Int64("session_id", sd.GetId()).Msg("Session data deleted")
+	}
+
+	// deleted samples occupy disk space until blocks are rewritten
+	if err := s.tsdbStorage.CleanTombstones(); err != nil {
+		return reclaimed, errors.Wrap(err, "clean tombstones")
+	}
+
+	if delta := sizeBefore - s.tsdbStorage.Size(); delta > 0 {
+		reclaimed += delta
+	}
+	return reclaimed, nil
 }
 
 func (s *storage) Quit() {
 	s.cancel()
 	s.wg.Wait()
+	if err := s.tsdbStorage.Close(); err != nil {
+		s.logger.Error().Err(err).Msg("close TSDB storage")
+	}
+	if err := s.callstacks.Close(); err != nil {
+		s.logger.Error().Err(err).Msg("close callstack dictionary")
+	}
 }
 
 // NewStorage builds new storage that keeps measurements in tsdb
@@ -84,16 +227,26 @@ func NewStorage(
 		}
 	}
 
+	if err = migrateTimestamps(logger, cfg.DataDir); err != nil {
+		return nil, errors.Wrap(err, "migrate TSDB timestamps")
+	}
+
 	// create storage
 	stor, err := prometheus.OpenTSDB(cfg.DataDir, goKitWrapper)
 	if err != nil {
 		return nil, err
 	}
 
+	callstacks, err := openCallstackDictionary(cfg.DataDir)
+	if err != nil {
+		_ = stor.Close()
+		return nil, errors.Wrap(err, "open callstack dictionary")
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 
 	s := &storage{
-		codec:           newB64Codec(),
+		callstacks:      callstacks,
 		metadataStorage: metadataStorage,
 		cfg:             cfg,
 		ctx:             ctx,
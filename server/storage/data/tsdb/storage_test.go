@@ -0,0 +1,146 @@
+package tsdb
+
+import (
+	"context"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/prometheus/tsdb/labels"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/memprofiler/memprofiler/schema"
+	"github.com/memprofiler/memprofiler/server/config"
+	"github.com/memprofiler/memprofiler/server/storage/data"
+	"github.com/memprofiler/memprofiler/server/storage/metadata"
+	"github.com/memprofiler/memprofiler/utils"
+)
+
+// TestDeleteLoadedSession checks that persisted blocks can be rewritten after the session has been read
+func TestDeleteLoadedSession(t *testing.T) {
+	logger := utils.NewLogger(&config.LoggingConfig{Level: zerolog.DebugLevel})
+
+	rootDir, err := ioutil.TempDir("", "memprofiler")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(rootDir)
+
+	metadataStorage, err := metadata.NewStorageSQLite(logger, &config.MetadataStorageConfig{DataDir: filepath.Join(rootDir, "metadata")})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer metadataStorage.Quit()
+
+	dataStorage, err := NewStorage(logger, &config.TSDBStorageConfig{DataDir: filepath.Join(rootDir, "data")}, metadataStorage)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer dataStorage.Quit()
+	s := dataStorage.(*storage)
+
+	// head is compacted in a background, when it spans more than 1.5 block ranges (3h)
+	const measurements = 4 * 60
+	saver, err := s.NewDataSaver(&schema.InstanceDescription{ServiceName: "service", InstanceName: "instance"})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	start := time.Now().Add(-measurements * time.Minute)
+	for i := 0; i < measurements; i++ {
+		observedAt, err := ptypes.TimestampProto(start.Add(time.Duration(i) * time.Minute))
+		assert.NoError(t, err)
+		assert.NoError(t, saver.Save(&schema.Measurement{
+			ObservedAt: observedAt,
+			Locations: []*schema.Location{{
+				Callstack:   &schema.Callstack{Id: "abcd", Frames: []*schema.StackFrame{{Name: "a", File: "b.go", Line: 1}}},
+				MemoryUsage: &schema.MemoryUsage{AllocBytes: int64(i)},
+			}},
+		}))
+	}
+	assert.NoError(t, saver.Close())
+	sd := saver.SessionDescription()
+
+	for deadline := time.Now().Add(30 * time.Second); s.tsdbStorage.NumSamples() == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("no persisted blocks")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	// session is read completely, and partially (loading is canceled)
+	assert.Equal(t, measurements, loadSession(t, s, sd, context.Background()))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	loadSession(t, s, sd, ctx)
+
+	// bounds taken from chunk metadata match the ones obtained by reading the whole session
+	assertSessionBounds(t, s, sd)
+
+	// samples covered by tombstones are not taken into account
+	label := sessionLabel(sd)
+	assert.NoError(t, s.tsdbStorage.Delete(
+		math.MinInt64, toTimestamp(start.Add(30*time.Minute)), labels.NewEqualMatcher(label.Name, label.Value)),
+	)
+	bounds := assertSessionBounds(t, s, sd)
+	first, err := ptypes.Timestamp(bounds.GetFirstObservedAt())
+	assert.NoError(t, err)
+	assert.Equal(t, fromTimestamp(toTimestamp(start.Add(31*time.Minute))), first.Local())
+
+	// block rewrite waits for all readers of the block
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.DeleteSessions(context.Background(), []*schema.SessionDescription{sd})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(30 * time.Second):
+		t.Fatal("session deletion hangs")
+	}
+
+	assert.Equal(t, 0, loadSession(t, s, sd, context.Background()))
+}
+
+// assertSessionBounds compares session bounds with the ones obtained by reading the whole session
+func assertSessionBounds(t *testing.T, s *storage, sd *schema.SessionDescription) *schema.SessionBounds {
+	bounds, err := s.SessionBounds(context.Background(), sd)
+	if !assert.NoError(t, err) || !assert.NotNil(t, bounds) {
+		t.FailNow()
+	}
+
+	loader, err := s.NewDataLoader(sd)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer func() { assert.NoError(t, loader.Close()) }()
+	expected, err := data.ScanBounds(context.Background(), loader)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, bounds)
+	return bounds
+}
+
+// loadSession reads session measurements until loader stops
+func loadSession(t *testing.T, s data.Storage, sd *schema.SessionDescription, ctx context.Context) int {
+	loader, err := s.NewDataLoader(sd)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer func() { assert.NoError(t, loader.Close()) }()
+
+	results, err := loader.Load(ctx)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	var count int
+	for result := range results {
+		assert.NoError(t, result.Err)
+		count++
+	}
+	return count
+}
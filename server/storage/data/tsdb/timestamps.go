@@ -0,0 +1,228 @@
+package tsdb
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/pkg/errors"
+	"github.com/prometheus/tsdb/labels"
+	"github.com/rs/zerolog"
+
+	"github.com/memprofiler/memprofiler/server/storage/data/tsdb/prometheus"
+	"github.com/memprofiler/memprofiler/utils"
+)
+
+// TSDB timestamps are measured in milliseconds, like in Prometheus;
+// older versions used seconds, so their data is migrated on startup
+
+func toTimestamp(t time.Time) int64 { return t.UnixNano() / int64(time.Millisecond) }
+
+func fromTimestamp(ts int64) time.Time {
+	return time.Unix(ts/1000, (ts%1000)*int64(time.Millisecond))
+}
+
+func protoToTimestamp(ts *timestamp.Timestamp) int64 {
+	return ts.GetSeconds()*1000 + int64(ts.GetNanos())/int64(time.Millisecond)
+}
+
+const (
+	// timestampsFile marks data directory containing TSDB with millisecond timestamps
+	timestampsFile = "timestamps"
+	// migrationDir keeps the state of the timestamps migration
+	migrationDir = "migration.tmp"
+	// migrationManifest lists the entries of the migrated TSDB; once it is written,
+	// the migrated blocks are ready to replace them
+	migrationManifest = "manifest"
+	// migrationWindow is a time range of samples copied at once [s]: appender rejects samples
+	// older than a half of the head block range relative to the latest appended one
+	migrationWindow = 30 * 60
+)
+
+const millisecondsUnit = "ms"
+
+// migrateTimestamps converts TSDB samples written with second timestamps to millisecond timestamps;
+// migration is resumed if it has been interrupted after the migrated blocks were built
+func migrateTimestamps(logger *zerolog.Logger, dataDir string) error {
+	marker := filepath.Join(dataDir, timestampsFile)
+	if utils.FileExists(marker) {
+		return nil
+	}
+
+	var (
+		dir      = filepath.Join(dataDir, migrationDir)
+		manifest = filepath.Join(dir, migrationManifest)
+	)
+
+	if !utils.FileExists(manifest) {
+		entries, err := tsdbEntries(dataDir)
+		if err != nil {
+			return errors.Wrap(err, "list TSDB entries")
+		}
+		if len(entries) == 0 {
+			return writeTimestampsMarker(marker)
+		}
+
+		logger.Info().Str("data_dir", dataDir).Msg("Migrating TSDB to millisecond timestamps")
+
+		if err := os.RemoveAll(dir); err != nil {
+			return errors.Wrap(err, "remove migration directory")
+		}
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			return errors.Wrap(err, "create migration directory")
+		}
+		if err := copyWithMillisecondTimestamps(logger, dataDir, dir); err != nil {
+			return err
+		}
+
+		tmpManifest := manifest + ".tmp"
+		if err := ioutil.WriteFile(tmpManifest, []byte(strings.Join(entries, "\n")+"\n"), 0640); err != nil {
+			return errors.Wrap(err, "write migration manifest")
+		}
+		if err := os.Rename(tmpManifest, manifest); err != nil {
+			return errors.Wrap(err, "write migration manifest")
+		}
+	}
+
+	// replace migrated entries with the new blocks
+	content, err := ioutil.ReadFile(filepath.Clean(manifest))
+	if err != nil {
+		return errors.Wrap(err, "read migration manifest")
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		if err := os.RemoveAll(filepath.Join(dataDir, scanner.Text())); err != nil {
+			return errors.Wrap(err, "remove migrated TSDB entry")
+		}
+	}
+
+	blocksDir := filepath.Join(dir, "blocks")
+	blocks, err := ioutil.ReadDir(blocksDir)
+	if err != nil {
+		return errors.Wrap(err, "list migrated blocks")
+	}
+	for _, block := range blocks {
+		if err := os.Rename(filepath.Join(blocksDir, block.Name()), filepath.Join(dataDir, block.Name())); err != nil {
+			return errors.Wrap(err, "move migrated block")
+		}
+	}
+
+	if err := writeTimestampsMarker(marker); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return errors.Wrap(err, "remove migration directory")
+	}
+
+	logger.Info().Fields(map[string]interface{}{
+		"data_dir": dataDir,
+		"blocks":   len(blocks),
+	}).Msg("TSDB migrated to millisecond timestamps")
+	return nil
+}
+
+// tsdbEntries lists the files and directories of data directory that belong to TSDB itself
+func tsdbEntries(dataDir string) ([]string, error) {
+	infos, err := ioutil.ReadDir(dataDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var entries []string
+	for _, info := range infos {
+		switch info.Name() {
+		case downsampledDir, callstacksFile, migrationDir, timestampsFile:
+		default:
+			entries = append(entries, info.Name())
+		}
+	}
+	return entries, nil
+}
+
+// copyWithMillisecondTimestamps copies samples to the new TSDB and writes its blocks to the migration directory
+func copyWithMillisecondTimestamps(logger *zerolog.Logger, dataDir, dir string) error {
+	goKitWrapper, err := NewGoKitLogWrapper(logger)
+	if err != nil {
+		return err
+	}
+
+	src, err := prometheus.OpenTSDB(dataDir, goKitWrapper)
+	if err != nil {
+		return errors.Wrap(err, "open TSDB")
+	}
+	defer func() { _ = src.Close() }()
+
+	dst, err := prometheus.OpenTSDB(filepath.Join(dir, "db"), goKitWrapper)
+	if err != nil {
+		return errors.Wrap(err, "open migrated TSDB")
+	}
+	defer func() { _ = dst.Close() }()
+
+	// samples are copied in time order, window by window
+	for mint, maxt := src.StartTime(), src.EndTime(); mint <= maxt; mint += migrationWindow {
+		if err := copyWindow(src, dst, mint, mint+migrationWindow-1); err != nil {
+			return errors.Wrap(err, "copy samples")
+		}
+	}
+
+	// snapshot creates no blocks if there are no samples
+	blocksDir := filepath.Join(dir, "blocks")
+	if err := os.MkdirAll(blocksDir, 0750); err != nil {
+		return errors.Wrap(err, "create migrated blocks directory")
+	}
+	if err := dst.Snapshot(blocksDir); err != nil {
+		return errors.Wrap(err, "snapshot migrated TSDB")
+	}
+	return nil
+}
+
+func copyWindow(src, dst prometheus.TSDB, mint, maxt int64) error {
+	querier, err := src.Querier(mint, maxt)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = querier.Close() }()
+
+	seriesSet, err := querier.Select(labels.NewMustRegexpMatcher(sessionLabelName, ".+"))
+	if err != nil {
+		return err
+	}
+
+	appender := dst.Appender()
+	for seriesSet.Next() {
+		series := seriesSet.At()
+		it := series.Iterator()
+		for it.Next() {
+			t, v := it.At()
+			if _, err := appender.Add(series.Labels(), t*1000, v); err != nil {
+				_ = appender.Rollback()
+				return err
+			}
+		}
+		if err := it.Err(); err != nil {
+			_ = appender.Rollback()
+			return err
+		}
+	}
+	if err := seriesSet.Err(); err != nil {
+		_ = appender.Rollback()
+		return err
+	}
+	return appender.Commit()
+}
+
+func writeTimestampsMarker(filename string) error {
+	tmpFilename := filename + ".tmp"
+	if err := ioutil.WriteFile(tmpFilename, []byte(millisecondsUnit+"\n"), 0640); err != nil {
+		return errors.Wrap(err, "write timestamps marker")
+	}
+	return errors.Wrap(os.Rename(tmpFilename, filename), "write timestamps marker")
+}
@@ -0,0 +1,101 @@
+package tsdb
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/tsdb/labels"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/memprofiler/memprofiler/server/config"
+	"github.com/memprofiler/memprofiler/server/storage/data/tsdb/prometheus"
+	"github.com/memprofiler/memprofiler/utils"
+)
+
+func TestTimestamps(t *testing.T) {
+	now := time.Unix(1500000000, 123456789)
+	assert.Equal(t, int64(1500000000123), toTimestamp(now))
+	assert.Equal(t, time.Unix(1500000000, 123000000), fromTimestamp(toTimestamp(now)))
+}
+
+func TestMigrateTimestamps(t *testing.T) {
+	logger := utils.NewLogger(&config.LoggingConfig{Level: zerolog.DebugLevel})
+
+	dataDir, err := ioutil.TempDir("", "memprofiler")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(dataDir)
+
+	goKitWrapper, err := NewGoKitLogWrapper(logger)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// samples written by older versions span several migration windows
+	const (
+		start   = 1500000000
+		samples = 30
+		step    = 10 * 60
+	)
+	series := []labels.Labels{
+		{{Name: sessionLabelName, Value: "1"}, {Name: callstackLabelName, Value: "a"}},
+		{{Name: sessionLabelName, Value: "2"}, {Name: callstackLabelName, Value: "b"}},
+	}
+	db, err := prometheus.OpenTSDB(dataDir, goKitWrapper)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	for i := 0; i < samples; i++ {
+		appender := db.Appender()
+		for j, lbls := range series {
+			_, err := appender.Add(lbls, int64(start+i*step), float64(i*len(series)+j))
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, appender.Commit())
+	}
+	assert.NoError(t, db.Close())
+
+	assert.NoError(t, migrateTimestamps(logger, dataDir))
+	assert.True(t, utils.FileExists(filepath.Join(dataDir, timestampsFile)))
+	assert.False(t, utils.FileExists(filepath.Join(dataDir, migrationDir)))
+
+	// migration is done once
+	assert.NoError(t, migrateTimestamps(logger, dataDir))
+
+	db, err = prometheus.OpenTSDB(dataDir, goKitWrapper)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer db.Close()
+
+	querier, err := db.Querier(math.MinInt64, math.MaxInt64)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer querier.Close()
+
+	for j, lbls := range series {
+		seriesSet, err := querier.Select(labels.NewEqualMatcher(sessionLabelName, lbls.Get(sessionLabelName)))
+		if !assert.NoError(t, err) || !assert.True(t, seriesSet.Next()) {
+			t.FailNow()
+		}
+		assert.Equal(t, labels.New(lbls...), seriesSet.At().Labels())
+
+		it := seriesSet.At().Iterator()
+		i := 0
+		for ; it.Next(); i++ {
+			ts, v := it.At()
+			assert.Equal(t, int64(start+i*step)*1000, ts)
+			assert.Equal(t, float64(i*len(series)+j), v)
+		}
+		assert.NoError(t, it.Err())
+		assert.Equal(t, samples, i)
+		assert.False(t, seriesSet.Next())
+	}
+}
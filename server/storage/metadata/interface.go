@@ -2,6 +2,7 @@ package metadata
 
 import (
 	"context"
+	"time"
 
 	"github.com/memprofiler/memprofiler/schema"
 	"github.com/memprofiler/memprofiler/server/common"
@@ -14,5 +15,13 @@ type Storage interface {
 	GetSessions(ctx context.Context, description *schema.InstanceDescription) ([]*schema.Session, error)
 	StartSession(ctx context.Context, description *schema.InstanceDescription) (*schema.SessionDescription, error)
 	StopSession(ctx context.Context, description *schema.SessionDescription) error
+	// FinishSession sets finish time of a session that hasn't been stopped (e.g. because of crash)
+	FinishSession(ctx context.Context, description *schema.SessionDescription, finishedAt time.Time) error
+	// DeleteSession removes session with its annotations
+	DeleteSession(ctx context.Context, description *schema.SessionDescription) error
+	// PutAnnotations stores session annotations; already existing annotations are ignored
+	PutAnnotations(ctx context.Context, description *schema.SessionDescription, annotations []*schema.Annotation) error
+	// GetAnnotations returns session annotations sorted by time
+	GetAnnotations(ctx context.Context, description *schema.SessionDescription) ([]*schema.Annotation, error)
 	common.Subsystem
 }
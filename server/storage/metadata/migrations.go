@@ -0,0 +1,102 @@
+package metadata
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+// migration is a single change of database schema
+type migration struct {
+	// version is a number of schema version produced by the migration; versions start from 1
+	version int
+	// description explains the purpose of the migration
+	description string
+	// up contains SQL statements applying the migration
+	up string
+}
+
+// migrationDialect contains database specific statements used to track schema version
+type migrationDialect struct {
+	// lock serializes migrations run concurrently (e.g. by server replicas sharing database); may be empty
+	lock string
+	// insertVersion stores applied migration version
+	insertVersion string
+}
+
+const (
+	createSchemaVersionStmt = `CREATE TABLE IF NOT EXISTS schema_version (version INTEGER NOT NULL PRIMARY KEY)`
+	selectSchemaVersionStmt = `SELECT COALESCE(MAX(version), 0) FROM schema_version`
+)
+
+// migrate applies migrations that haven't been applied yet in order of their versions;
+// every migration is applied in a separate transaction together with its version
+func migrate(
+	ctx context.Context,
+	logger *zerolog.Logger,
+	db *sql.DB,
+	dialect migrationDialect,
+	migrations []migration,
+) error {
+	for i, m := range migrations {
+		if m.version != i+1 {
+			return fmt.Errorf("migration %d has unexpected version %d", i+1, m.version)
+		}
+		applied, err := applyMigration(ctx, db, dialect, m)
+		if err != nil {
+			return errors.Wrapf(err, "apply migration %d (%s)", m.version, m.description)
+		}
+		if applied {
+			logger.Info().Fields(map[string]interface{}{
+				"version":     m.version,
+				"description": m.description,
+			}).Msg("Metadata storage schema migrated")
+		}
+	}
+	return nil
+}
+
+func applyMigration(ctx context.Context, db *sql.DB, dialect migrationDialect, m migration) (applied bool, err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, errors.Wrap(err, "begin tx")
+	}
+	defer func() {
+		if err != nil || !applied {
+			_ = tx.Rollback()
+			return
+		}
+		if err = tx.Commit(); err != nil {
+			applied = false
+			err = errors.Wrap(err, "commit tx")
+		}
+	}()
+
+	if dialect.lock != "" {
+		if _, err = tx.ExecContext(ctx, dialect.lock); err != nil {
+			return false, errors.Wrap(err, "lock schema")
+		}
+	}
+	if _, err = tx.ExecContext(ctx, createSchemaVersionStmt); err != nil {
+		return false, errors.Wrap(err, "create schema_version table")
+	}
+
+	var version int
+	if err = tx.QueryRowContext(ctx, selectSchemaVersionStmt).Scan(&version); err != nil {
+		return false, errors.Wrap(err, "select schema version")
+	}
+	if version >= m.version {
+		return false, nil
+	}
+
+	if _, err = tx.ExecContext(ctx, m.up); err != nil {
+		return false, errors.Wrap(err, "execute migration")
+	}
+	if _, err = tx.ExecContext(ctx, dialect.insertVersion, m.version); err != nil {
+		return false, errors.Wrap(err, "insert schema version")
+	}
+	return true, nil
+}
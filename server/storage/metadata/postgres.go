@@ -0,0 +1,394 @@
+package metadata
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	"github.com/memprofiler/memprofiler/schema"
+	"github.com/memprofiler/memprofiler/server/config"
+)
+
+var _ Storage = (*storagePostgres)(nil)
+
+// storagePostgres keeps metadata in PostgreSQL, so it can be shared by multiple server replicas
+type storagePostgres struct {
+	db     *sql.DB
+	logger *zerolog.Logger
+}
+
+func (s *storagePostgres) GetServices(ctx context.Context) ([]string, error) {
+	var result []string
+
+	callback := func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, "SELECT name FROM services ORDER BY id")
+		if err != nil {
+			return errors.Wrap(err, "get services: select services")
+		}
+		defer s.closeRows(rows, "get services")
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				return errors.Wrap(err, "get services: scan rows")
+			}
+			result = append(result, name)
+		}
+		return errors.Wrap(rows.Err(), "get services: iterate rows")
+	}
+
+	return result, s.wrapTx(ctx, callback)
+}
+
+func (s *storagePostgres) GetInstances(ctx context.Context, serviceName string) ([]*schema.InstanceDescription, error) {
+	var result []*schema.InstanceDescription
+
+	callback := func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(
+			ctx,
+			`SELECT instances.name FROM instances
+			JOIN services ON services.id = instances.service_id
+			WHERE services.name = $1 ORDER BY instances.id`,
+			serviceName,
+		)
+		if err != nil {
+			return errors.Wrap(err, "get instances: select instances")
+		}
+		defer s.closeRows(rows, "get instances")
+		for rows.Next() {
+			var instanceName string
+			if err := rows.Scan(&instanceName); err != nil {
+				return errors.Wrap(err, "get instances: scan rows")
+			}
+			result = append(result, &schema.InstanceDescription{
+				ServiceName:  serviceName,
+				InstanceName: instanceName,
+			})
+		}
+		return errors.Wrap(rows.Err(), "get instances: iterate rows")
+	}
+
+	return result, s.wrapTx(ctx, callback)
+}
+
+func (s *storagePostgres) GetSessions(
+	ctx context.Context,
+	instanceDesc *schema.InstanceDescription,
+) ([]*schema.Session, error) {
+	var result []*schema.Session
+
+	callback := func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(
+			ctx,
+			`SELECT sessions.id, sessions.started_at, sessions.finished_at FROM sessions
+			JOIN instances ON instances.id = sessions.instance_id
+			JOIN services ON services.id = instances.service_id
+			WHERE instances.name = $1 AND services.name = $2 ORDER BY sessions.id`,
+			instanceDesc.GetInstanceName(), instanceDesc.GetServiceName(),
+		)
+		if err != nil {
+			return errors.Wrap(err, "get sessions: select sessions")
+		}
+		defer s.closeRows(rows, "get sessions")
+		for rows.Next() {
+			var (
+				sessionID  int64
+				startedAt  time.Time
+				finishedAt pq.NullTime
+				metadata   = &schema.SessionMetadata{}
+			)
+			if err := rows.Scan(&sessionID, &startedAt, &finishedAt); err != nil {
+				return errors.Wrap(err, "get sessions: scan rows")
+			}
+			if metadata.StartedAt, err = ptypes.TimestampProto(startedAt); err != nil {
+				return errors.Wrap(err, "get sessions: convert started_at time to timestamp")
+			}
+			if finishedAt.Valid {
+				if metadata.FinishedAt, err = ptypes.TimestampProto(finishedAt.Time); err != nil {
+					return errors.Wrap(err, "get sessions: convert finished_at time to timestamp")
+				}
+			}
+			result = append(result, &schema.Session{
+				Description: &schema.SessionDescription{InstanceDescription: instanceDesc, Id: sessionID},
+				Metadata:    metadata,
+			})
+		}
+		return errors.Wrap(rows.Err(), "get sessions: iterate rows")
+	}
+
+	return result, s.wrapTx(ctx, callback)
+}
+
+func (s *storagePostgres) StartSession(
+	ctx context.Context,
+	instanceDesc *schema.InstanceDescription,
+) (*schema.SessionDescription, error) {
+	var sessionDesc *schema.SessionDescription
+
+	callback := func(tx *sql.Tx) error {
+		// create service if not exists; concurrent insertion waits for the other transaction,
+		// and the following select sees the committed row
+		_, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO services (name) VALUES ($1) ON CONFLICT DO NOTHING`,
+			instanceDesc.GetServiceName(),
+		)
+		if err != nil {
+			return errors.Wrap(err, "start session: insert service")
+		}
+		var serviceID int64
+		err = tx.QueryRowContext(
+			ctx,
+			`SELECT id FROM services WHERE name = $1`,
+			instanceDesc.GetServiceName(),
+		).Scan(&serviceID)
+		if err != nil {
+			return errors.Wrap(err, "start session: select service id")
+		}
+
+		// create instance if not exists
+		_, err = tx.ExecContext(
+			ctx,
+			`INSERT INTO instances (name, service_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+			instanceDesc.GetInstanceName(), serviceID,
+		)
+		if err != nil {
+			return errors.Wrap(err, "start session: insert instance")
+		}
+		var instanceID int64
+		err = tx.QueryRowContext(
+			ctx,
+			`SELECT id FROM instances WHERE name = $1 AND service_id = $2`,
+			instanceDesc.GetInstanceName(), serviceID,
+		).Scan(&instanceID)
+		if err != nil {
+			return errors.Wrap(err, "start session: select instance id")
+		}
+
+		// create new session
+		var sessionID int64
+		err = tx.QueryRowContext(
+			ctx,
+			`INSERT INTO sessions (started_at, instance_id) VALUES (now(), $1) RETURNING id`,
+			instanceID,
+		).Scan(&sessionID)
+		if err != nil {
+			return errors.Wrap(err, "start session: insert session")
+		}
+
+		sessionDesc = &schema.SessionDescription{InstanceDescription: instanceDesc, Id: sessionID}
+		return nil
+	}
+
+	return sessionDesc, s.wrapTx(ctx, callback)
+}
+
+func (s *storagePostgres) StopSession(ctx context.Context, description *schema.SessionDescription) error {
+	callback := func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `UPDATE sessions SET finished_at = now() WHERE id = $1`, description.GetId())
+		return errors.Wrap(err, "stop session")
+	}
+	return s.wrapTx(ctx, callback)
+}
+
+func (s *storagePostgres) FinishSession(
+	ctx context.Context,
+	description *schema.SessionDescription,
+	finishedAt time.Time,
+) error {
+	callback := func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(
+			ctx,
+			`UPDATE sessions SET finished_at = $1 WHERE id = $2 AND finished_at IS NULL`,
+			finishedAt.UTC(), description.GetId(),
+		)
+		return errors.Wrap(err, "finish session")
+	}
+	return s.wrapTx(ctx, callback)
+}
+
+func (s *storagePostgres) DeleteSession(ctx context.Context, description *schema.SessionDescription) error {
+	callback := func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `DELETE FROM annotations WHERE session_id = $1`, description.GetId())
+		if err != nil {
+			return errors.Wrap(err, "delete session: delete annotations")
+		}
+		_, err = tx.ExecContext(ctx, `DELETE FROM sessions WHERE id = $1`, description.GetId())
+		if err != nil {
+			return errors.Wrap(err, "delete session: delete session")
+		}
+		return nil
+	}
+	return s.wrapTx(ctx, callback)
+}
+
+func (s *storagePostgres) PutAnnotations(
+	ctx context.Context,
+	description *schema.SessionDescription,
+	annotations []*schema.Annotation,
+) error {
+	callback := func(tx *sql.Tx) error {
+		stmt, err := tx.PrepareContext(
+			ctx,
+			`INSERT INTO annotations (session_id, observed_at, kind, callstack_id, description)
+			VALUES ($1, $2, $3, $4, $5) ON CONFLICT DO NOTHING`,
+		)
+		if err != nil {
+			return errors.Wrap(err, "put annotations: prepare statement")
+		}
+		defer func() {
+			if txErr := stmt.Close(); txErr != nil {
+				s.logger.Error().Err(txErr).Msg("put annotations: close statement")
+			}
+		}()
+		for _, annotation := range annotations {
+			observedAt, err := ptypes.Timestamp(annotation.GetObservedAt())
+			if err != nil {
+				return errors.Wrap(err, "put annotations: convert observed_at timestamp to time")
+			}
+			_, err = stmt.ExecContext(
+				ctx,
+				description.GetId(), observedAt.UnixNano(), int32(annotation.GetKind()),
+				annotation.GetCallstackId(), annotation.GetDescription(),
+			)
+			if err != nil {
+				return errors.Wrap(err, "put annotations: insert annotation")
+			}
+		}
+		return nil
+	}
+	return s.wrapTx(ctx, callback)
+}
+
+func (s *storagePostgres) GetAnnotations(
+	ctx context.Context,
+	description *schema.SessionDescription,
+) ([]*schema.Annotation, error) {
+	var result []*schema.Annotation
+
+	callback := func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(
+			ctx,
+			`SELECT observed_at, kind, callstack_id, description FROM annotations
+			WHERE session_id = $1 ORDER BY observed_at, id`,
+			description.GetId(),
+		)
+		if err != nil {
+			return errors.Wrap(err, "get annotations: select annotations")
+		}
+		defer s.closeRows(rows, "get annotations")
+		for rows.Next() {
+			var (
+				observedAt int64
+				kind       int32
+				annotation = &schema.Annotation{}
+			)
+			if err := rows.Scan(&observedAt, &kind, &annotation.CallstackId, &annotation.Description); err != nil {
+				return errors.Wrap(err, "get annotations: scan rows")
+			}
+			if annotation.ObservedAt, err = ptypes.TimestampProto(time.Unix(0, observedAt)); err != nil {
+				return errors.Wrap(err, "get annotations: convert observed_at time to timestamp")
+			}
+			annotation.Kind = schema.AnnotationKind(kind)
+			result = append(result, annotation)
+		}
+		return errors.Wrap(rows.Err(), "get annotations: iterate rows")
+	}
+
+	return result, s.wrapTx(ctx, callback)
+}
+
+func (s *storagePostgres) Quit() {
+	if err := s.db.Close(); err != nil {
+		s.logger.Error().Err(err).Msg("close metadata storage")
+	}
+}
+
+func (s *storagePostgres) closeRows(rows *sql.Rows, op string) {
+	if err := rows.Close(); err != nil {
+		s.logger.Error().Err(err).Msg(op + ": close rows")
+	}
+}
+
+func (s *storagePostgres) wrapTx(ctx context.Context, txFunc func(*sql.Tx) error) (err error) {
+	// default isolation level (read committed) is enough, since every statement sees committed rows
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		err = errors.Wrap(err, "begin tx")
+		return
+	}
+
+	defer func() {
+		if err != nil {
+			s.logger.Error().Err(err).Msg("SQL error")
+			if txErr := tx.Rollback(); txErr != nil {
+				s.logger.Error().Err(txErr).Msg("Transaction rollback error")
+			}
+		} else if err = tx.Commit(); err != nil {
+			err = errors.Wrap(err, "commit tx")
+		}
+	}()
+	err = txFunc(tx)
+	return
+}
+
+var postgresMigrationDialect = migrationDialect{
+	// arbitrary key of advisory lock released at the end of transaction
+	lock:          `SELECT pg_advisory_xact_lock(7436298)`,
+	insertVersion: `INSERT INTO schema_version (version) VALUES ($1)`,
+}
+
+var postgresMigrations = []migration{
+	{
+		version:     1,
+		description: "create tables",
+		up: `
+		CREATE TABLE services (
+			id BIGSERIAL PRIMARY KEY,
+			name TEXT NOT NULL UNIQUE
+		);
+		CREATE TABLE instances (
+			id BIGSERIAL PRIMARY KEY,
+			name TEXT NOT NULL,
+			service_id BIGINT NOT NULL REFERENCES services (id) ON DELETE CASCADE,
+			UNIQUE (service_id, name)
+		);
+		CREATE TABLE sessions (
+			id BIGSERIAL PRIMARY KEY,
+			started_at TIMESTAMPTZ NOT NULL,
+			finished_at TIMESTAMPTZ,
+			instance_id BIGINT NOT NULL REFERENCES instances (id) ON DELETE CASCADE
+		);
+		CREATE INDEX sessions_instance_id ON sessions (instance_id);
+		CREATE TABLE annotations (
+			id BIGSERIAL PRIMARY KEY,
+			session_id BIGINT NOT NULL REFERENCES sessions (id) ON DELETE CASCADE,
+			observed_at BIGINT NOT NULL,
+			kind INTEGER NOT NULL,
+			callstack_id TEXT NOT NULL,
+			description TEXT NOT NULL,
+			UNIQUE (session_id, observed_at, kind, callstack_id)
+		);
+		`,
+	},
+}
+
+// NewStoragePostgres runs new storage based on PostgreSQL; database schema is migrated to the latest version
+func NewStoragePostgres(logger *zerolog.Logger, cfg *config.PostgresStorageConfig) (Storage, error) {
+	db, err := sql.Open("postgres", cfg.DSN)
+	if err != nil {
+		return nil, errors.Wrap(err, "open metadata storage")
+	}
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+
+	if err := migrate(context.Background(), logger, db, postgresMigrationDialect, postgresMigrations); err != nil {
+		_ = db.Close()
+		return nil, errors.Wrap(err, "migrate database")
+	}
+
+	return &storagePostgres{db: db, logger: logger}, nil
+}
@@ -239,6 +239,121 @@ func (s *storageSQLite) StopSession(ctx context.Context, description *schema.Ses
 	return s.wrapTx(ctx, callback)
 }
 
+func (s *storageSQLite) FinishSession(
+	ctx context.Context,
+	description *schema.SessionDescription,
+	finishedAt time.Time,
+) error {
+	callback := func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(
+			ctx,
+			`UPDATE sessions SET finished_at = ? WHERE id = ? AND finished_at IS NULL`,
+			finishedAt.UTC().Format(timeFormatSQLite),
+			description.Id,
+		)
+		if err != nil {
+			return errors.Wrap(err, "finish session")
+		}
+		return nil
+	}
+	return s.wrapTx(ctx, callback)
+}
+
+func (s *storageSQLite) DeleteSession(ctx context.Context, description *schema.SessionDescription) error {
+	callback := func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `DELETE FROM annotations WHERE session_id = ?`, description.GetId())
+		if err != nil {
+			return errors.Wrap(err, "delete session: delete annotations")
+		}
+		_, err = tx.ExecContext(ctx, `DELETE FROM sessions WHERE id = ?`, description.GetId())
+		if err != nil {
+			return errors.Wrap(err, "delete session: delete session")
+		}
+		return nil
+	}
+	return s.wrapTx(ctx, callback)
+}
+
+func (s *storageSQLite) PutAnnotations(
+	ctx context.Context,
+	description *schema.SessionDescription,
+	annotations []*schema.Annotation,
+) error {
+	callback := func(tx *sql.Tx) error {
+		stmt, err := tx.PrepareContext(
+			ctx,
+			`INSERT OR IGNORE INTO annotations (session_id, observed_at, kind, callstack_id, description)
+			VALUES (?, ?, ?, ?, ?)`,
+		)
+		if err != nil {
+			return errors.Wrap(err, "put annotations: prepare statement")
+		}
+		defer func() {
+			if txErr := stmt.Close(); txErr != nil {
+				s.logger.Error().Err(txErr).Msg("put annotations: close statement")
+			}
+		}()
+		for _, annotation := range annotations {
+			observedAt, err := ptypes.Timestamp(annotation.GetObservedAt())
+			if err != nil {
+				return errors.Wrap(err, "put annotations: convert observed_at timestamp to time")
+			}
+			_, err = stmt.ExecContext(
+				ctx,
+				description.GetId(), observedAt.UnixNano(), int32(annotation.GetKind()),
+				annotation.GetCallstackId(), annotation.GetDescription(),
+			)
+			if err != nil {
+				return errors.Wrap(err, "put annotations: insert annotation")
+			}
+		}
+		return nil
+	}
+	return s.wrapTx(ctx, callback)
+}
+
+func (s *storageSQLite) GetAnnotations(
+	ctx context.Context,
+	description *schema.SessionDescription,
+) ([]*schema.Annotation, error) {
+	var result []*schema.Annotation
+
+	callback := func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(
+			ctx,
+			`SELECT observed_at, kind, callstack_id, description FROM annotations
+			WHERE session_id = ? ORDER BY observed_at, id`,
+			description.GetId(),
+		)
+		if err != nil {
+			return errors.Wrap(err, "get annotations: select annotations")
+		}
+		defer func() {
+			if txErr := rows.Close(); txErr != nil {
+				s.logger.Error().Err(err).Msg("get annotations: close rows")
+			}
+		}()
+		for rows.Next() {
+			var (
+				observedAt int64
+				kind       int32
+				annotation = &schema.Annotation{}
+			)
+			if err := rows.Scan(&observedAt, &kind, &annotation.CallstackId, &annotation.Description); err != nil {
+				return errors.Wrap(err, "get annotations: scan rows")
+			}
+			if annotation.ObservedAt, err = ptypes.TimestampProto(time.Unix(0, observedAt)); err != nil {
+				return errors.Wrap(err, "get annotations: convert observed_at time to timestamp")
+			}
+			annotation.Kind = schema.AnnotationKind(kind)
+			result = append(result, annotation)
+		}
+		return nil
+	}
+
+	return result, s.wrapTx(ctx, callback)
+}
+
 func (s *storageSQLite) Quit() {
 	if err := s.db.Close(); err != nil {
 		s.logger.Error().Err(err).Msg("close metadata storage")
@@ -267,40 +382,38 @@ func (s *storageSQLite) wrapTx(ctx context.Context, txFunc func(*sql.Tx) error)
 	return
 }
 
-// NewStorageSQLite runs new storage based on SQLite
-func NewStorageSQLite(logger *zerolog.Logger, cfg *config.MetadataStorageConfig) (Storage, error) {
-	if !utils.FileExists(cfg.DataDir) {
-		if err := os.MkdirAll(cfg.DataDir, 0750); err != nil {
-			return nil, errors.Wrap(err, "create dir")
-		}
-	}
-
-	fileName := filepath.Join(cfg.DataDir, "metadata.db")
-	fileExists := utils.FileExists(fileName)
-
-	db, err := sql.Open("sqlite3", fileName)
-	if err != nil {
-		return nil, errors.Wrap(err, "open metadata storage")
+// NewStorage runs metadata storage of configured type
+func NewStorage(logger *zerolog.Logger, cfg *config.MetadataStorageConfig) (Storage, error) {
+	if cfg.Type() == config.PostgresMetadataStorage {
+		return NewStoragePostgres(logger, cfg.Postgres)
 	}
+	return NewStorageSQLite(logger, cfg)
+}
 
-	// initialize database if necessary
-	if !fileExists {
+var sqliteMigrationDialect = migrationDialect{
+	insertVersion: `INSERT INTO schema_version (version) VALUES (?)`,
+}
 
-		sqlInitStmt := `
-		CREATE TABLE services (
-			id INTEGER PRIMARY KEY AUTOINCREMENT, 
+var sqliteMigrations = []migration{
+	{
+		// databases created before migrations were introduced already have these tables
+		version:     1,
+		description: "create tables",
+		up: `
+		CREATE TABLE IF NOT EXISTS services (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			name TEXT NOT NULL UNIQUE
 		);
-		CREATE TABLE instances (
-			id INTEGER PRIMARY KEY AUTOINCREMENT, 
+		CREATE TABLE IF NOT EXISTS instances (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			name TEXT NOT NULL UNIQUE,
 			service_id INTEGER,
 			FOREIGN KEY (service_id)
 				REFERENCES services (id)
 					ON DELETE CASCADE
 		);
-		CREATE TABLE sessions (
-			id INTEGER PRIMARY KEY AUTOINCREMENT, 
+		CREATE TABLE IF NOT EXISTS sessions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			started_at datetime NOT NULL,
 			finished_at datetime,
 			instance_id INTEGER,
@@ -308,13 +421,58 @@ func NewStorageSQLite(logger *zerolog.Logger, cfg *config.MetadataStorageConfig)
 				REFERENCES instances (id)
 					ON DELETE CASCADE
 		);
-	`
+		CREATE TABLE IF NOT EXISTS annotations (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			session_id INTEGER NOT NULL,
+			observed_at INTEGER NOT NULL,
+			kind INTEGER NOT NULL,
+			callstack_id TEXT NOT NULL,
+			description TEXT NOT NULL,
+			UNIQUE (session_id, observed_at, kind, callstack_id),
+			FOREIGN KEY (session_id)
+				REFERENCES sessions (id)
+					ON DELETE CASCADE
+		);
+		`,
+	},
+	{
+		// SQLite can't drop constraints, so the table is rebuilt keeping instance ids
+		version:     2,
+		description: "make instance names unique within service",
+		up: `
+		CREATE TABLE instances_new (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			service_id INTEGER,
+			UNIQUE (service_id, name),
+			FOREIGN KEY (service_id)
+				REFERENCES services (id)
+					ON DELETE CASCADE
+		);
+		INSERT INTO instances_new (id, name, service_id) SELECT id, name, service_id FROM instances;
+		DROP TABLE instances;
+		ALTER TABLE instances_new RENAME TO instances;
+		`,
+	},
+}
 
-		_, err := db.Exec(sqlInitStmt)
-		if err != nil {
-			return nil, errors.Wrap(err, "initialize database")
+// NewStorageSQLite runs new storage based on SQLite; database schema is migrated to the latest version
+func NewStorageSQLite(logger *zerolog.Logger, cfg *config.MetadataStorageConfig) (Storage, error) {
+	if !utils.FileExists(cfg.DataDir) {
+		if err := os.MkdirAll(cfg.DataDir, 0750); err != nil {
+			return nil, errors.Wrap(err, "create dir")
 		}
 	}
 
-	return &storageSQLite{db: db}, nil
+	db, err := sql.Open("sqlite3", filepath.Join(cfg.DataDir, "metadata.db"))
+	if err != nil {
+		return nil, errors.Wrap(err, "open metadata storage")
+	}
+
+	if err := migrate(context.Background(), logger, db, sqliteMigrationDialect, sqliteMigrations); err != nil {
+		_ = db.Close()
+		return nil, errors.Wrap(err, "migrate database")
+	}
+
+	return &storageSQLite{db: db, logger: *logger}, nil
 }
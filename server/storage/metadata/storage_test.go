@@ -2,8 +2,12 @@ package metadata
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -19,19 +23,68 @@ import (
 func TestStorage(t *testing.T) {
 	logger := utils.NewLogger(&config.LoggingConfig{Level: zerolog.DebugLevel})
 
-	// create new storage instance
-	dirName, err := ioutil.TempDir("", "memprofiler")
-	if !assert.NoError(t, err) {
-		t.FailNow()
-	}
-	defer os.RemoveAll(dirName)
+	t.Run("SQLite", func(t *testing.T) {
+		// create new storage instance
+		dirName, err := ioutil.TempDir("", "memprofiler")
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		defer os.RemoveAll(dirName)
 
-	storage, err := NewStorageSQLite(logger, &config.MetadataStorageConfig{DataDir: dirName})
-	if !assert.NoError(t, err) {
-		t.FailNow()
-	}
-	defer storage.Quit()
+		storage, err := NewStorageSQLite(logger, &config.MetadataStorageConfig{DataDir: dirName})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		defer storage.Quit()
+
+		testStorage(t, storage)
+	})
+
+	// PostgreSQL tests run against a database given by DSN, e.g.
+	// MEMPROFILER_TEST_POSTGRES_DSN="postgres://postgres@localhost:5432/postgres?sslmode=disable";
+	// every run uses its own schema, which is dropped afterwards
+	t.Run("Postgres", func(t *testing.T) {
+		dsn := os.Getenv("MEMPROFILER_TEST_POSTGRES_DSN")
+		if dsn == "" {
+			t.Skip("MEMPROFILER_TEST_POSTGRES_DSN is not set")
+		}
+
+		db, err := sql.Open("postgres", dsn)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		defer db.Close()
+
+		schemaName := fmt.Sprintf("memprofiler_test_%d", time.Now().UnixNano())
+		if _, err := db.Exec("CREATE SCHEMA " + schemaName); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		defer func() {
+			_, err := db.Exec("DROP SCHEMA " + schemaName + " CASCADE")
+			assert.NoError(t, err)
+		}()
 
+		// unknown connection parameters are passed to server as run-time parameters
+		separator := " "
+		if strings.Contains(dsn, "://") {
+			separator = "?"
+			if strings.Contains(dsn, "?") {
+				separator = "&"
+			}
+		}
+		storage, err := NewStoragePostgres(logger, &config.PostgresStorageConfig{
+			DSN: dsn + separator + "search_path=" + schemaName,
+		})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		defer storage.Quit()
+
+		testStorage(t, storage)
+	})
+}
+
+func testStorage(t *testing.T, storage Storage) {
 	// there are two services with one instance per service
 	ctx := context.Background()
 	instances := []*schema.InstanceDescription{
@@ -126,4 +179,165 @@ func TestStorage(t *testing.T) {
 			assert.True(t, expectedFinishTime.Add(time.Second).After(actualFinishTime)) // session just stopped
 		}
 	})
+	t.Run("FinishSession", func(t *testing.T) {
+		sd, err := storage.StartSession(ctx, instances[0])
+		if !assert.NoError(t, err) {
+			return
+		}
+		finishedAt := time.Unix(1000, 0).UTC()
+		assert.NoError(t, storage.FinishSession(ctx, sd, finishedAt))
+
+		// already finished session is not changed
+		assert.NoError(t, storage.FinishSession(ctx, sd, finishedAt.Add(time.Hour)))
+
+		sessions, err := storage.GetSessions(ctx, instances[0])
+		assert.NoError(t, err)
+		for _, session := range sessions {
+			if session.GetDescription().GetId() == sd.GetId() {
+				actualFinishTime, err := ptypes.Timestamp(session.GetMetadata().GetFinishedAt())
+				assert.NoError(t, err)
+				assert.Equal(t, finishedAt, actualFinishTime)
+			}
+		}
+		assert.NoError(t, storage.DeleteSession(ctx, sd))
+	})
+	t.Run("Annotations", func(t *testing.T) {
+		sessions, err := storage.GetSessions(ctx, instances[0])
+		if !assert.NoError(t, err) {
+			return
+		}
+		sd := sessions[0].Description
+
+		tstamp1, _ := ptypes.TimestampProto(time.Unix(0, 1500))
+		tstamp2, _ := ptypes.TimestampProto(time.Unix(0, 1000))
+		annotations := []*schema.Annotation{
+			{ObservedAt: tstamp1, Kind: schema.AnnotationKind_CHANGE_POINT, CallstackId: "1", Description: "first"},
+			{ObservedAt: tstamp2, Kind: schema.AnnotationKind_CHANGE_POINT, Description: "second"},
+		}
+
+		// annotations that have been already stored are ignored
+		assert.NoError(t, storage.PutAnnotations(ctx, sd, annotations))
+		assert.NoError(t, storage.PutAnnotations(ctx, sd, annotations))
+
+		actual, err := storage.GetAnnotations(ctx, sd)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, []*schema.Annotation{annotations[1], annotations[0]}, actual)
+
+		// annotations belong to a particular session
+		sessions, err = storage.GetSessions(ctx, instances[1])
+		if !assert.NoError(t, err) {
+			return
+		}
+		actual, err = storage.GetAnnotations(ctx, sessions[0].Description)
+		assert.NoError(t, err)
+		assert.Empty(t, actual)
+	})
+	t.Run("DeleteSession", func(t *testing.T) {
+		sessions, err := storage.GetSessions(ctx, instances[0])
+		if !assert.NoError(t, err) {
+			return
+		}
+		sd := sessions[0].Description
+		assert.NoError(t, storage.DeleteSession(ctx, sd))
+
+		// session is deleted with its annotations
+		sessions, err = storage.GetSessions(ctx, instances[0])
+		assert.NoError(t, err)
+		assert.Empty(t, sessions)
+		annotations, err := storage.GetAnnotations(ctx, sd)
+		assert.NoError(t, err)
+		assert.Empty(t, annotations)
+	})
+	t.Run("InstanceNames", func(t *testing.T) {
+		// instance names are unique within a service only
+		for _, serviceName := range []string{"service3", "service4"} {
+			instanceDesc := &schema.InstanceDescription{ServiceName: serviceName, InstanceName: "node_1"}
+			_, err := storage.StartSession(ctx, instanceDesc)
+			if !assert.NoError(t, err) {
+				return
+			}
+			actualInstances, err := storage.GetInstances(ctx, serviceName)
+			assert.NoError(t, err)
+			assert.Equal(t, []*schema.InstanceDescription{instanceDesc}, actualInstances)
+			sessions, err := storage.GetSessions(ctx, instanceDesc)
+			assert.NoError(t, err)
+			assert.Len(t, sessions, 1)
+		}
+	})
+}
+
+func TestSQLiteMigrations(t *testing.T) {
+	logger := utils.NewLogger(&config.LoggingConfig{Level: zerolog.DebugLevel})
+
+	dirName, err := ioutil.TempDir("", "memprofiler")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(dirName)
+
+	// database created by older versions has no schema version
+	db, err := sql.Open("sqlite3", filepath.Join(dirName, "metadata.db"))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	_, err = db.Exec(`
+		CREATE TABLE services (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL UNIQUE);
+		CREATE TABLE instances (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			service_id INTEGER,
+			FOREIGN KEY (service_id) REFERENCES services (id) ON DELETE CASCADE
+		);
+		CREATE TABLE sessions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			started_at datetime NOT NULL,
+			finished_at datetime,
+			instance_id INTEGER,
+			FOREIGN KEY (instance_id) REFERENCES instances (id) ON DELETE CASCADE
+		);
+		INSERT INTO services (name) VALUES ('service1');
+		INSERT INTO instances (name, service_id) VALUES ('node_1', 1);
+		INSERT INTO sessions (started_at, finished_at, instance_id) VALUES ('2019-01-01T00:00:00Z', '2019-01-01T01:00:00Z', 1);
+	`)
+	assert.NoError(t, err)
+	assert.NoError(t, db.Close())
+
+	// migrations are applied once
+	for i := 0; i < 2; i++ {
+		storage, err := NewStorageSQLite(logger, &config.MetadataStorageConfig{DataDir: dirName})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, len(sqliteMigrations), schemaVersion(t, storage.(*storageSQLite).db))
+		storage.Quit()
+	}
+
+	storage, err := NewStorageSQLite(logger, &config.MetadataStorageConfig{DataDir: dirName})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer storage.Quit()
+
+	// existing data is kept
+	ctx := context.Background()
+	existing := &schema.InstanceDescription{ServiceName: "service1", InstanceName: "node_1"}
+	sessions, err := storage.GetSessions(ctx, existing)
+	assert.NoError(t, err)
+	if assert.Len(t, sessions, 1) {
+		assert.Equal(t, int64(1), sessions[0].GetDescription().GetId())
+		assert.NotNil(t, sessions[0].GetMetadata().GetFinishedAt())
+	}
+
+	// instance with the same name can be created for another service
+	sd, err := storage.StartSession(ctx, &schema.InstanceDescription{ServiceName: "service2", InstanceName: "node_1"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), sd.GetId())
+}
+
+func schemaVersion(t *testing.T, db *sql.DB) int {
+	var version int
+	assert.NoError(t, db.QueryRow(selectSchemaVersionStmt).Scan(&version))
+	return version
 }
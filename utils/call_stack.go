@@ -4,9 +4,12 @@ import (
 	"fmt"
 	"hash/fnv"
 	"io"
+	"regexp"
 	"runtime"
 	"strings"
 
+	"github.com/pkg/errors"
+
 	"github.com/memprofiler/memprofiler/schema"
 )
 
@@ -18,8 +21,9 @@ func UpdateMemoryUsage(mu *schema.MemoryUsage, r *runtime.MemProfileRecord) {
 	mu.FreeBytes += r.FreeBytes
 }
 
-// FillCallstack uses raw data to populate stack
-func FillCallstack(cs *schema.Callstack, rawStack []uintptr, allFrames bool) {
+// FillCallstack uses raw data to populate stack;
+// if filter is not nil, it's applied to the frames before the stack is hashed
+func FillCallstack(cs *schema.Callstack, rawStack []uintptr, allFrames bool, filter *CallstackFilter) {
 	var (
 		show   = allFrames
 		frames = runtime.CallersFrames(rawStack)
@@ -40,8 +44,95 @@ func FillCallstack(cs *schema.Callstack, rawStack []uintptr, allFrames bool) {
 	}
 
 	if !show {
-		FillCallstack(cs, rawStack, true)
+		FillCallstack(cs, rawStack, true, filter)
+		return
+	}
+
+	cs.Frames = filter.Apply(cs.Frames)
+}
+
+// CallstackFilter drops and trims stack frames, so that the allocations
+// made from the same place, but through the different framework code
+// (HTTP middleware, GRPC interceptors, etc.), collapse into a single callstack
+type CallstackFilter struct {
+	// Include keeps only frames with function names matching any of expressions (if not empty)
+	Include []*regexp.Regexp
+	// Exclude drops frames with function names matching any of expressions
+	Exclude []*regexp.Regexp
+	// MaxDepth limits the number of frames kept starting from the allocation site (0 means no limit)
+	MaxDepth int
+}
+
+// Apply returns frames that passed through the filter;
+// if every frame has been filtered out, the allocation site frame is kept anyway
+func (f *CallstackFilter) Apply(frames []*schema.StackFrame) []*schema.StackFrame {
+	if f == nil || len(frames) == 0 {
+		return frames
+	}
+
+	result := make([]*schema.StackFrame, 0, len(frames))
+	for _, frame := range frames {
+		if f.MaxDepth > 0 && len(result) == f.MaxDepth {
+			break
+		}
+		if f.accept(frame.GetName()) {
+			result = append(result, frame)
+		}
+	}
+
+	if len(result) == 0 {
+		result = append(result, frames[0])
+	}
+
+	return result
+}
+
+func (f *CallstackFilter) accept(name string) bool {
+	for _, re := range f.Exclude {
+		if re.MatchString(name) {
+			return false
+		}
+	}
+	if len(f.Include) == 0 {
+		return true
+	}
+	for _, re := range f.Include {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewCallstackFilter compiles regular expressions and builds new filter
+func NewCallstackFilter(include, exclude []string, maxDepth int) (*CallstackFilter, error) {
+	if maxDepth < 0 {
+		return nil, fmt.Errorf("negative max depth: %d", maxDepth)
+	}
+
+	compile := func(exprs []string) ([]*regexp.Regexp, error) {
+		result := make([]*regexp.Regexp, 0, len(exprs))
+		for _, expr := range exprs {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return nil, errors.Wrapf(err, "compile expression '%s'", expr)
+			}
+			result = append(result, re)
+		}
+		return result, nil
+	}
+
+	var (
+		f   = &CallstackFilter{MaxDepth: maxDepth}
+		err error
+	)
+	if f.Include, err = compile(include); err != nil {
+		return nil, errors.Wrap(err, "include")
+	}
+	if f.Exclude, err = compile(exclude); err != nil {
+		return nil, errors.Wrap(err, "exclude")
 	}
+	return f, nil
 }
 
 // HashCallstack computes a hash value for a stack (useful for stack comparison etc.)
@@ -1 +1,95 @@
 package utils
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/memprofiler/memprofiler/schema"
+)
+
+func TestCallstackFilter(t *testing.T) {
+	frames := []*schema.StackFrame{
+		{Name: "main.allocate", File: "main.go", Line: 10},
+		{Name: "main.handler", File: "main.go", Line: 20},
+		{Name: "github.com/some/middleware.Wrap.func1", File: "middleware.go", Line: 30},
+		{Name: "net/http.HandlerFunc.ServeHTTP", File: "server.go", Line: 40},
+		{Name: "main.main", File: "main.go", Line: 50},
+	}
+
+	t.Run("Nil", func(t *testing.T) {
+		var filter *CallstackFilter
+		assert.Equal(t, frames, filter.Apply(frames))
+	})
+
+	t.Run("Exclude", func(t *testing.T) {
+		filter, err := NewCallstackFilter(nil, []string{`^net/http\.`, `middleware`}, 0)
+		if !assert.NoError(t, err) {
+			return
+		}
+		expected := []*schema.StackFrame{frames[0], frames[1], frames[4]}
+		assert.Equal(t, expected, filter.Apply(frames))
+	})
+
+	t.Run("Include", func(t *testing.T) {
+		filter, err := NewCallstackFilter([]string{`^main\.`}, []string{`^main\.main$`}, 0)
+		if !assert.NoError(t, err) {
+			return
+		}
+		expected := []*schema.StackFrame{frames[0], frames[1]}
+		assert.Equal(t, expected, filter.Apply(frames))
+	})
+
+	t.Run("MaxDepth", func(t *testing.T) {
+		filter, err := NewCallstackFilter(nil, []string{`middleware`}, 3)
+		if !assert.NoError(t, err) {
+			return
+		}
+		expected := []*schema.StackFrame{frames[0], frames[1], frames[3]}
+		assert.Equal(t, expected, filter.Apply(frames))
+	})
+
+	t.Run("AllFiltered", func(t *testing.T) {
+		filter, err := NewCallstackFilter(nil, []string{`.*`}, 0)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, frames[:1], filter.Apply(frames))
+	})
+
+	t.Run("InvalidExpression", func(t *testing.T) {
+		_, err := NewCallstackFilter([]string{`(`}, nil, 0)
+		assert.Error(t, err)
+		_, err = NewCallstackFilter(nil, nil, -1)
+		assert.Error(t, err)
+	})
+}
+
+func TestFillCallstack(t *testing.T) {
+	rawStack := make([]uintptr, 32)
+	rawStack = rawStack[:runtime.Callers(1, rawStack)]
+
+	// the stacks differ in depth, but after trimming they have the same hash
+	filter, err := NewCallstackFilter(nil, nil, 1)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	full := &schema.Callstack{}
+	FillCallstack(full, rawStack, false, nil)
+	trimmed := &schema.Callstack{}
+	FillCallstack(trimmed, rawStack, false, filter)
+	shorter := &schema.Callstack{}
+	FillCallstack(shorter, rawStack[:1], false, filter)
+
+	assert.True(t, len(full.Frames) > 1)
+	assert.Len(t, trimmed.Frames, 1)
+	assert.Equal(t, full.Frames[0], trimmed.Frames[0])
+
+	trimmedHash, err := HashCallstack(trimmed)
+	assert.NoError(t, err)
+	shorterHash, err := HashCallstack(shorter)
+	assert.NoError(t, err)
+	assert.Equal(t, trimmedHash, shorterHash)
+}